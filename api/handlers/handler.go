@@ -5,6 +5,11 @@ import (
 
 	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/health"
+	"github.com/AsaHero/abclinic/internal/pkg/loginguard"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
+	"github.com/AsaHero/abclinic/internal/pkg/token"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
@@ -14,12 +19,20 @@ type HandlerArguments struct {
 	Config              *config.Config
 	Logger              *zap.Logger
 	Enforcer            *casbin.Enforcer
+	Denylist            *denylist.Denylist
+	TokenKeys           *token.KeySet
+	LoginGuard          *loginguard.Guard
+	Health              *health.Checker
+	Metrics             *metrics.Metrics
 	DentistsUsecase     usecase.Denstists
 	PriceListUsecase    usecase.PriceList
 	InfoUsecase         usecase.InfoUsecase
 	BlogsUsecase        usecase.Blogs
 	RbacUsecase         usecase.Rbac
 	RefreshTokenUsecase usecase.RefreshToken
+	MFAUsecase          usecase.MFA
+	SSOUsecase          usecase.SSO
+	APIKeysUsecase      usecase.APIKeys
 }
 
 type BaseHandler struct{}
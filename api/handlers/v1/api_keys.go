@@ -0,0 +1,253 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	errorsapi "github.com/AsaHero/abclinic/api/errors"
+	"github.com/AsaHero/abclinic/api/handlers"
+	"github.com/AsaHero/abclinic/api/middleware"
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/usecase"
+	"github.com/casbin/casbin/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"go.uber.org/zap"
+)
+
+type apiKeysHandler struct {
+	handlers.BaseHandler
+	apiKeysUsecase usecase.APIKeys
+	logger         *zap.Logger
+	config         *config.Config
+	enforcer       *casbin.Enforcer
+}
+
+func NewAPIKeysHandler(options handlers.HandlerArguments) http.Handler {
+	handler := apiKeysHandler{
+		apiKeysUsecase: options.APIKeysUsecase,
+		logger:         options.Logger,
+		config:         options.Config,
+		enforcer:       options.Enforcer,
+	}
+
+	router := chi.NewRouter()
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Authorizer(handler.enforcer, options.Metrics))
+
+		r.Get("/", handler.ListAPIKeys())
+		r.Post("/", handler.CreateAPIKey())
+		r.Post("/{id}/rotate", handler.RotateAPIKey())
+		r.Delete("/{id}", handler.RevokeAPIKey())
+	})
+
+	return router
+}
+
+// ListAPIKeys
+// @Security ApiKeyAuth
+// @Router /v1/api-keys [GET]
+// @Summary List api keys
+// @Description List api keys of machine clients, revoked keys are included
+// @Tags ApiKeys
+// @Accept json
+// @Produce json
+// @Param role query string false "role"
+// @Param revoked query string false "true or false"
+// @Success 200 {object} []models.APIKeyResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h apiKeysHandler) ListAPIKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		filter := entity.APIKeysFilter{}
+		if role := r.URL.Query().Get("role"); role != "" {
+			filter.Roles = []string{role}
+		}
+		if value := r.URL.Query().Get("revoked"); value != "" {
+			revoked, err := strconv.ParseBool(value)
+			if err != nil {
+				render.Render(w, r, &errorsapi.ErrResponse{
+					Err:            err,
+					HTTPStatusCode: http.StatusBadRequest,
+					ErrorText:      "revoked must be true or false",
+				})
+				return
+			}
+			filter.Revoked = &revoked
+		}
+
+		apiKeys, err := h.apiKeysUsecase.List(ctx, filter)
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := []models.APIKeyResponse{}
+		for _, v := range apiKeys {
+			response = append(response, models.APIKeyResponse{
+				GUID:       v.GUID,
+				Name:       v.Name,
+				Prefix:     v.Prefix,
+				Role:       v.Role,
+				Scopes:     v.Scopes,
+				CreatedBy:  v.CreatedBy,
+				LastUsedAt: v.LastUsedAt,
+				ExpiresAt:  v.ExpiresAt,
+				RevokedAt:  v.RevokedAt,
+				CreatedAt:  v.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// CreateAPIKey
+// @Security ApiKeyAuth
+// @Router /v1/api-keys [POST]
+// @Summary Create api key
+// @Description Create api key with a role and read or write scopes, the key is returned only once
+// @Tags ApiKeys
+// @Accept json
+// @Produce json
+// @Param body body models.CreateAPIKeyRequest true "body"
+// @Success 200 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h apiKeysHandler) CreateAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.CreateAPIKeyRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		claims, _ := h.GetAuthData(ctx)
+
+		apiKey := &entity.APIKeys{
+			Name:      request.Name,
+			Role:      request.Role,
+			Scopes:    request.Scopes,
+			CreatedBy: claims["user_id"],
+			ExpiresAt: request.ExpiresAt,
+		}
+
+		key, err := h.apiKeysUsecase.Create(ctx, apiKey)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrInvalidAPIKeyReq) {
+				status = http.StatusBadRequest
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.CreateAPIKeyResponse{
+			GUID: apiKey.GUID,
+			Key:  key,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// RotateAPIKey
+// @Security ApiKeyAuth
+// @Router /v1/api-keys/{id}/rotate [POST]
+// @Summary Rotate api key
+// @Description Issue a new secret for the key, the old one stops working immediately
+// @Tags ApiKeys
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.CreateAPIKeyResponse
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h apiKeysHandler) RotateAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		key, err := h.apiKeysUsecase.Rotate(ctx, guid)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, usecase.ErrAPIKeyRevoked):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.CreateAPIKeyResponse{
+			GUID: guid,
+			Key:  key,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// RevokeAPIKey
+// @Security ApiKeyAuth
+// @Router /v1/api-keys/{id} [DELETE]
+// @Summary Revoke api key
+// @Description Revoke api key
+// @Tags ApiKeys
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h apiKeysHandler) RevokeAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		if err := h.apiKeysUsecase.Revoke(ctx, guid); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errorspkg.ErrorNotFound) {
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
@@ -0,0 +1,79 @@
+package v1_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+)
+
+func TestAPIKeys(t *testing.T) {
+	env := itest.New(t, func(cfg *config.Config) {
+		cfg.RateLimit.Enabled = true
+		cfg.RateLimit.Rules = []string{"GET /v1/rbac/users 2/1m identity"}
+		cfg.RateLimit.APIKeyFailures = "3/1m"
+	})
+	admin := env.Login(t, entity.RoleAdmin)
+
+	createKey := func(name string) string {
+		t.Helper()
+
+		resp := env.Request(t, http.MethodPost, "/v1/api-keys", admin, models.CreateAPIKeyRequest{
+			Name:   name,
+			Role:   entity.RoleAdmin,
+			Scopes: []string{entity.ScopeRead, entity.ScopeWrite},
+		})
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create api key: got %d %s", resp.StatusCode, resp.Body)
+		}
+		var created models.CreateAPIKeyResponse
+		resp.Decode(t, &created)
+		return created.Key
+	}
+	key := createKey("integration")
+	otherKey := createKey("other integration")
+
+	// routes of the current user need a user, an api key has none
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPut, "/v1/rbac/me/password"},
+		{http.MethodPost, "/v1/rbac/me/2fa"},
+		{http.MethodPost, "/v1/rbac/me/2fa/confirm"},
+		{http.MethodDelete, "/v1/rbac/me/2fa"},
+	} {
+		if resp := env.RequestWithAPIKey(t, route.method, route.path, key, nil); resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s %s with api key: got %d, want %d", route.method, route.path, resp.StatusCode, http.StatusForbidden)
+		}
+	}
+
+	unknownKey := func(prefix string) string {
+		return "abk_" + prefix + "_" + strings.Repeat("A", 43)
+	}
+
+	// every verified key has its own bucket, unknown keys share the bucket of the address,
+	// failed lookups of the address are limited on their own
+	tests := []struct {
+		name string
+		path string
+		key  string
+		want int
+	}{
+		{"first request", "/v1/rbac/users", key, http.StatusOK},
+		{"second request", "/v1/rbac/users", key, http.StatusOK},
+		{"over the quota", "/v1/rbac/users", key, http.StatusTooManyRequests},
+		{"other key", "/v1/rbac/users", otherKey, http.StatusOK},
+		{"unknown key", "/v1/rbac/users", unknownKey("000000000000"), http.StatusUnauthorized},
+		{"malformed key", "/v1/rbac/users", "abk_000000000001_short", http.StatusUnauthorized},
+		{"unknown keys throttled", "/v1/rbac/users", unknownKey("000000000002"), http.StatusTooManyRequests},
+		{"key of address out of failures", "/v1/dentists", key, http.StatusTooManyRequests},
+		{"address out of failures without key", "/v1/dentists", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if resp := env.RequestWithAPIKey(t, http.MethodGet, tt.path, tt.key, nil); resp.StatusCode != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.name, resp.StatusCode, resp.Body, tt.want)
+		}
+	}
+}
@@ -1,15 +1,26 @@
 package v1
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	errorsapi "github.com/AsaHero/abclinic/api/errors"
 	"github.com/AsaHero/abclinic/api/handlers"
+	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
 	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/loginguard"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
 	"github.com/AsaHero/abclinic/internal/pkg/token"
 	"github.com/AsaHero/abclinic/internal/pkg/validation"
 	"github.com/AsaHero/abclinic/internal/usecase"
@@ -18,9 +29,28 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// ssoStateCookie binds sso state to the browser which started login
+	ssoStateCookie = "sso_state"
+	ssoCookiePath  = "/v1/sso"
+)
+
+var (
+	errInvalidCredentials     = errors.New("invalid username or password")
+	errPasswordChangeRequired = errors.New("password change required, send new_password")
+	errInvalidMFAToken        = errors.New("invalid or expired mfa token")
+)
+
 type authHandler struct {
+	handlers.BaseHandler
 	rbacUsecase          usecase.Rbac
 	reshreshTokenUsecase usecase.RefreshToken
+	mfaUsecase           usecase.MFA
+	ssoUsecase           usecase.SSO
+	denylist             *denylist.Denylist
+	tokenKeys            *token.KeySet
+	loginGuard           *loginguard.Guard
+	metrics              *metrics.Metrics
 	logger               *zap.Logger
 	config               *config.Config
 }
@@ -29,6 +59,12 @@ func NewAuthHandler(option handlers.HandlerArguments) http.Handler {
 	handler := authHandler{
 		rbacUsecase:          option.RbacUsecase,
 		reshreshTokenUsecase: option.RefreshTokenUsecase,
+		mfaUsecase:           option.MFAUsecase,
+		ssoUsecase:           option.SSOUsecase,
+		denylist:             option.Denylist,
+		tokenKeys:            option.TokenKeys,
+		loginGuard:           option.LoginGuard,
+		metrics:              option.Metrics,
 		logger:               option.Logger,
 		config:               option.Config,
 	}
@@ -38,8 +74,12 @@ func NewAuthHandler(option handlers.HandlerArguments) http.Handler {
 	router.Group(func(r chi.Router) {
 		// public apis
 		r.Post("/login", handler.Login())
+		r.Post("/login/mfa", handler.LoginMFA())
+		r.Post("/login/mfa/enroll", handler.EnrollMFA())
+		r.Get("/sso/login", handler.SSOLogin())
+		r.Post("/sso/callback", handler.SSOCallback())
 		r.Post("/refresh", handler.RefreshToken())
-
+		r.Post("/logout", handler.Logout())
 	})
 	return router
 }
@@ -51,7 +91,10 @@ func NewAuthHandler(option handlers.HandlerArguments) http.Handler {
 // @Produce json
 // @Param body body models.LoginRequest true "body"
 // @Success 200 {object} models.LoginResponse
-// @Failure 404 {object} models.ResponseError
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
 // @Failure 500 {object} models.ResponseError
 func (h authHandler) Login() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -67,39 +110,408 @@ func (h authHandler) Login() http.HandlerFunc {
 			return
 		}
 
+		ip := middleware.ClientIP(r)
+
+		// the attempt is counted before the password is checked, concurrent attempts can't skip the backoff
+		if wait, err := h.loginGuard.Reserve(request.Username, ip); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusTooManyRequests,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
 		isExists, user, err := h.rbacUsecase.UsernameExists(ctx, request.Username)
 		if err != nil {
-			h.logger.Error("error on Login/ rbacUsecase.UsernameExists", zap.Error(err))
+			h.loginGuard.Release(request.Username, ip)
+			logger.FromContext(r.Context()).Error("error on Login/ rbacUsecase.UsernameExists", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		// unknown usernames cost the same bcrypt check, so response time does not reveal them
+		passwordHash := validation.DummyPasswordHash
+		if isExists {
+			passwordHash = user.Password
+		}
+
+		if ok := validation.CheckPasswordHash(request.Password, passwordHash); !ok || !isExists {
+			h.loginGuard.Fail(request.Username, ip)
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errInvalidCredentials,
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      errInvalidCredentials.Error(),
+			})
+			return
+		}
+
+		h.loginGuard.Succeed(request.Username, ip)
+
+		// password reset by admin is a one-time token, an expired one can't start login at all
+		if user.MustChangePassword && user.PasswordExpiresAt != nil && time.Now().After(*user.PasswordExpiresAt) {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            usecase.ErrPasswordExpired,
+				HTTPStatusCode: http.StatusForbidden,
+				ErrorText:      usecase.ErrPasswordExpired.Error(),
+			})
+			return
+		}
+
+		// second factor is checked with a challenge token before any access token is issued
+		if !h.checkMFA(w, r, user) {
+			return
+		}
+
+		if !h.changeForcedPassword(w, r, user, request.NewPassword) {
+			return
+		}
+
+		access, refresh, err := h.reshreshTokenUsecase.GenerateToken(ctx, user.Role, user.GUID, h.tokenKeys, h.config.Token.AccessTTL, h.config.Token.RefreshTTL)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("error on Login/ token.GenerateToken", zap.Error(err))
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+		h.countTokens("login")
+
+		response := models.LoginResponse{
+			AccessToken:  access,
+			RefreshToken: refresh,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// LoginMFA
+// @Router /v1/login/mfa [POST]
+// @Summary Complete login with second factor
+// @Description Exchange mfa token and a TOTP or recovery code for tokens, the first valid code completes pending enrollment and returns recovery codes
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.MFALoginRequest true "body"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h authHandler) LoginMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.MFALoginRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("cannot parse request body"),
 				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      "cannot parse request body",
+			})
+			return
+		}
+
+		claims, ok := h.parseMFAToken(request.MFAToken)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errInvalidMFAToken,
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      errInvalidMFAToken.Error(),
+			})
+			return
+		}
+
+		user, err := h.rbacUsecase.GetUser(ctx, entity.UsersFilter{GUIDs: []string{claims.UserID}})
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errInvalidMFAToken,
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      errInvalidMFAToken.Error(),
+			})
+			return
+		}
+
+		// the code isn't spent on a login which can't complete without a new password
+		if user.MustChangePassword && request.NewPassword == "" {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errPasswordChangeRequired,
+				HTTPStatusCode: http.StatusForbidden,
+				ErrorText:      errPasswordChangeRequired.Error(),
+			})
+			return
+		}
+
+		ip := middleware.ClientIP(r)
+
+		if wait, err := h.loginGuard.Reserve(user.Username, ip); err != nil {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusTooManyRequests,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		var recoveryCodes []string
+		if user.TOTPEnabled {
+			err = h.mfaUsecase.Verify(ctx, user.GUID, request.Code)
+		} else {
+			recoveryCodes, err = h.mfaUsecase.Confirm(ctx, user.GUID, request.Code)
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidMFACode):
+				h.loginGuard.Fail(user.Username, ip)
+				status = http.StatusUnauthorized
+			case errors.Is(err, usecase.ErrMFANotEnrolled):
+				h.loginGuard.Release(user.Username, ip)
+				status = http.StatusForbidden
+			default:
+				h.loginGuard.Release(user.Username, ip)
+				logger.FromContext(r.Context()).Error("error on LoginMFA/ mfaUsecase.Verify", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		h.loginGuard.Succeed(user.Username, ip)
+
+		if !h.changeForcedPassword(w, r, user, request.NewPassword) {
+			return
+		}
+
+		// challenge token is single use
+		h.denylist.RevokeToken(claims.ID, h.config.MFA.ChallengeTTL)
+
+		access, refresh, err := h.reshreshTokenUsecase.GenerateToken(ctx, user.Role, user.GUID, h.tokenKeys, h.config.Token.AccessTTL, h.config.Token.RefreshTTL)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("error on LoginMFA/ token.GenerateToken", zap.Error(err))
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
 				ErrorText:      err.Error(),
 			})
 			return
 		}
+		h.countTokens("mfa")
 
-		if !isExists {
+		response := models.LoginResponse{
+			AccessToken:   access,
+			RefreshToken:  refresh,
+			RecoveryCodes: recoveryCodes,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// EnrollMFA
+// @Router /v1/login/mfa/enroll [POST]
+// @Summary Enroll second factor during login
+// @Description Generate TOTP secret for a user whose role requires two-factor authentication
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.MFAEnrollRequest true "body"
+// @Success 200 {object} models.MFAEnrollResponse
+// @Failure 401 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h authHandler) EnrollMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.MFAEnrollRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
-				Err:            errors.New("invalid username"),
+				Err:            errors.New("cannot parse request body"),
 				HTTPStatusCode: http.StatusBadRequest,
-				ErrorText:      "invalid username",
+				ErrorText:      "cannot parse request body",
 			})
 			return
 		}
 
-		ok := validation.CheckPasswordHash(request.Password, user.Password)
+		claims, ok := h.parseMFAToken(request.MFAToken)
 		if !ok {
 			render.Render(w, r, &errorsapi.ErrResponse{
-				Err:            errors.New("invalid password"),
+				Err:            errInvalidMFAToken,
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      errInvalidMFAToken.Error(),
+			})
+			return
+		}
+
+		secret, uri, err := h.mfaUsecase.Enroll(ctx, claims.UserID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrMFAAlreadyEnabled) {
+				status = http.StatusConflict
+			} else {
+				logger.FromContext(r.Context()).Error("error on EnrollMFA/ mfaUsecase.Enroll", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.MFAEnrollResponse{
+			Secret:     secret,
+			OtpauthURI: uri,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// SSOLogin
+// @Router /v1/sso/login [GET]
+// @Summary Start single sign-on
+// @Description Get identity provider URL to send the user to, provider redirects back to the frontend with code and state. The state is also set as HttpOnly sso_state cookie which callback requires
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.SSOLoginResponse
+// @Failure 404 {object} models.ResponseError
+// @Failure 502 {object} models.ResponseError
+func (h authHandler) SSOLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		authURL, state, err := h.ssoUsecase.AuthorizationURL(ctx)
+		if err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, usecase.ErrSSODisabled) {
+				status = http.StatusNotFound
+			} else {
+				logger.FromContext(r.Context()).Error("error on SSOLogin/ ssoUsecase.AuthorizationURL", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		// callback is accepted only from the browser which started login, so nobody can log it in to their account
+		http.SetCookie(w, &http.Cookie{
+			Name:     ssoStateCookie,
+			Value:    state,
+			Path:     ssoCookiePath,
+			MaxAge:   int(h.config.OIDC.StateTTL.Seconds()),
+			Secure:   strings.HasPrefix(h.config.OIDC.RedirectURL, "https://"),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		response := models.SSOLoginResponse{
+			AuthorizationURL: authURL,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// SSOCallback
+// @Router /v1/sso/callback [POST]
+// @Summary Complete single sign-on
+// @Description Exchange code and state returned by identity provider for tokens, the user is created on first login. The request must carry sso_state cookie of the login, a role requiring two-factor authentication gets mfa challenge instead of tokens
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.SSOCallbackRequest true "body"
+// @Success 200 {object} models.LoginResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h authHandler) SSOCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.SSOCallbackRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("cannot parse request body"),
 				HTTPStatusCode: http.StatusBadRequest,
-				ErrorText:      "invalid password",
+				ErrorText:      "cannot parse request body",
 			})
 			return
 		}
 
-		access, refresh, err := h.reshreshTokenUsecase.GenerateToken(ctx, user.Role, user.GUID, h.config.Token.Secret, h.config.Token.AccessTTL, h.config.Token.RefreshTTL)
+		cookie, err := r.Cookie(ssoStateCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(request.State)) != 1 {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            usecase.ErrInvalidSSOState,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      usecase.ErrInvalidSSOState.Error(),
+			})
+			return
+		}
+
+		// state is single use
+		http.SetCookie(w, &http.Cookie{
+			Name:     ssoStateCookie,
+			Path:     ssoCookiePath,
+			MaxAge:   -1,
+			HttpOnly: true,
+		})
+
+		user, err := h.ssoUsecase.Callback(ctx, request.Code, request.State)
 		if err != nil {
-			h.logger.Error("error on Login/ token.GenerateToken", zap.Error(err))
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrSSOExchange):
+				logger.FromContext(r.Context()).Warn("SSOCallback/ ssoUsecase.Callback", zap.Error(err))
+				status = http.StatusUnauthorized
+			case errors.Is(err, usecase.ErrSSODisabled):
+				status = http.StatusNotFound
+			case errors.Is(err, usecase.ErrInvalidSSOState):
+				status = http.StatusBadRequest
+			case errors.Is(err, usecase.ErrSSONoRole):
+				status = http.StatusForbidden
+			case errors.Is(err, usecase.ErrSSOUsernameTaken):
+				status = http.StatusConflict
+			default:
+				logger.FromContext(r.Context()).Error("error on SSOCallback/ ssoUsecase.Callback", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		// identity provider login is the first factor only, the role may require the second one
+		if !h.checkMFA(w, r, user) {
+			return
+		}
+
+		access, refresh, err := h.reshreshTokenUsecase.GenerateToken(ctx, user.Role, user.GUID, h.tokenKeys, h.config.Token.AccessTTL, h.config.Token.RefreshTTL)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("error on SSOCallback/ token.GenerateToken", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -107,6 +519,7 @@ func (h authHandler) Login() http.HandlerFunc {
 			})
 			return
 		}
+		h.countTokens("sso")
 
 		response := models.LoginResponse{
 			AccessToken:  access,
@@ -117,6 +530,98 @@ func (h authHandler) Login() http.HandlerFunc {
 	}
 }
 
+// checkMFA renders mfa challenge if the user has to pass the second factor, it returns false if no tokens can be
+// issued yet
+func (h authHandler) checkMFA(w http.ResponseWriter, r *http.Request, user *entity.Users) bool {
+	mfaRequired, err := h.mfaUsecase.IsRequired(r.Context(), user.Role)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("error on checkMFA/ mfaUsecase.IsRequired", zap.Error(err))
+		render.Render(w, r, &errorsapi.ErrResponse{
+			Err:            err,
+			HTTPStatusCode: http.StatusInternalServerError,
+			ErrorText:      err.Error(),
+		})
+		return false
+	}
+
+	if !user.TOTPEnabled && !mfaRequired {
+		return true
+	}
+
+	mfaToken, err := token.GenerateMFAToken(user.Role, user.GUID, h.tokenKeys, h.config.MFA.ChallengeTTL)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("error on checkMFA/ token.GenerateMFAToken", zap.Error(err))
+		render.Render(w, r, &errorsapi.ErrResponse{
+			Err:            err,
+			HTTPStatusCode: http.StatusInternalServerError,
+			ErrorText:      err.Error(),
+		})
+		return false
+	}
+
+	// the forced password change waits for the second factor, it's sent along with the code
+	render.JSON(w, r, models.LoginResponse{
+		MFARequired:            true,
+		MFAEnrollmentRequired:  !user.TOTPEnabled,
+		MFAToken:               mfaToken,
+		PasswordChangeRequired: user.MustChangePassword,
+	})
+	return false
+}
+
+// changeForcedPassword replaces password reset by admin once credentials are checked, it renders the error
+// and returns false if login can't go on
+func (h authHandler) changeForcedPassword(w http.ResponseWriter, r *http.Request, user *entity.Users, newPassword string) bool {
+	if !user.MustChangePassword {
+		return true
+	}
+
+	if user.PasswordExpiresAt != nil && time.Now().After(*user.PasswordExpiresAt) {
+		render.Render(w, r, &errorsapi.ErrResponse{
+			Err:            usecase.ErrPasswordExpired,
+			HTTPStatusCode: http.StatusForbidden,
+			ErrorText:      usecase.ErrPasswordExpired.Error(),
+		})
+		return false
+	}
+
+	if newPassword == "" {
+		render.Render(w, r, &errorsapi.ErrResponse{
+			Err:            errPasswordChangeRequired,
+			HTTPStatusCode: http.StatusForbidden,
+			ErrorText:      errPasswordChangeRequired.Error(),
+		})
+		return false
+	}
+
+	if err := h.rbacUsecase.SetPassword(r.Context(), user.GUID, newPassword); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, validation.ErrPasswordPolicy) {
+			status = http.StatusBadRequest
+		} else {
+			logger.FromContext(r.Context()).Error("error on changeForcedPassword/ rbacUsecase.SetPassword", zap.Error(err))
+		}
+		render.Render(w, r, &errorsapi.ErrResponse{
+			Err:            err,
+			HTTPStatusCode: status,
+			ErrorText:      err.Error(),
+		})
+		return false
+	}
+
+	return true
+}
+
+// parseMFAToken accepts only unused challenge tokens
+func (h authHandler) parseMFAToken(mfaToken string) (*token.Claims, bool) {
+	claims, err := token.ParseJwtToken(mfaToken, h.tokenKeys, token.TypeMFA)
+	if err != nil || h.denylist.IsTokenRevoked(claims.ID) {
+		return nil, false
+	}
+
+	return claims, true
+}
+
 // RefreshToken
 // @Router /v1/refresh [POST]
 // @Tags Auth
@@ -131,7 +636,7 @@ func (h authHandler) RefreshToken() http.HandlerFunc {
 		requestBody := models.RefreshTokenRequest{}
 		err := json.NewDecoder(r.Body).Decode(&requestBody)
 		if err != nil {
-			h.logger.Error("investorHandler/RefreshToken/Decode", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on RefreshToken/ json.Decode", zap.Error(err))
 			http.Error(w, "invalid request body", http.StatusBadRequest)
 			return
 		}
@@ -140,74 +645,50 @@ func (h authHandler) RefreshToken() http.HandlerFunc {
 
 		tokenEntity, err := h.reshreshTokenUsecase.Get(ctx, requestBody.RefreshToken)
 		if err != nil && !errors.Is(err, errorspkg.ErrorNotFound) {
-			h.logger.Error("investorHandler/RefreshToken/Get", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on RefreshToken/ reshreshTokenUsecase.Get", zap.Error(err))
 			http.Error(w, "invalid token", http.StatusBadRequest)
 			return
 		}
 		if errors.Is(err, errorspkg.ErrorNotFound) {
-			h.logger.Error("investorHandler/RefreshToken/GenerateToken", zap.Error(err))
+			logger.FromContext(r.Context()).Warn("RefreshToken/ refresh token not found")
 			http.Error(w, "no such token", http.StatusBadRequest)
 			return
 		}
 
-		claims, err := token.ParseJwtToken(tokenEntity.RefreshToken, h.config.Token.Secret)
+		claims, err := token.ParseJwtToken(tokenEntity.RefreshToken, h.tokenKeys, token.TypeRefresh)
 		if err != nil {
-			h.logger.Error("investorHandler/RefreshToken/ParseJwtToken", zap.Error(err))
+			logger.FromContext(r.Context()).Warn("RefreshToken/ token.ParseJwtToken", zap.Error(err))
 			http.Error(w, "invalid authorization token", http.StatusBadRequest)
 			return
 		}
 
-		sub, ok := claims["sub"]
-		if !ok {
-			h.logger.Error("investorHandler/RefreshToken/sub", zap.Error(err))
-			http.Error(w, "not authorized", http.StatusBadRequest)
-			return
-		}
-
-		role, ok := sub.(string)
-		if !ok {
-			render.Render(w, r, &errorsapi.ErrResponse{
-				Err:            errors.New("failed to fetch authentication data(role)"),
-				HTTPStatusCode: http.StatusUnauthorized,
-				ErrorText:      "failed to fetch authentication data (role)",
-			})
-			return
-		}
-
-		userID, ok := claims["user_id"]
-		if !ok {
-			h.logger.Error("investorHandler/RefreshToken/sub", zap.Error(err))
-			http.Error(w, "not authorized", http.StatusBadRequest)
-			return
-		}
-
-		userIDstr, ok := userID.(string)
-		if !ok {
+		if claims.Role() == "" || claims.UserID == "" {
 			render.Render(w, r, &errorsapi.ErrResponse{
-				Err:            errors.New("failed to fetch authentication data (id)"),
+				Err:            errors.New("failed to fetch authentication data"),
 				HTTPStatusCode: http.StatusUnauthorized,
-				ErrorText:      "failed to fetch authentication data (id)",
+				ErrorText:      "failed to fetch authentication data",
 			})
 			return
 		}
 
 		accessToken, refreshToken, err := h.reshreshTokenUsecase.GenerateToken(
 			ctx,
-			role,
-			userIDstr,
-			h.config.Token.Secret,
+			claims.Role(),
+			claims.UserID,
+			h.tokenKeys,
 			h.config.Token.AccessTTL,
 			h.config.Token.RefreshTTL,
 		)
 		if err != nil {
-			h.logger.Error("investorHandler/RefreshToken/GenerateToken", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on RefreshToken/ token.GenerateToken", zap.Error(err))
 			http.Error(w, "failed to generate token", http.StatusInternalServerError)
 			return
 		}
+		h.countTokens("refresh")
 
 		err = h.reshreshTokenUsecase.Delete(ctx, tokenEntity.RefreshToken)
 		if err != nil {
-			h.logger.Error("investorHandler/RefreshToken/Delete", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on RefreshToken/ reshreshTokenUsecase.Delete", zap.Error(err))
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -219,3 +700,77 @@ func (h authHandler) RefreshToken() http.HandlerFunc {
 		render.JSON(w, r, response)
 	}
 }
+
+// Logout
+// @Security ApiKeyAuth
+// @Router /v1/logout [POST]
+// @Summary Logout
+// @Description Revoke current access token and its refresh token
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param body body models.LogoutRequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h authHandler) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, ok := h.GetAuthData(ctx)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("failed to fetch authentication data"),
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      "failed to fetch authentication data",
+			})
+			return
+		}
+
+		request := models.LogoutRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("cannot parse request body"),
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      "cannot parse request body",
+			})
+			return
+		}
+
+		// refresh token may be already rotated or expired, logout still succeeds,
+		// but a token of another user is never deleted
+		var refreshToken *entity.RefreshToken
+		if request.RefreshToken != "" {
+			stored, err := h.reshreshTokenUsecase.Get(ctx, request.RefreshToken)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("Logout/ reshreshTokenUsecase.Get", zap.Error(err))
+			} else if stored.UserID != claims["user_id"] {
+				render.Render(w, r, &errorsapi.ErrResponse{
+					Err:            errors.New("refresh token belongs to another user"),
+					HTTPStatusCode: http.StatusForbidden,
+					ErrorText:      "refresh token belongs to another user",
+				})
+				return
+			} else {
+				refreshToken = stored
+			}
+		}
+
+		h.denylist.RevokeToken(claims["jti"], h.config.Token.AccessTTL)
+
+		if refreshToken != nil {
+			if err := h.reshreshTokenUsecase.Delete(ctx, refreshToken.RefreshToken); err != nil {
+				logger.FromContext(r.Context()).Warn("Logout/ reshreshTokenUsecase.Delete", zap.Error(err))
+			}
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+func (h *authHandler) countTokens(flow string) {
+	if h.metrics != nil {
+		h.metrics.TokensIssued.WithLabelValues(flow).Inc()
+	}
+}
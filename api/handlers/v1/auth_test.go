@@ -0,0 +1,295 @@
+package v1_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+	"github.com/AsaHero/abclinic/internal/pkg/totp"
+)
+
+func TestAuth(t *testing.T) {
+	env := itest.New(t)
+
+	if _, err := env.Admin.RbacUsecase.CreateUser(context.Background(), &entity.Users{
+		Role:     entity.RoleSecretary,
+		Username: "reception",
+		Password: itest.Password,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "reception", Password: "wrong"})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "reception", Password: itest.Password})
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("login within backoff: got %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	time.Sleep(env.Config.Login.BaseDelay)
+
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "reception", Password: itest.Password})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var login models.LoginResponse
+	resp.Decode(t, &login)
+	if login.AccessToken == "" || login.RefreshToken == "" {
+		t.Fatalf("login: tokens are not issued: %s", resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", login.AccessToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("user info: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var user models.GetUserInfoResponse
+	resp.Decode(t, &user)
+	if user.Role != entity.RoleSecretary || user.Username != "reception" {
+		t.Errorf("user info: got %+v", user)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/refresh", "", models.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("refresh: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var refreshed models.RefreshTokenResponse
+	resp.Decode(t, &refreshed)
+
+	// refresh tokens are rotated, so the used one is rejected
+	resp = env.Request(t, http.MethodPost, "/v1/refresh", "", models.RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("reused refresh token: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	// refresh token of another user is neither deleted nor the caller logged out
+	resp = env.Request(t, http.MethodPost, "/v1/logout", env.Login(t, entity.RoleAdmin), models.LogoutRequest{RefreshToken: refreshed.RefreshToken})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("logout with refresh token of another user: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", env.Login(t, entity.RoleAdmin), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("access token after rejected logout: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("refresh after rejected logout: got %d %s", resp.StatusCode, resp.Body)
+	}
+	resp.Decode(t, &refreshed)
+
+	resp = env.Request(t, http.MethodPost, "/v1/logout", refreshed.AccessToken, models.LogoutRequest{RefreshToken: refreshed.RefreshToken})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", refreshed.AccessToken, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("access token after logout: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/refresh", "", models.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("refresh token after logout: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestForcedPasswordChangeWithMFA(t *testing.T) {
+	env := itest.New(t)
+	ctx := context.Background()
+
+	userID, err := env.Admin.RbacUsecase.CreateUser(ctx, &entity.Users{
+		Role:     entity.RoleDentist,
+		Username: "doctor",
+		Password: itest.Password,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "doctor", Password: itest.Password})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var login models.LoginResponse
+	resp.Decode(t, &login)
+
+	resp = env.Request(t, http.MethodPost, "/v1/rbac/me/2fa", login.AccessToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("enroll: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var enrollment models.MFAEnrollResponse
+	resp.Decode(t, &enrollment)
+
+	code, err := totp.Code(enrollment.Secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp = env.Request(t, http.MethodPost, "/v1/rbac/me/2fa/confirm", login.AccessToken, models.MFACodeRequest{Code: code})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("confirm: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	temporary, _, err := env.Admin.RbacUsecase.ResetPassword(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// password isn't changed before the second factor, even when it's sent with the password
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "doctor", Password: temporary, NewPassword: "Changed-Password-1"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login with temporary password: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var challenge models.LoginResponse
+	resp.Decode(t, &challenge)
+	if !challenge.MFARequired || !challenge.PasswordChangeRequired || challenge.AccessToken != "" {
+		t.Fatalf("login with temporary password: got %s, want mfa challenge with password change", resp.Body)
+	}
+
+	user, err := env.Admin.RbacUsecase.GetUser(ctx, entity.UsersFilter{GUIDs: []string{userID}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.MustChangePassword {
+		t.Fatal("password is changed before the second factor")
+	}
+
+	code, err = totp.Code(enrollment.Secret, time.Now().Add(30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: code})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("second factor without new password: got %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: code, NewPassword: "Changed-Password-1"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("second factor with new password: got %d %s", resp.StatusCode, resp.Body)
+	}
+	login = models.LoginResponse{}
+	resp.Decode(t, &login)
+
+	// tokens issued right after the password change aren't denied with the revoked ones
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", login.AccessToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("user info after password change: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "doctor", Password: "Changed-Password-1"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login with new password: got %d %s", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestMFAReplayAndReset(t *testing.T) {
+	env := itest.New(t)
+	ctx := context.Background()
+
+	userID, err := env.Admin.RbacUsecase.CreateUser(ctx, &entity.Users{
+		Role:     entity.RoleDentist,
+		Username: "doctor",
+		Password: itest.Password,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// login waits out the backoff of refused codes, it doubles with every one of them
+	login := func() models.LoginResponse {
+		t.Helper()
+
+		resp := env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "doctor", Password: itest.Password})
+		for delay := env.Config.Login.BaseDelay; resp.StatusCode == http.StatusTooManyRequests && delay <= env.Config.Login.MaxDelay; delay *= 2 {
+			time.Sleep(delay)
+			resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "doctor", Password: itest.Password})
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("login: got %d %s", resp.StatusCode, resp.Body)
+		}
+		var login models.LoginResponse
+		resp.Decode(t, &login)
+		return login
+	}
+
+	admin := env.Login(t, entity.RoleAdmin)
+
+	session := login()
+	resp := env.Request(t, http.MethodPost, "/v1/rbac/me/2fa", session.AccessToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("enroll: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var enrollment models.MFAEnrollResponse
+	resp.Decode(t, &enrollment)
+
+	now := time.Now()
+	confirmed, err := totp.Code(enrollment.Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := totp.Code(enrollment.Secret, now.Add(totp.Period))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/rbac/me/2fa/confirm", session.AccessToken, models.MFACodeRequest{Code: confirmed})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("confirm: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var recovery models.MFARecoveryCodesResponse
+	resp.Decode(t, &recovery)
+	if len(recovery.RecoveryCodes) == 0 {
+		t.Fatal("confirm: got no recovery codes")
+	}
+
+	tests := []struct {
+		name string
+		code string
+		want int
+	}{
+		{"code accepted by confirmation", confirmed, http.StatusUnauthorized},
+		{"code of the next step", next, http.StatusOK},
+		{"code accepted by login", next, http.StatusUnauthorized},
+		{"recovery code", recovery.RecoveryCodes[0], http.StatusOK},
+		{"used recovery code", recovery.RecoveryCodes[0], http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		challenge := login()
+		if !challenge.MFARequired {
+			t.Fatalf("%s: login doesn't ask for the second factor", tt.name)
+		}
+
+		resp = env.Request(t, http.MethodPost, "/v1/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: tt.code})
+		if resp.StatusCode != tt.want {
+			t.Fatalf("%s: got %d %s, want %d", tt.name, resp.StatusCode, resp.Body, tt.want)
+		}
+		if resp.StatusCode == http.StatusOK {
+			resp.Decode(t, &session)
+		}
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/rbac/user/"+userID+"/2fa", admin, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("reset two-factor authentication: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", session.AccessToken, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("access token after reset: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/refresh", "", models.RefreshTokenRequest{RefreshToken: session.RefreshToken})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("refresh token after reset: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	if session = login(); session.MFARequired || session.AccessToken == "" {
+		t.Errorf("login after reset: got %+v, want tokens", session)
+	}
+}
@@ -0,0 +1,121 @@
+package v1_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+// TestAuthorization checks casbin decisions of every protected route for every role,
+// allowed requests carry no body, so they fail validation after authorization without changing data
+func TestAuthorization(t *testing.T) {
+	env := itest.New(t)
+
+	roles := []string{entity.RoleAdmin, entity.RoleDentist, entity.RoleSecretary, entity.RoleWebsite}
+	tokens := make(map[string]string)
+	for _, role := range roles {
+		tokens[role] = env.Login(t, role)
+	}
+
+	admin := []string{entity.RoleAdmin}
+	priceList := []string{entity.RoleAdmin, entity.RoleSecretary}
+	info := []string{entity.RoleAdmin, entity.RoleSecretary}
+	blogs := []string{entity.RoleAdmin, entity.RoleDentist}
+	staff := []string{entity.RoleAdmin, entity.RoleDentist, entity.RoleSecretary}
+
+	tests := []struct {
+		method  string
+		path    string
+		allowed []string
+	}{
+		{http.MethodPut, "/v1/dentists/1", admin},
+
+		{http.MethodPost, "/v1/services", priceList},
+		{http.MethodPut, "/v1/services/" + missingGUID, priceList},
+		{http.MethodDelete, "/v1/services/" + missingGUID, priceList},
+		{http.MethodPost, "/v1/services/" + missingGUID + "/history", priceList},
+		{http.MethodDelete, "/v1/services/" + missingGUID + "/history/" + missingGUID, priceList},
+		{http.MethodPost, "/v1/services/groups", priceList},
+		{http.MethodPut, "/v1/services/groups/" + missingGUID, priceList},
+		{http.MethodDelete, "/v1/services/groups/" + missingGUID, priceList},
+		{http.MethodPost, "/v1/services/tiers", admin},
+		{http.MethodPut, "/v1/services/tiers/" + missingGUID, admin},
+		{http.MethodDelete, "/v1/services/tiers/" + missingGUID, admin},
+		{http.MethodPost, "/v1/services/rates", admin},
+		{http.MethodPut, "/v1/services/rates/" + missingGUID, admin},
+		{http.MethodDelete, "/v1/services/rates/" + missingGUID, admin},
+
+		{http.MethodPost, "/v1/articles", info},
+		{http.MethodPut, "/v1/articles/" + missingGUID, info},
+		{http.MethodDelete, "/v1/articles/" + missingGUID, info},
+		{http.MethodPost, "/v1/articles/chapter", info},
+		{http.MethodPut, "/v1/articles/chapter/" + missingGUID, info},
+		{http.MethodDelete, "/v1/articles/chapter/" + missingGUID, info},
+
+		{http.MethodPost, "/v1/blogs", blogs},
+		{http.MethodPut, "/v1/blogs/" + missingGUID, blogs},
+		{http.MethodDelete, "/v1/blogs/" + missingGUID, blogs},
+		{http.MethodPost, "/v1/blogs/" + missingGUID + "/publication", blogs},
+		{http.MethodPut, "/v1/blogs/publication/" + missingGUID, blogs},
+		{http.MethodDelete, "/v1/blogs/publication/" + missingGUID, blogs},
+
+		{http.MethodPost, "/v1/authors", blogs},
+		{http.MethodPut, "/v1/authors/" + missingGUID, blogs},
+		{http.MethodDelete, "/v1/authors/" + missingGUID, blogs},
+
+		{http.MethodPost, "/v1/file", staff},
+		{http.MethodDelete, "/v1/file", admin},
+
+		{http.MethodGet, "/v1/rbac/roles", staff},
+		{http.MethodGet, "/v1/rbac/user", staff},
+		{http.MethodPut, "/v1/rbac/me/password", staff},
+		{http.MethodGet, "/v1/rbac/users", admin},
+		{http.MethodPost, "/v1/rbac/user", admin},
+		{http.MethodPut, "/v1/rbac/user/" + missingGUID, admin},
+		{http.MethodDelete, "/v1/rbac/user/" + missingGUID, admin},
+		{http.MethodPost, "/v1/rbac/user/" + missingGUID + "/unlock", admin},
+		{http.MethodPost, "/v1/rbac/user/" + missingGUID + "/reset-password", admin},
+		{http.MethodDelete, "/v1/rbac/user/" + missingGUID + "/2fa", admin},
+		{http.MethodGet, "/v1/rbac/roles/2fa", admin},
+		{http.MethodPut, "/v1/rbac/roles/" + entity.RoleWebsite + "/2fa", admin},
+
+		{http.MethodGet, "/v1/api-keys", admin},
+		{http.MethodPost, "/v1/api-keys", admin},
+		{http.MethodPost, "/v1/api-keys/" + missingGUID + "/rotate", admin},
+		{http.MethodDelete, "/v1/api-keys/" + missingGUID, admin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if resp := env.Request(t, tt.method, tt.path, "", nil); resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("anonymous: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+			}
+
+			for _, role := range roles {
+				resp := env.Request(t, tt.method, tt.path, tokens[role], nil)
+
+				if !contains(tt.allowed, role) {
+					if resp.StatusCode != http.StatusForbidden {
+						t.Errorf("%s: got %d, want %d", role, resp.StatusCode, http.StatusForbidden)
+					}
+					continue
+				}
+
+				if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+					t.Errorf("%s: got %d, want access", role, resp.StatusCode)
+				}
+			}
+		})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -36,28 +36,6 @@ func NewAuthorsHandler(args handlers.HandlerArguments) http.Handler {
 		enforcer:     args.Enforcer,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/authors", "POST"},
-		{"admin", "/v1/authors/{id}", "PUT"},
-		{"admin", "/v1/authors/{id}", "DELETE"},
-
-		// dentist
-		{"dentist", "/v1/authors", "POST"},
-		{"dentist", "/v1/authors/{id}", "PUT"},
-		{"dentist", "/v1/authors/{id}", "DELETE"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
@@ -67,7 +45,7 @@ func NewAuthorsHandler(args handlers.HandlerArguments) http.Handler {
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, args.Metrics))
 
 		r.Post("/", handler.CreateAuthor())
 		r.Put("/{id}", handler.UpdateAuthor())
@@ -92,7 +70,7 @@ func (h authorsHandler) GetAuthorsList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		authors, err := h.blogsUsecase.ListAuthors(ctx, map[string]string{})
+		authors, err := h.blogsUsecase.ListAuthors(ctx, entity.AuthorsFilter{})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -141,6 +119,7 @@ func (h authorsHandler) GetAuthor() http.HandlerFunc {
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      "failed to fetch authentication data",
 			})
+			return
 		}
 
 		userID := claims["user_id"]
@@ -11,6 +11,7 @@ import (
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/entity"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"github.com/go-chi/chi/v5"
@@ -33,44 +34,17 @@ func NewBlogsHandler(args handlers.HandlerArguments) http.Handler {
 		blogsUsecase: args.BlogsUsecase,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/blogs", "POST"},
-		{"admin", "/v1/blogs/{id}", "PUT"},
-		{"admin", "/v1/blogs/{id}", "DELETE"},
-		{"admin", "/v1/blogs/{id}/publication", "POST"},
-		{"admin", "/v1/blogs/publication/{id}", "PUT"},
-		{"admin", "/v1/blogs/publication/{id}", "DELETE"},
-
-		// dentist
-		{"dentist", "/v1/blogs", "POST"},
-		{"dentist", "/v1/blogs/{id}", "PUT"},
-		{"dentist", "/v1/blogs/{id}", "DELETE"},
-		{"dentist", "/v1/blogs/{id}/publication", "POST"},
-		{"dentist", "/v1/blogs/publication/{id}", "PUT"},
-		{"dentist", "/v1/blogs/publication/{id}", "DELETE"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
+		r.Use(middleware.HTTPCache(handler.config.Cache.BlogsMaxAge))
 		r.Get("/", handler.GetCategoriesList())
 		r.Get("/{id}/publication", handler.GetPublicationsList())
 
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, args.Metrics))
 		// category
 
 		r.Post("/", handler.CreateCategory())
@@ -103,7 +77,7 @@ func (h blogsHandler) GetPublicationsList() http.HandlerFunc {
 
 		categoryID := chi.URLParam(r, "id")
 
-		publications, err := h.blogsUsecase.ListPublications(ctx, map[string]string{"category_id": categoryID})
+		publications, err := h.blogsUsecase.ListPublications(ctx, entity.PublicationsFilter{CategoryIDs: []string{categoryID}})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -118,7 +92,7 @@ func (h blogsHandler) GetPublicationsList() http.HandlerFunc {
 		for _, v := range publications {
 			author, err := h.blogsUsecase.GetAuthor(ctx, v.AuthorID)
 			if err != nil {
-				h.logger.Error("error on GetPublicationsList/ blogsUsecase.ListPublicationsAuthors", zap.Error(err))
+				logger.FromContext(r.Context()).Error("error on GetPublicationsList/ blogsUsecase.ListPublicationsAuthors", zap.Error(err))
 			}
 
 			publication := models.Publications{
@@ -175,7 +149,7 @@ func (h blogsHandler) CreatePublication() http.HandlerFunc {
 
 		request := models.CreatePublicationRequest{}
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			h.logger.Error("error on decoding request body", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on decoding request body", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -337,7 +311,7 @@ func (h blogsHandler) GetCategoriesList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		categories, err := h.blogsUsecase.ListPublicationsCategories(ctx, map[string]string{})
+		categories, err := h.blogsUsecase.ListPublicationsCategories(ctx, entity.CategoriesFilter{})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -0,0 +1,110 @@
+package v1_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"net/http"
+	"testing"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestBlogs(t *testing.T) {
+	env := itest.New(t)
+	token := env.Login(t, entity.RoleDentist)
+
+	resp := env.Request(t, http.MethodPost, "/v1/authors", token, models.CreateAuthorRequest{Name: "Dr. Karimova", Img: pngBase64(t)})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create author: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var author models.GUIDResponse
+	resp.Decode(t, &author)
+
+	resp = env.Request(t, http.MethodPost, "/v1/blogs", token, models.CreateCategoryRequest{
+		Title:       "Prevention",
+		Description: "How to keep teeth healthy",
+		Img:         "https://cdn.example.com/prevention.jpg",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create category: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var category models.GUIDResponse
+	resp.Decode(t, &category)
+
+	var categories []models.Categories
+	env.Request(t, http.MethodGet, "/v1/blogs", "", nil).Decode(t, &categories)
+	if len(categories) != 1 || categories[0].GUID != category.GUID || categories[0].Title != "Prevention" {
+		t.Fatalf("list categories: got %+v", categories)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/blogs/"+category.GUID+"/publication", token, models.CreatePublicationRequest{
+		AuthorID: author.GUID,
+		Title:    "Brushing",
+		Text:     "Two minutes twice a day",
+		Type:     entity.PublicationTypeVideo,
+		Video:    "https://cdn.example.com/brushing.mp4",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create publication: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var publication models.GUIDResponse
+	resp.Decode(t, &publication)
+
+	resp = env.Request(t, http.MethodPut, "/v1/blogs/publication/"+publication.GUID, token, models.UpdatePublicationRequest{
+		Title: "Brushing technique",
+		Text:  "Two minutes twice a day",
+		Type:  entity.PublicationTypeVideo,
+		Video: "https://cdn.example.com/brushing-technique.mp4",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update publication: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var publications []models.Publications
+	env.Request(t, http.MethodGet, "/v1/blogs/"+category.GUID+"/publication", "", nil).Decode(t, &publications)
+	if len(publications) != 1 {
+		t.Fatalf("list publications: got %+v", publications)
+	}
+	if got := publications[0]; got.GUID != publication.GUID || got.Author.GUID != author.GUID || got.Title != "Brushing technique" ||
+		got.Type != entity.PublicationTypeVideo || got.Video != "https://cdn.example.com/brushing-technique.mp4" {
+		t.Errorf("list publications: got %+v", got)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/blogs/publication/"+publication.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete publication: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/blogs/"+category.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete category: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/authors/"+author.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete author: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var authors []models.Authors
+	env.Request(t, http.MethodGet, "/v1/authors", "", nil).Decode(t, &authors)
+	for _, v := range authors {
+		if v.GUID == author.GUID {
+			t.Errorf("list authors after delete: %s is listed", author.GUID)
+		}
+	}
+}
+
+// pngBase64 is an image accepted as author photo
+func pngBase64(t *testing.T) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/entity"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"github.com/go-chi/chi/v5"
@@ -33,30 +34,16 @@ func NewDentistsHandler(args handlers.HandlerArguments) http.Handler {
 		dentistsUsecase: args.DentistsUsecase,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/dentists/{id}", "PUT"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
+		r.Use(middleware.HTTPCache(handler.config.Cache.DentistsMaxAge))
 		r.Get("/", handler.GetDentistsList())
 		r.Get("/{id}", handler.GetDentist())
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, args.Metrics))
 		r.Put("/{id}", handler.UpdateDentist())
 	})
 
@@ -83,7 +70,7 @@ func (h dentistsHandler) GetDentist() http.HandlerFunc {
 
 		id, err := strconv.ParseInt(param, 10, 64)
 		if err != nil {
-			h.logger.Error("error on parsing param to int", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on parsing param to int", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -94,7 +81,7 @@ func (h dentistsHandler) GetDentist() http.HandlerFunc {
 
 		dentist, err := h.dentistsUsecase.Get(ctx, id)
 		if err != nil {
-			h.logger.Error("error on GetDentistsList/dentistsUsecase.Get", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on GetDentistsList/dentistsUsecase.Get", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -132,9 +119,9 @@ func (h dentistsHandler) GetDentistsList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		dentists, err := h.dentistsUsecase.List(ctx, map[string]string{})
+		dentists, err := h.dentistsUsecase.List(ctx, entity.DentistsFilter{})
 		if err != nil {
-			h.logger.Error("error on GetDentistsList/dentistsUsecase.Get", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on GetDentistsList/dentistsUsecase.Get", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -179,7 +166,7 @@ func (h dentistsHandler) UpdateDentist() http.HandlerFunc {
 
 		request := models.UpdateDentistRequest{}
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			h.logger.Error("error on decoding request body", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on decoding request body", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -192,7 +179,7 @@ func (h dentistsHandler) UpdateDentist() http.HandlerFunc {
 
 		id, err := strconv.ParseInt(param, 10, 64)
 		if err != nil {
-			h.logger.Error("error on parsing param to int", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on parsing param to int", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -208,7 +195,7 @@ func (h dentistsHandler) UpdateDentist() http.HandlerFunc {
 			URL:  request.Img,
 		})
 		if err != nil {
-			h.logger.Error("error on UpdateDentist/dentistsUsecase.Update", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on UpdateDentist/dentistsUsecase.Update", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
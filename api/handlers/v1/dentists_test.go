@@ -0,0 +1,120 @@
+package v1_test
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestDentists(t *testing.T) {
+	env := itest.New(t)
+	env.LoadFixtures(t, "demo.yaml")
+	token := env.Login(t, entity.RoleAdmin)
+
+	var dentists []models.GetDentistsListResponse
+	env.Request(t, http.MethodGet, "/v1/dentists", "", nil).Decode(t, &dentists)
+	if len(dentists) == 0 {
+		t.Fatal("list dentists: demo fixtures are not listed")
+	}
+
+	path := "/v1/dentists/" + strconv.FormatInt(dentists[0].ID, 10)
+
+	resp := env.Request(t, http.MethodPut, path, token, models.UpdateDentistRequest{
+		Name: "Dr. Updated",
+		Info: "Orthodontist, 12 years of practice",
+		Img:  "https://cdn.example.com/updated.jpg",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update dentist: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodGet, path, "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get dentist: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var dentist models.GetDentistsListResponse
+	resp.Decode(t, &dentist)
+
+	// clone name, side and priority are not changed by update
+	want := dentists[0]
+	want.Name = "Dr. Updated"
+	want.Info = "Orthodontist, 12 years of practice"
+	want.Img = "https://cdn.example.com/updated.jpg"
+	if dentist != want {
+		t.Errorf("get dentist: got %+v, want %+v", dentist, want)
+	}
+
+	resp = env.Request(t, http.MethodGet, "/v1/dentists/not-a-number", "", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("get dentist by invalid id: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestDentistsHTTPCache(t *testing.T) {
+	env := itest.New(t)
+	env.LoadFixtures(t, "demo.yaml")
+	token := env.Login(t, entity.RoleAdmin)
+
+	resp := env.Get(t, "/v1/dentists", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list dentists: got %d %s", resp.StatusCode, resp.Body)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("list dentists: got no ETag")
+	}
+	// dentists have no modification time, the time of caching is not one
+	if got := resp.Header.Get("Last-Modified"); got != "" {
+		t.Errorf("list dentists: got Last-Modified %q, want none", got)
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "public, max-age=300" {
+		t.Errorf("list dentists: got Cache-Control %q", got)
+	}
+
+	var dentists []models.GetDentistsListResponse
+	resp.Decode(t, &dentists)
+
+	resp = env.Get(t, "/v1/dentists", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusNotModified || len(resp.Body) != 0 {
+		t.Errorf("list dentists with matching ETag: got %d %s, want %d", resp.StatusCode, resp.Body, http.StatusNotModified)
+	}
+
+	resp = env.Get(t, "/v1/dentists", http.Header{"If-None-Match": {`"other", W/` + etag}})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("list dentists with ETag among others: got %d, want %d", resp.StatusCode, http.StatusNotModified)
+	}
+
+	resp = env.Get(t, "/v1/dentists", http.Header{"If-Modified-Since": {time.Now().UTC().Format(http.TimeFormat)}})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("list dentists with If-Modified-Since: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.Header.Get("Content-Range") != "" || resp.Header.Get("Accept-Ranges") != "" {
+		t.Errorf("list dentists: got range headers %v", resp.Header)
+	}
+
+	resp = env.Request(t, http.MethodPut, "/v1/dentists/"+strconv.FormatInt(dentists[0].ID, 10), token, models.UpdateDentistRequest{
+		Name: "Dr. Cached",
+		Info: dentists[0].Info,
+		Img:  dentists[0].Img,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update dentist: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Get(t, "/v1/dentists", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list dentists after update: got %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.Header.Get("ETag") == etag {
+		t.Error("list dentists after update: ETag is not changed")
+	}
+	if !bytes.Contains(resp.Body, []byte("Dr. Cached")) {
+		t.Errorf("list dentists after update: update is not listed in %s", resp.Body)
+	}
+}
@@ -2,16 +2,21 @@ package v1
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	errorsapi "github.com/AsaHero/abclinic/api/errors"
 	"github.com/AsaHero/abclinic/api/handlers"
 	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -20,6 +25,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -27,6 +34,7 @@ type filesHandler struct {
 	logger   *zap.Logger
 	config   *config.Config
 	enforcer *casbin.Enforcer
+	metrics  *metrics.Metrics
 }
 
 func NewFilesHandler(option handlers.HandlerArguments) http.Handler {
@@ -34,35 +42,13 @@ func NewFilesHandler(option handlers.HandlerArguments) http.Handler {
 		logger:   option.Logger,
 		config:   option.Config,
 		enforcer: option.Enforcer,
+		metrics:  option.Metrics,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/file", "POST"},
-		{"admin", "/v1/file", "DELETE"},
-
-		// secretary
-		{"secretary", "/v1/file", "POST"},
-		{"secretary", "/v1/file", "DELETE"},
-
-		// dentist
-		{"dentist", "/v1/file", "POST"},
-		{"dentist", "/v1/file", "DELETE"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, option.Metrics))
 		// file
 		r.Post("/", handler.UploadFile())
 		r.Delete("/", handler.DeleteFile())
@@ -84,7 +70,7 @@ func (handler *filesHandler) UploadFile() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		_, header, err := r.FormFile("file")
 		if err != nil {
-			handler.logger.Error("error r.FormFile", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error r.FormFile", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -102,7 +88,7 @@ func (handler *filesHandler) UploadFile() http.HandlerFunc {
 
 		src, err := file.File.Open()
 		if err != nil {
-			handler.logger.Error("cannot open file from form-data", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot open file from form-data", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -124,7 +110,7 @@ func (handler *filesHandler) UploadFile() http.HandlerFunc {
 
 		newSession, err := session.NewSession(cdnConfig)
 		if err != nil {
-			handler.logger.Error("cannot create an aws session", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot create an aws session", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -147,10 +133,14 @@ func (handler *filesHandler) UploadFile() http.HandlerFunc {
 			ACL:                aws.String("public-read"),
 		}
 
-		fmt.Printf("%v\n", object)
-		_, err = cdnClient.PutObject(&object)
+		logger.FromContext(r.Context()).Debug("uploading object to cdn", zap.String("key", file.File.Filename), zap.Int64("size", file.File.Size))
+		ctx, span := startS3Span(r.Context(), "PutObject", handler.config.CDN.BucketName, file.File.Filename)
+		start := time.Now()
+		_, err = cdnClient.PutObjectWithContext(ctx, &object)
+		handler.observeUpload(file.File.Size, time.Since(start), err)
+		tracing.End(span, err)
 		if err != nil {
-			handler.logger.Error("cannot upload object to cdn", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot upload object to cdn", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -203,7 +193,7 @@ func (handler *filesHandler) DeleteFile() http.HandlerFunc {
 
 		newSession, err := session.NewSession(cdnConfig)
 		if err != nil {
-			handler.logger.Error("cannot create an aws session", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot create an aws session", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -213,12 +203,14 @@ func (handler *filesHandler) DeleteFile() http.HandlerFunc {
 		}
 		cdnClient := s3.New(newSession)
 
-		_, err = cdnClient.DeleteObject(&s3.DeleteObjectInput{
+		ctx, span := startS3Span(r.Context(), "DeleteObject", handler.config.CDN.BucketName, filename)
+		_, err = cdnClient.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
 			Bucket: aws.String(handler.config.CDN.BucketName),
 			Key:    aws.String(filename),
 		})
+		tracing.End(span, err)
 		if err != nil {
-			handler.logger.Error("cannot delete object in cdn", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot delete object in cdn", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -227,12 +219,14 @@ func (handler *filesHandler) DeleteFile() http.HandlerFunc {
 			return
 		}
 
-		err = cdnClient.WaitUntilObjectNotExists(&s3.HeadObjectInput{
+		ctx, span = startS3Span(r.Context(), "WaitUntilObjectNotExists", handler.config.CDN.BucketName, filename)
+		err = cdnClient.WaitUntilObjectNotExistsWithContext(ctx, &s3.HeadObjectInput{
 			Bucket: aws.String(handler.config.CDN.BucketName),
 			Key:    aws.String(filename),
 		})
+		tracing.End(span, err)
 		if err != nil {
-			handler.logger.Error("cannot delete object in cdn", zap.Error(err))
+			logger.FromContext(r.Context()).Error("cannot delete object in cdn", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusInternalServerError,
@@ -244,3 +238,29 @@ func (handler *filesHandler) DeleteFile() http.HandlerFunc {
 		render.JSON(w, r, models.Empty{})
 	}
 }
+
+func (handler *filesHandler) observeUpload(size int64, duration time.Duration, err error) {
+	if handler.metrics == nil {
+		return
+	}
+
+	result := "success"
+	if err != nil {
+		result = "error"
+	} else {
+		handler.metrics.UploadSize.Observe(float64(size))
+	}
+	handler.metrics.UploadDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func startS3Span(ctx context.Context, operation, bucket, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "S3 "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("aws.service", "s3"),
+			attribute.String("aws.operation", operation),
+			attribute.String("aws.s3.bucket", bucket),
+			attribute.String("aws.s3.key", key),
+		),
+	)
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/AsaHero/abclinic/api/handlers"
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/pkg/health"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type healthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(args handlers.HandlerArguments) http.Handler {
+	handler := healthHandler{
+		checker: args.Health,
+	}
+
+	router := chi.NewRouter()
+
+	router.Group(func(r chi.Router) {
+		// public apis
+		r.Get("/healthz", handler.Liveness())
+		r.Get("/readyz", handler.Readiness())
+	})
+
+	return router
+}
+
+// Liveness
+// @Router /healthz [GET]
+// @Summary Liveness probe
+// @Description Reports that the process is up, dependencies are not checked
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+func (h healthHandler) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		render.JSON(w, r, models.HealthResponse{Status: health.StatusOK})
+	}
+}
+
+// Readiness
+// @Router /readyz [GET]
+// @Summary Readiness probe
+// @Description Checks database, authorization policy and object storage, fails while the server shuts down
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthResponse
+// @Failure 503 {object} models.HealthResponse
+func (h healthHandler) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+
+		report := h.checker.Ready(r.Context())
+
+		response := models.HealthResponse{
+			Status: report.Status,
+			Checks: make([]models.HealthCheck, 0, len(report.Checks)),
+		}
+		for _, v := range report.Checks {
+			response.Checks = append(response.Checks, models.HealthCheck{
+				Name:      v.Name,
+				Status:    v.Status,
+				LatencyMs: float64(v.Latency.Microseconds()) / 1000,
+				Error:     v.Error,
+			})
+		}
+
+		if report.Status != health.StatusOK {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, response)
+	}
+}
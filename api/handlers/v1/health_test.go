@@ -0,0 +1,27 @@
+package v1_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/itest"
+	"github.com/AsaHero/abclinic/internal/pkg/health"
+)
+
+func TestHealth(t *testing.T) {
+	env := itest.New(t)
+
+	for _, path := range []string{"/healthz", "/readyz"} {
+		resp := env.Request(t, http.MethodGet, path, "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: got %d %s", path, resp.StatusCode, resp.Body)
+		}
+
+		var response models.HealthResponse
+		resp.Decode(t, &response)
+		if response.Status != health.StatusOK {
+			t.Errorf("%s: got status %q", path, response.Status)
+		}
+	}
+}
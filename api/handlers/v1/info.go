@@ -10,6 +10,7 @@ import (
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/entity"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"github.com/go-chi/chi/v5"
@@ -32,37 +33,10 @@ func NewInfoHandler(args handlers.HandlerArguments) http.Handler {
 		infoUsecase: args.InfoUsecase,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/articles", "POST"},
-		{"admin", "/v1/articles/{id}", "PUT"},
-		{"admin", "/v1/articles/{id}", "DELETE"},
-		{"admin", "/v1/articles/chapter", "POST"},
-		{"admin", "/v1/articles/chapter/{id}", "PUT"},
-		{"admin", "/v1/articles/chapter/{id}", "DELETE"},
-
-		// secretary
-		{"secretary", "/v1/articles", "POST"},
-		{"secretary", "/v1/articles/{id}", "PUT"},
-		{"secretary", "/v1/articles/{id}", "DELETE"},
-		{"secretary", "/v1/articles/chapter", "POST"},
-		{"secretary", "/v1/articles/chapter/{id}", "PUT"},
-		{"secretary", "/v1/articles/chapter/{id}", "DELETE"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
+		r.Use(middleware.HTTPCache(handler.config.Cache.InfoMaxAge))
 		r.Get("/{id}", handler.GetArticlesByChapter())
 		r.Get("/chapter", handler.GetChapterList())
 		r.Get("/chapter/{id}", handler.GetChpater())
@@ -70,7 +44,7 @@ func NewInfoHandler(args handlers.HandlerArguments) http.Handler {
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, args.Metrics))
 
 		r.Post("/", handler.CreateArticle())
 		r.Put("/{id}", handler.UpdateArticle())
@@ -100,7 +74,7 @@ func (h infoHandler) GetArticlesByChapter() http.HandlerFunc {
 
 		chapterID := chi.URLParam(r, "id")
 
-		articles, err := h.infoUsecase.ListArticles(ctx, map[string]string{"chapter_id": chapterID})
+		articles, err := h.infoUsecase.ListArticles(ctx, entity.ArticlesFilter{ChapterIDs: []string{chapterID}})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -143,7 +117,7 @@ func (h infoHandler) CreateArticle() http.HandlerFunc {
 
 		request := models.CreateArticleRequest{}
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			h.logger.Error("error on decoding request body", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on decoding request body", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -270,7 +244,7 @@ func (h infoHandler) GetChapterList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		chapters, err := h.infoUsecase.ListArticlesChapters(ctx, map[string]string{})
+		chapters, err := h.infoUsecase.ListArticlesChapters(ctx, entity.ChaptersFilter{})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -311,7 +285,7 @@ func (h infoHandler) GetChpater() http.HandlerFunc {
 
 		guid := chi.URLParam(r, "id")
 
-		chapters, err := h.infoUsecase.ListArticlesChapters(ctx, map[string]string{"guid": guid})
+		chapters, err := h.infoUsecase.ListArticlesChapters(ctx, entity.ChaptersFilter{GUIDs: []string{guid}})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -0,0 +1,77 @@
+package v1_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestInfo(t *testing.T) {
+	env := itest.New(t)
+	token := env.Login(t, entity.RoleSecretary)
+
+	resp := env.Request(t, http.MethodPost, "/v1/articles/chapter", token, models.CreateChapterRequest{Name: "About us"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create chapter: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var chapter models.GUIDResponse
+	resp.Decode(t, &chapter)
+
+	var chapters []models.Chapter
+	env.Request(t, http.MethodGet, "/v1/articles/chapter", "", nil).Decode(t, &chapters)
+	if len(chapters) != 1 || chapters[0].Name != "About us" {
+		t.Fatalf("list chapters: got %+v", chapters)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/articles", token, models.CreateArticleRequest{
+		ChapterID: chapter.GUID,
+		Text:      "Founded in 2010",
+		Img:       "https://cdn.example.com/clinic.jpg",
+		Side:      "left",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create article: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var article models.GUIDResponse
+	resp.Decode(t, &article)
+
+	resp = env.Request(t, http.MethodPut, "/v1/articles/"+article.GUID, token, models.UpdateArticleRequest{
+		Text: "Founded in 2010 in Tashkent",
+		Img:  "https://cdn.example.com/clinic.jpg",
+		Side: "left",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update article: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var articles []models.Article
+	env.Request(t, http.MethodGet, "/v1/articles/"+chapter.GUID, "", nil).Decode(t, &articles)
+	if len(articles) != 1 || articles[0].GUID != article.GUID || articles[0].Text != "Founded in 2010 in Tashkent" {
+		t.Fatalf("list articles: got %+v", articles)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/articles/"+article.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete article: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	articles = nil
+	env.Request(t, http.MethodGet, "/v1/articles/"+chapter.GUID, "", nil).Decode(t, &articles)
+	if len(articles) != 0 {
+		t.Errorf("list articles after delete: got %+v", articles)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/articles/chapter/"+chapter.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete chapter: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	chapters = nil
+	env.Request(t, http.MethodGet, "/v1/articles/chapter", "", nil).Decode(t, &chapters)
+	if len(chapters) != 0 {
+		t.Errorf("list chapters after delete: got %+v", chapters)
+	}
+}
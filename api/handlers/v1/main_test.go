@@ -0,0 +1,15 @@
+package v1_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+// missingGUID is a well-formed id of nothing
+const missingGUID = "00000000-0000-0000-0000-000000000000"
+
+func TestMain(m *testing.M) {
+	os.Exit(itest.Main(m))
+}
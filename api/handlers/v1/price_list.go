@@ -2,14 +2,19 @@ package v1
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"time"
 
 	errorsapi "github.com/AsaHero/abclinic/api/errors"
 	"github.com/AsaHero/abclinic/api/handlers"
 	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"github.com/go-chi/chi/v5"
@@ -32,53 +37,37 @@ func NewPriceListHandler(args handlers.HandlerArguments) http.Handler {
 		priceListUsecase: args.PriceListUsecase,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/services", "POST"},
-		{"admin", "/v1/services/{id}", "PUT"},
-		{"admin", "/v1/services/{id}", "DELETE"},
-		{"admin", "/v1/services/groups", "POST"},
-		{"admin", "/v1/services/groups/{id}", "PUT"},
-		{"admin", "/v1/services/groups/{id}", "DELETE"},
-
-		// secretary
-		{"secretary", "/v1/services", "POST"},
-		{"secretary", "/v1/services/{id}", "PUT)"},
-		{"secretary", "/v1/services/{id}", "DELETE"},
-		{"secretary", "/v1/services/groups", "POST"},
-		{"secretary", "/v1/services/groups/{id}", "PUT"},
-		{"secretary", "/v1/services/groups/{id}", "DELETE"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
+		r.Use(middleware.HTTPCache(handler.config.Cache.PriceListMaxAge))
 
 		r.Get("/{group_id}", handler.GetPriceListByGroup())
 		r.Get("/groups", handler.GetGroupList())
 		r.Get("/groups/{id}", handler.GetGroup())
+		r.Get("/tiers", handler.GetPriceTiers())
+		r.Get("/rates", handler.GetExchangeRates())
+		r.Get("/{id}/history", handler.GetPriceHistory())
 
 	})
 
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, args.Metrics))
 
 		r.Post("/", handler.CreateService())
 		r.Put("/{id}", handler.UpdateService())
 		r.Delete("/{id}", handler.DeleteService())
+		r.Post("/{id}/history", handler.SchedulePrice())
+		r.Delete("/{id}/history/{price_id}", handler.CancelPrice())
 		r.Post("/groups", handler.CreateServiceGroup())
 		r.Put("/groups/{id}", handler.UpdateServiceGroup())
 		r.Delete("/groups/{id}", handler.DeleteServiceGroups())
+		r.Post("/tiers", handler.CreatePriceTier())
+		r.Put("/tiers/{id}", handler.UpdatePriceTier())
+		r.Delete("/tiers/{id}", handler.DeletePriceTier())
+		r.Post("/rates", handler.CreateExchangeRate())
+		r.Put("/rates/{id}", handler.UpdateExchangeRate())
+		r.Delete("/rates/{id}", handler.DeleteExchangeRate())
 	})
 	return router
 }
@@ -92,7 +81,10 @@ func NewPriceListHandler(args handlers.HandlerArguments) http.Handler {
 // @Accept json
 // @Produce json
 // @Param group_id path string true "group_id"
+// @Param at query string false "prices in effect at the time, RFC3339 or date, now by default"
+// @Param currency query string false "converts prices to the currency with exchange rates, ISO 4217 code"
 // @Success 200 {object} []models.Services
+// @Failure 400 {object} models.ResponseError
 // @Failure 404 {object} models.ResponseError
 // @Failure 500 {object} models.ResponseError
 func (h priceListHandler) GetPriceListByGroup() http.HandlerFunc {
@@ -101,12 +93,33 @@ func (h priceListHandler) GetPriceListByGroup() http.HandlerFunc {
 
 		groupID := chi.URLParam(r, "group_id")
 
-		services, err := h.priceListUsecase.ListServices(ctx, map[string]string{"group_id": groupID})
+		at, err := parseAt(r.URL.Query().Get("at"))
 		if err != nil {
-			h.logger.Error("error on GetPriceListByGroup/ priceListUsecase.ListServices", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
-				HTTPStatusCode: http.StatusInternalServerError,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      "at must be RFC3339 time or 2006-01-02 date",
+			})
+			return
+		}
+
+		services, err := h.priceListUsecase.ListServices(ctx, entity.ServicesFilter{
+			GroupIDs: []string{groupID},
+		}, entity.ServicesListOptions{
+			At:       at,
+			Currency: strings.ToUpper(r.URL.Query().Get("currency")),
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidCurrency), errors.Is(err, usecase.ErrNoExchangeRate):
+				status = http.StatusBadRequest
+			default:
+				logger.FromContext(r.Context()).Error("error on GetPriceListByGroup/ priceListUsecase.ListServices", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
 				ErrorText:      err.Error(),
 			})
 			return
@@ -116,10 +129,10 @@ func (h priceListHandler) GetPriceListByGroup() http.HandlerFunc {
 
 		for _, v := range services {
 			response = append(response, models.Services{
-				GUID:        v.GUID,
-				Name:        v.Name,
-				Price:       v.Price[0],
-				UrgentPrice: v.Price[1],
+				GUID:     v.GUID,
+				Name:     v.Name,
+				Currency: v.Currency,
+				Prices:   tierPricesResponse(v.Prices),
 			})
 		}
 
@@ -137,6 +150,7 @@ func (h priceListHandler) GetPriceListByGroup() http.HandlerFunc {
 // @Produce json
 // @Param body body models.CreateServiceRequest true "body"
 // @Success 200 {object} models.GUIDResponse
+// @Failure 400 {object} models.ResponseError
 // @Failure 404 {object} models.ResponseError
 // @Failure 500 {object} models.ResponseError
 func (h priceListHandler) CreateService() http.HandlerFunc {
@@ -145,7 +159,7 @@ func (h priceListHandler) CreateService() http.HandlerFunc {
 
 		request := models.CreateServiceRequest{}
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			h.logger.Error("error on decoding request body", zap.Error(err))
+			logger.FromContext(r.Context()).Error("error on decoding request body", zap.Error(err))
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
@@ -155,15 +169,21 @@ func (h priceListHandler) CreateService() http.HandlerFunc {
 		}
 
 		guid, err := h.priceListUsecase.CreateService(ctx, &entity.Services{
-			GroupID: request.GroupID,
-			Name:    request.Name,
-			Price:   []float64{request.Price, request.UrgentPrice},
+			GroupID:  request.GroupID,
+			Name:     request.Name,
+			Currency: request.Currency,
+			Prices:   tierPrices(request.Prices),
 		})
 		if err != nil {
-			h.logger.Error("error on CreateService/ priceListUsecase.CreateService", zap.Error(err))
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrInvalidPrice) {
+				status = http.StatusBadRequest
+			} else {
+				logger.FromContext(r.Context()).Error("error on CreateService/ priceListUsecase.CreateService", zap.Error(err))
+			}
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
-				HTTPStatusCode: http.StatusInternalServerError,
+				HTTPStatusCode: status,
 				ErrorText:      err.Error(),
 			})
 			return
@@ -188,6 +208,7 @@ func (h priceListHandler) CreateService() http.HandlerFunc {
 // @Param id path string true "id"
 // @Param body body models.UpdateServiceRequest true "body"
 // @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
 // @Failure 404 {object} models.ResponseError
 // @Failure 500 {object} models.ResponseError
 func (h priceListHandler) UpdateService() http.HandlerFunc {
@@ -206,17 +227,20 @@ func (h priceListHandler) UpdateService() http.HandlerFunc {
 			return
 		}
 
-		priceArrey := []float64{request.Price, request.UrgentPrice}
-
 		err := h.priceListUsecase.UpdateService(ctx, &entity.Services{
-			GUID:  guid,
-			Name:  request.Name,
-			Price: priceArrey,
+			GUID:     guid,
+			Name:     request.Name,
+			Currency: request.Currency,
+			Prices:   tierPrices(request.Prices),
 		})
 		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrInvalidPrice) {
+				status = http.StatusBadRequest
+			}
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
-				HTTPStatusCode: http.StatusInternalServerError,
+				HTTPStatusCode: status,
 				ErrorText:      err.Error(),
 			})
 			return
@@ -259,6 +283,157 @@ func (h priceListHandler) DeleteService() http.HandlerFunc {
 	}
 }
 
+// GetPriceHistory
+// @Security ApiKeyAuth
+// @Router /v1/services/{id}/history [GET]
+// @Summary Get price history
+// @Description Get past, current and scheduled prices of service ordered by valid_from
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} []models.ServicePrice
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) GetPriceHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		prices, err := h.priceListUsecase.ListPrices(ctx, entity.ServicePricesFilter{ServiceIDs: []string{guid}})
+		if err != nil {
+			logger.FromContext(r.Context()).Error("error on GetPriceHistory/ priceListUsecase.ListPrices", zap.Error(err))
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := []models.ServicePrice{}
+
+		for _, v := range prices {
+			response = append(response, models.ServicePrice{
+				GUID:      v.GUID,
+				Currency:  v.Currency,
+				Prices:    tierPricesResponse(v.Prices),
+				ValidFrom: v.ValidFrom,
+				ValidTo:   v.ValidTo,
+			})
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// SchedulePrice
+// @Security ApiKeyAuth
+// @Router /v1/services/{id}/history [POST]
+// @Summary Schedule price
+// @Description Change price of service from valid_from, immediately if it's omitted. A price scheduled from the same valid_from is a conflict, cancel it first to replace it.
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param body body models.SchedulePriceRequest true "body"
+// @Success 200 {object} models.GUIDResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) SchedulePrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		serviceID := chi.URLParam(r, "id")
+
+		request := models.SchedulePriceRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		price := &entity.ServicePrices{
+			ServiceID: serviceID,
+			Prices:    tierPrices(request.Prices),
+			Currency:  request.Currency,
+		}
+		if request.ValidFrom != nil {
+			price.ValidFrom = *request.ValidFrom
+		}
+
+		guid, err := h.priceListUsecase.SchedulePrice(ctx, price)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrPriceInPast), errors.Is(err, usecase.ErrInvalidPrice):
+				status = http.StatusBadRequest
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, errorspkg.ErrorConflict):
+				status = http.StatusConflict
+			default:
+				logger.FromContext(r.Context()).Error("error on SchedulePrice/ priceListUsecase.SchedulePrice", zap.Error(err))
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.GUIDResponse{GUID: guid})
+	}
+}
+
+// CancelPrice
+// @Security ApiKeyAuth
+// @Router /v1/services/{id}/history/{price_id} [DELETE]
+// @Summary Cancel scheduled price
+// @Description Delete price of service that hasn't taken effect yet
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param price_id path string true "price_id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) CancelPrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		serviceID := chi.URLParam(r, "id")
+		guid := chi.URLParam(r, "price_id")
+
+		err := h.priceListUsecase.CancelPrice(ctx, serviceID, guid)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, usecase.ErrPriceInEffect):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
 // GetGroupList
 // @Security ApiKeyAuth
 // @Router /v1/services/groups [GET]
@@ -274,7 +449,7 @@ func (h priceListHandler) GetGroupList() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		groups, err := h.priceListUsecase.ListServiceGroups(ctx, map[string]string{})
+		groups, err := h.priceListUsecase.ListServiceGroups(ctx, entity.ServiceGroupsFilter{})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -315,7 +490,7 @@ func (h priceListHandler) GetGroup() http.HandlerFunc {
 
 		guid := chi.URLParam(r, "id")
 
-		groups, err := h.priceListUsecase.ListServiceGroups(ctx, map[string]string{"guid": guid})
+		groups, err := h.priceListUsecase.ListServiceGroups(ctx, entity.ServiceGroupsFilter{GUIDs: []string{guid}})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
@@ -458,3 +633,413 @@ func (h priceListHandler) DeleteServiceGroups() http.HandlerFunc {
 		render.JSON(w, r, models.Empty{})
 	}
 }
+
+// GetPriceTiers
+// @Security ApiKeyAuth
+// @Router /v1/services/tiers [GET]
+// @Summary Get price tiers
+// @Description Get price tiers of the clinic ordered by position
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Success 200 {object} []models.PriceTier
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) GetPriceTiers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tiers, err := h.priceListUsecase.ListPriceTiers(ctx, entity.PriceTiersFilter{})
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := []models.PriceTier{}
+
+		for _, v := range tiers {
+			response = append(response, models.PriceTier{
+				GUID:     v.GUID,
+				Code:     v.Code,
+				Name:     v.Name,
+				Position: v.Position,
+			})
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// CreatePriceTier
+// @Security ApiKeyAuth
+// @Router /v1/services/tiers [POST]
+// @Summary Create price tier
+// @Description Create price tier, code keys prices of the tier and is fixed once created
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param body body models.CreatePriceTierRequest true "body"
+// @Success 200 {object} models.GUIDResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) CreatePriceTier() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.CreatePriceTierRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		guid, err := h.priceListUsecase.CreatePriceTier(ctx, &entity.PriceTiers{
+			Code:     request.Code,
+			Name:     request.Name,
+			Position: request.Position,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidPriceTier):
+				status = http.StatusBadRequest
+			case errors.Is(err, errorspkg.ErrorConflict):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.GUIDResponse{GUID: guid})
+	}
+}
+
+// UpdatePriceTier
+// @Security ApiKeyAuth
+// @Router /v1/services/tiers/{id} [PUT]
+// @Summary Update price tier
+// @Description Update name and position of price tier
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param body body models.UpdatePriceTierRequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) UpdatePriceTier() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		request := models.UpdatePriceTierRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		err := h.priceListUsecase.UpdatePriceTier(ctx, &entity.PriceTiers{
+			GUID:     guid,
+			Name:     request.Name,
+			Position: request.Position,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidPriceTier):
+				status = http.StatusBadRequest
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// DeletePriceTier
+// @Security ApiKeyAuth
+// @Router /v1/services/tiers/{id} [DELETE]
+// @Summary Delete price tier
+// @Description Delete price tier without prices in history
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) DeletePriceTier() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		err := h.priceListUsecase.DeletePriceTier(ctx, guid)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, usecase.ErrPriceTierInUse):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// GetExchangeRates
+// @Security ApiKeyAuth
+// @Router /v1/services/rates [GET]
+// @Summary Get exchange rates
+// @Description Get exchange rates prices are converted with, a pair converts both ways
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Success 200 {object} []models.ExchangeRate
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) GetExchangeRates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		rates, err := h.priceListUsecase.ListExchangeRates(ctx, entity.ExchangeRatesFilter{})
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := []models.ExchangeRate{}
+
+		for _, v := range rates {
+			response = append(response, models.ExchangeRate{
+				GUID:      v.GUID,
+				Base:      v.Base,
+				Quote:     v.Quote,
+				Rate:      v.Rate,
+				UpdatedAt: v.UpdatedAt,
+			})
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// CreateExchangeRate
+// @Security ApiKeyAuth
+// @Router /v1/services/rates [POST]
+// @Summary Create exchange rate
+// @Description Create exchange rate of a pair of currencies, one unit of base is rate units of quote
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param body body models.CreateExchangeRateRequest true "body"
+// @Success 200 {object} models.GUIDResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) CreateExchangeRate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		request := models.CreateExchangeRateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		guid, err := h.priceListUsecase.CreateExchangeRate(ctx, &entity.ExchangeRates{
+			Base:  strings.ToUpper(request.Base),
+			Quote: strings.ToUpper(request.Quote),
+			Rate:  request.Rate,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidExchangeRate):
+				status = http.StatusBadRequest
+			case errors.Is(err, errorspkg.ErrorConflict):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.GUIDResponse{GUID: guid})
+	}
+}
+
+// UpdateExchangeRate
+// @Security ApiKeyAuth
+// @Router /v1/services/rates/{id} [PUT]
+// @Summary Update exchange rate
+// @Description Update rate of exchange rate, currencies of the pair are fixed
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Param body body models.UpdateExchangeRateRequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) UpdateExchangeRate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		request := models.UpdateExchangeRateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		err := h.priceListUsecase.UpdateExchangeRate(ctx, &entity.ExchangeRates{
+			GUID: guid,
+			Rate: request.Rate,
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidExchangeRate):
+				status = http.StatusBadRequest
+			case errors.Is(err, errorspkg.ErrorNotFound):
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// DeleteExchangeRate
+// @Security ApiKeyAuth
+// @Router /v1/services/rates/{id} [DELETE]
+// @Summary Delete exchange rate
+// @Description Delete exchange rate, prices aren't converted between its currencies anymore
+// @Tags Price list
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h priceListHandler) DeleteExchangeRate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		err := h.priceListUsecase.DeleteExchangeRate(ctx, guid)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errorspkg.ErrorNotFound) {
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// tierPrices keeps omitted prices nil
+func tierPrices(prices []models.TierPrice) []entity.TierPrice {
+	if prices == nil {
+		return nil
+	}
+
+	result := make([]entity.TierPrice, 0, len(prices))
+	for _, v := range prices {
+		result = append(result, entity.TierPrice{
+			Tier:   v.Tier,
+			Amount: v.Amount,
+			From:   v.From,
+		})
+	}
+	return result
+}
+
+func tierPricesResponse(prices []entity.TierPrice) []models.TierPrice {
+	result := []models.TierPrice{}
+	for _, v := range prices {
+		result = append(result, models.TierPrice{
+			Tier:   v.Tier,
+			Amount: v.Amount,
+			From:   v.From,
+		})
+	}
+	return result
+}
+
+// parseAt reads time of ?at=, a bare date is its local midnight, empty is zero
+func parseAt(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
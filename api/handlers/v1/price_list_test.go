@@ -0,0 +1,298 @@
+package v1_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestPriceList(t *testing.T) {
+	env := itest.New(t)
+	token := env.Login(t, entity.RoleSecretary)
+	adminToken := env.Login(t, entity.RoleAdmin)
+
+	// tiers are listed by position, not by creation
+	for _, v := range []models.CreatePriceTierRequest{
+		{Code: "urgent", Name: "Urgent", Position: 2},
+		{Code: "regular", Name: "Regular", Position: 1},
+	} {
+		if resp := env.Request(t, http.MethodPost, "/v1/services/tiers", adminToken, v); resp.StatusCode != http.StatusOK {
+			t.Fatalf("create tier %s: got %d %s", v.Code, resp.StatusCode, resp.Body)
+		}
+	}
+	resp := env.Request(t, http.MethodPost, "/v1/services/tiers", adminToken, models.CreatePriceTierRequest{Code: "Child tier", Name: "Child"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("create tier with invalid code: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	var tiers []models.PriceTier
+	env.Request(t, http.MethodGet, "/v1/services/tiers", "", nil).Decode(t, &tiers)
+	if len(tiers) != 2 || tiers[0].Code != "regular" || tiers[1].Code != "urgent" {
+		t.Fatalf("list tiers: got %+v", tiers)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/services/groups", token, models.CreateServiceGroupRequest{Name: "Therapy"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create group: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var group models.GUIDResponse
+	resp.Decode(t, &group)
+
+	resp = env.Request(t, http.MethodPut, "/v1/services/groups/"+group.GUID, token, models.CreateServiceGroupRequest{Name: "Dental therapy"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update group: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var groups []models.ServicesGroup
+	env.Request(t, http.MethodGet, "/v1/services/groups", "", nil).Decode(t, &groups)
+	if len(groups) != 1 || groups[0].Name != "Dental therapy" {
+		t.Fatalf("list groups: got %+v", groups)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/services", token, models.CreateServiceRequest{
+		GroupID: group.GUID,
+		Name:    "Filling",
+		Prices:  []models.TierPrice{{Tier: "child", Amount: 200000}},
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("create service in missing tier: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/services", token, models.CreateServiceRequest{
+		GroupID: group.GUID,
+		Name:    "Filling",
+		Prices:  tierPrices(300000, 450000),
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create service: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var service models.GUIDResponse
+	resp.Decode(t, &service)
+
+	resp = env.Request(t, http.MethodPut, "/v1/services/"+service.GUID, token, models.UpdateServiceRequest{
+		Name: "Light-cured filling",
+		// amounts are listed in order of tiers
+		Prices: []models.TierPrice{
+			{Tier: "urgent", Amount: 500000},
+			{Tier: "regular", Amount: 350000, From: true},
+		},
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update service: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var services []models.Services
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID, "", nil).Decode(t, &services)
+	want := models.Services{
+		GUID:     service.GUID,
+		Name:     "Light-cured filling",
+		Currency: "UZS",
+		Prices: []models.TierPrice{
+			{Tier: "regular", Amount: 350000, From: true},
+			{Tier: "urgent", Amount: 500000},
+		},
+	}
+	if len(services) != 1 || !reflect.DeepEqual(services[0], want) {
+		t.Fatalf("list services: got %+v, want %+v", services, want)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/tiers/"+tiers[1].GUID, adminToken, nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("delete tier with prices: got %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	validFrom := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	resp = env.Request(t, http.MethodPost, "/v1/services/"+service.GUID+"/history", token, models.SchedulePriceRequest{
+		Currency:  "USD",
+		Prices:    tierPrices(40, 55),
+		ValidFrom: &validFrom,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("schedule price: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var scheduled models.GUIDResponse
+	resp.Decode(t, &scheduled)
+
+	resp = env.Request(t, http.MethodPost, "/v1/services/"+service.GUID+"/history", token, models.SchedulePriceRequest{
+		Currency:  "USD",
+		Prices:    tierPrices(45, 60),
+		ValidFrom: &validFrom,
+	})
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("schedule price at taken valid_from: got %d %s, want %d", resp.StatusCode, resp.Body, http.StatusConflict)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	resp = env.Request(t, http.MethodPost, "/v1/services/"+service.GUID+"/history", token, models.SchedulePriceRequest{Prices: tierPrices(1, 1), ValidFrom: &past})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("schedule price in past: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	var history []models.ServicePrice
+	env.Request(t, http.MethodGet, "/v1/services/"+service.GUID+"/history", "", nil).Decode(t, &history)
+	if len(history) != 3 || history[0].Prices[0].Amount != 300000 || history[1].Prices[0].Amount != 350000 || history[2].Prices[0].Amount != 40 {
+		t.Fatalf("price history: got %+v", history)
+	}
+	if history[1].ValidTo == nil || !history[1].ValidTo.Equal(validFrom) || history[2].ValidTo != nil {
+		t.Errorf("price history periods: got %+v", history)
+	}
+
+	services = nil
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?at="+validFrom.Format(time.RFC3339), "", nil).Decode(t, &services)
+	if len(services) != 1 || services[0].Currency != "USD" || !reflect.DeepEqual(services[0].Prices, tierPrices(40, 55)) {
+		t.Errorf("list services at %s: got %+v", validFrom, services)
+	}
+
+	if resp := env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?at=March", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("list services at invalid time: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/"+service.GUID+"/history/"+history[1].GUID, token, nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("cancel price in effect: got %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/"+service.GUID+"/history/"+scheduled.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cancel scheduled price: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	history = nil
+	env.Request(t, http.MethodGet, "/v1/services/"+service.GUID+"/history", "", nil).Decode(t, &history)
+	if len(history) != 2 || history[1].ValidTo != nil {
+		t.Errorf("price history after cancel: got %+v", history)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/"+service.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete service: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	services = nil
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID, "", nil).Decode(t, &services)
+	if len(services) != 0 {
+		t.Errorf("list services after delete: got %+v", services)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/groups/"+group.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete group: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	groups = nil
+	env.Request(t, http.MethodGet, "/v1/services/groups", "", nil).Decode(t, &groups)
+	if len(groups) != 0 {
+		t.Errorf("list groups after delete: got %+v", groups)
+	}
+}
+
+func TestExchangeRates(t *testing.T) {
+	env := itest.New(t)
+	token := env.Login(t, entity.RoleSecretary)
+	adminToken := env.Login(t, entity.RoleAdmin)
+
+	for _, v := range []models.CreatePriceTierRequest{
+		{Code: "regular", Name: "Regular", Position: 1},
+		{Code: "urgent", Name: "Urgent", Position: 2},
+	} {
+		if resp := env.Request(t, http.MethodPost, "/v1/services/tiers", adminToken, v); resp.StatusCode != http.StatusOK {
+			t.Fatalf("create tier %s: got %d %s", v.Code, resp.StatusCode, resp.Body)
+		}
+	}
+
+	resp := env.Request(t, http.MethodPost, "/v1/services/rates", adminToken, models.CreateExchangeRateRequest{Base: "usd", Quote: "UZS", Rate: 12650.5})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create rate: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var rate models.GUIDResponse
+	resp.Decode(t, &rate)
+
+	for name, v := range map[string]models.CreateExchangeRateRequest{
+		"same currencies": {Base: "UZS", Quote: "UZS", Rate: 1},
+		"zero rate":       {Base: "EUR", Quote: "UZS"},
+	} {
+		if resp := env.Request(t, http.MethodPost, "/v1/services/rates", adminToken, v); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("create rate of %s: got %d, want %d", name, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+	resp = env.Request(t, http.MethodPost, "/v1/services/rates", adminToken, models.CreateExchangeRateRequest{Base: "UZS", Quote: "USD", Rate: 0.000079})
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("create inverse rate: got %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/services/groups", token, models.CreateServiceGroupRequest{Name: "Therapy"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create group: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var group models.GUIDResponse
+	resp.Decode(t, &group)
+
+	// 50 000.00 and 75 000.50 soums
+	resp = env.Request(t, http.MethodPost, "/v1/services", token, models.CreateServiceRequest{
+		GroupID:  group.GUID,
+		Name:     "Filling",
+		Currency: "UZS",
+		Prices:   tierPrices(5000000, 7500050),
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create service: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	// amounts are rounded to cents
+	var services []models.Services
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?currency=usd", "", nil).Decode(t, &services)
+	if len(services) != 1 || services[0].Currency != "USD" || !reflect.DeepEqual(services[0].Prices, tierPrices(395, 593)) {
+		t.Fatalf("list services in USD: got %+v", services)
+	}
+
+	services = nil
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID, "", nil).Decode(t, &services)
+	if len(services) != 1 || services[0].Currency != "UZS" || !reflect.DeepEqual(services[0].Prices, tierPrices(5000000, 7500050)) {
+		t.Fatalf("list services in own currency: got %+v", services)
+	}
+
+	for _, currency := range []string{"EUR", "ABC", "dollar"} {
+		if resp := env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?currency="+currency, "", nil); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("list services in %s: got %d, want %d", currency, resp.StatusCode, http.StatusBadRequest)
+		}
+	}
+
+	resp = env.Request(t, http.MethodPut, "/v1/services/rates/"+rate.GUID, adminToken, models.UpdateExchangeRateRequest{Rate: 12500})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update rate: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var rates []models.ExchangeRate
+	env.Request(t, http.MethodGet, "/v1/services/rates", "", nil).Decode(t, &rates)
+	if len(rates) != 1 || rates[0].Base != "USD" || rates[0].Quote != "UZS" || rates[0].Rate != 12500 {
+		t.Fatalf("list rates: got %+v", rates)
+	}
+
+	services = nil
+	env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?currency=USD", "", nil).Decode(t, &services)
+	if len(services) != 1 || !reflect.DeepEqual(services[0].Prices, tierPrices(400, 600)) {
+		t.Errorf("list services in USD after rate update: got %+v", services)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/services/rates/"+rate.GUID, adminToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete rate: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	if resp := env.Request(t, http.MethodGet, "/v1/services/"+group.GUID+"?currency=USD", "", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("list services in USD after rate delete: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+// tierPrices are amounts in tiers "regular" and "urgent"
+func tierPrices(regular, urgent int64) []models.TierPrice {
+	return []models.TierPrice{
+		{Tier: "regular", Amount: regular},
+		{Tier: "urgent", Amount: urgent},
+	}
+}
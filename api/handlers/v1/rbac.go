@@ -10,7 +10,10 @@ import (
 	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/api/models"
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/loginguard"
+	"github.com/AsaHero/abclinic/internal/pkg/validation"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/casbin/casbin/v2"
 	"github.com/go-chi/chi/v5"
@@ -20,55 +23,33 @@ import (
 
 type rbacHandler struct {
 	handlers.BaseHandler
-	rbacUsecase    usecase.Rbac
+	rbacUsecase   usecase.Rbac
 	authorUsecase usecase.Blogs
-	logger         *zap.Logger
-	config         *config.Config
-	enforcer       *casbin.Enforcer
+	mfaUsecase    usecase.MFA
+	loginGuard    *loginguard.Guard
+	logger        *zap.Logger
+	config        *config.Config
+	enforcer      *casbin.Enforcer
 }
 
 func NewRbacHandler(options handlers.HandlerArguments) http.Handler {
 	handler := rbacHandler{
-		rbacUsecase:    options.RbacUsecase,
-		logger:         options.Logger,
-		config:         options.Config,
-		enforcer:       options.Enforcer,
+		rbacUsecase:   options.RbacUsecase,
+		logger:        options.Logger,
+		config:        options.Config,
+		enforcer:      options.Enforcer,
 		authorUsecase: options.BlogsUsecase,
+		mfaUsecase:    options.MFAUsecase,
+		loginGuard:    options.LoginGuard,
 	}
 
-	policies := [][]string{
-		// admin
-		{"admin", "/v1/rbac/roles", "GET"},
-		{"admin", "/v1/rbac/user", "GET"},
-		{"admin", "/v1/rbac/users", "GET"},
-		{"admin", "/v1/rbac/user", "POST"},
-		{"admin", "/v1/rbac/user/{id}", "PUT"},
-		{"admin", "/v1/rbac/user/{id}", "DELETE"},
-
-		// dentist
-		{"dentist", "/v1/rbac/roles", "GET"},
-		{"dentist", "/v1/rbac/user", "GET"},
-
-		// secretary
-		{"secretary", "/v1/rbac/roles", "GET"},
-		{"secretary", "/v1/rbac/user", "GET"},
-	}
-
-	for _, v := range policies {
-		_, err := handler.enforcer.AddPolicy(v)
-		if err != nil {
-			handler.logger.Error("error while adding policies to the casbin", zap.Error(err))
-			return nil
-		}
-	}
-
-	handler.enforcer.SavePolicy()
-
 	router := chi.NewRouter()
 	router.Group(func(r chi.Router) {
-		r.Use(middleware.Authorizer(handler.enforcer, handler.logger))
+		r.Use(middleware.Authorizer(handler.enforcer, options.Metrics))
 		// roles
 		r.Get("/roles", handler.GetRoles())
+		r.Get("/roles/2fa", handler.GetRolesMFA())
+		r.Put("/roles/{role}/2fa", handler.SetRoleMFA())
 
 		// users
 		r.Get("/users", handler.GetAllUsers())
@@ -76,7 +57,20 @@ func NewRbacHandler(options handlers.HandlerArguments) http.Handler {
 		r.Post("/user", handler.CreateUser())
 		r.Put("/user/{id}", handler.UpdateUser())
 		r.Delete("/user/{id}", handler.DeleteUser())
-
+		r.Post("/user/{id}/unlock", handler.UnlockUser())
+		r.Post("/user/{id}/reset-password", handler.ResetPassword())
+		r.Delete("/user/{id}/2fa", handler.ResetMFA())
+
+		// current user, api keys have none
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireUser)
+			r.Put("/me/password", handler.ChangePassword())
+
+			// two-factor authentication
+			r.Post("/me/2fa", handler.EnrollMFA())
+			r.Post("/me/2fa/confirm", handler.ConfirmMFA())
+			r.Delete("/me/2fa", handler.DisableMFA())
+		})
 	})
 
 	return router
@@ -130,17 +124,19 @@ func (h rbacHandler) GetUserInfo() http.HandlerFunc {
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      "failed to fetch authentication data",
 			})
+			return
 		}
 
 		userID := claims["user_id"]
 
-		user, err := h.rbacUsecase.GetUser(ctx, map[string]string{"guid": userID})
+		user, err := h.rbacUsecase.GetUser(ctx, entity.UsersFilter{GUIDs: []string{userID}})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      err.Error(),
 			})
+			return
 		}
 
 		response := models.GetUserInfoResponse{
@@ -194,6 +190,7 @@ func (h rbacHandler) CreateUser() http.HandlerFunc {
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      err.Error(),
 			})
+			return
 		}
 
 		if request.Role == entity.RoleDentist {
@@ -207,6 +204,7 @@ func (h rbacHandler) CreateUser() http.HandlerFunc {
 					HTTPStatusCode: http.StatusBadRequest,
 					ErrorText:      err.Error(),
 				})
+				return
 			}
 		}
 
@@ -261,6 +259,7 @@ func (h rbacHandler) UpdateUser() http.HandlerFunc {
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      err.Error(),
 			})
+			return
 		}
 
 		render.JSON(w, r, models.Empty{})
@@ -282,13 +281,14 @@ func (h rbacHandler) GetAllUsers() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		users, err := h.rbacUsecase.ListUsers(ctx, map[string]string{})
+		users, err := h.rbacUsecase.ListUsers(ctx, entity.UsersFilter{})
 		if err != nil {
 			render.Render(w, r, &errorsapi.ErrResponse{
 				Err:            err,
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      err.Error(),
 			})
+			return
 		}
 
 		response := []models.GetAllUsersResponse{}
@@ -332,6 +332,472 @@ func (h rbacHandler) DeleteUser() http.HandlerFunc {
 				HTTPStatusCode: http.StatusBadRequest,
 				ErrorText:      err.Error(),
 			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// UnlockUser
+// @Security ApiKeyAuth
+// @Router /v1/rbac/user/{id}/unlock [POST]
+// @Summary Unlock user
+// @Description Lift login lockout after repeated failed attempts
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) UnlockUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		user, err := h.rbacUsecase.GetUser(ctx, entity.UsersFilter{GUIDs: []string{guid}})
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusNotFound,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		h.loginGuard.Unlock(user.Username)
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// ResetPassword
+// @Security ApiKeyAuth
+// @Router /v1/rbac/user/{id}/reset-password [POST]
+// @Summary Reset user password
+// @Description Replace password with a one-time token, user must set a new password on next login
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.ResetPasswordResponse
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		resetToken, expiresAt, err := h.rbacUsecase.ResetPassword(ctx, guid)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errorspkg.ErrorNotFound) {
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.ResetPasswordResponse{
+			ResetToken: resetToken,
+			ExpiresAt:  expiresAt,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// ChangePassword
+// @Security ApiKeyAuth
+// @Router /v1/rbac/me/password [PUT]
+// @Summary Change own password
+// @Description Change password of the authenticated user, all sessions are revoked
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param body body models.ChangePasswordRequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, ok := h.GetAuthData(ctx)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("failed to fetch authentication data"),
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      "failed to fetch authentication data",
+			})
+			return
+		}
+
+		request := models.ChangePasswordRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		err := h.rbacUsecase.ChangePassword(ctx, claims["user_id"], request.CurrentPassword, request.NewPassword)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, validation.ErrPasswordPolicy):
+				status = http.StatusBadRequest
+			case errors.Is(err, usecase.ErrWrongPassword), errors.Is(err, usecase.ErrPasswordExpired):
+				status = http.StatusForbidden
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// EnrollMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/me/2fa [POST]
+// @Summary Enroll two-factor authentication
+// @Description Generate TOTP secret and otpauth URI, enrollment is completed by confirm
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.MFAEnrollResponse
+// @Failure 403 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) EnrollMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, ok := h.GetAuthData(ctx)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("failed to fetch authentication data"),
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      "failed to fetch authentication data",
+			})
+			return
+		}
+
+		secret, uri, err := h.mfaUsecase.Enroll(ctx, claims["user_id"])
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrMFAAlreadyEnabled) {
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.MFAEnrollResponse{
+			Secret:     secret,
+			OtpauthURI: uri,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// ConfirmMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/me/2fa/confirm [POST]
+// @Summary Confirm two-factor authentication
+// @Description Enable enrolled TOTP secret with a valid code, recovery codes are shown only once
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param body body models.MFACodeRequest true "body"
+// @Success 200 {object} models.MFARecoveryCodesResponse
+// @Failure 400 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) ConfirmMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, ok := h.GetAuthData(ctx)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("failed to fetch authentication data"),
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      "failed to fetch authentication data",
+			})
+			return
+		}
+
+		request := models.MFACodeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		recoveryCodes, err := h.mfaUsecase.Confirm(ctx, claims["user_id"], request.Code)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, usecase.ErrInvalidMFACode), errors.Is(err, usecase.ErrMFANotEnrolled):
+				status = http.StatusBadRequest
+			case errors.Is(err, usecase.ErrMFAAlreadyEnabled):
+				status = http.StatusConflict
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		response := models.MFARecoveryCodesResponse{
+			RecoveryCodes: recoveryCodes,
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// DisableMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/me/2fa [DELETE]
+// @Summary Disable two-factor authentication
+// @Description Disable own second factor with a valid code, not allowed if the role requires it
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param body body models.MFACodeRequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) DisableMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		claims, ok := h.GetAuthData(ctx)
+		if !ok {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("failed to fetch authentication data"),
+				HTTPStatusCode: http.StatusUnauthorized,
+				ErrorText:      "failed to fetch authentication data",
+			})
+			return
+		}
+
+		request := models.MFACodeRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		required, err := h.mfaUsecase.IsRequired(ctx, claims["sub"])
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		if required {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            usecase.ErrMFARequired,
+				HTTPStatusCode: http.StatusForbidden,
+				ErrorText:      usecase.ErrMFARequired.Error(),
+			})
+			return
+		}
+
+		if err := h.mfaUsecase.Verify(ctx, claims["user_id"], request.Code); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, usecase.ErrInvalidMFACode) || errors.Is(err, usecase.ErrMFANotEnrolled) {
+				status = http.StatusBadRequest
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		if err := h.mfaUsecase.Disable(ctx, claims["user_id"]); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// ResetMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/user/{id}/2fa [DELETE]
+// @Summary Reset user two-factor authentication
+// @Description Remove second factor of a user who lost the device and revoke tokens of the user, user enrolls again on next login if the role requires it
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param id path string true "id"
+// @Success 200 {object} models.Empty
+// @Failure 404 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) ResetMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		guid := chi.URLParam(r, "id")
+
+		if err := h.mfaUsecase.Disable(ctx, guid); err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, errorspkg.ErrorNotFound) {
+				status = http.StatusNotFound
+			}
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: status,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		// sessions opened with the lost device must not outlive the reset
+		if err := h.rbacUsecase.RevokeTokens(ctx, guid); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		render.JSON(w, r, models.Empty{})
+	}
+}
+
+// GetRolesMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/roles/2fa [GET]
+// @Summary Get two-factor requirement of roles
+// @Description Get two-factor requirement of roles
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Success 200 {object} []models.RoleMFAResponse
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) GetRolesMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		settings, err := h.mfaUsecase.ListRoleSettings(ctx)
+		if err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		required := make(map[string]bool, len(settings))
+		for _, v := range settings {
+			required[v.Role] = v.MFARequired
+		}
+
+		response := []models.RoleMFAResponse{}
+		for _, role := range []string{entity.RoleAdmin, entity.RoleDentist, entity.RoleSecretary} {
+			response = append(response, models.RoleMFAResponse{
+				Role:        role,
+				MFARequired: required[role],
+			})
+		}
+
+		render.JSON(w, r, response)
+	}
+}
+
+// SetRoleMFA
+// @Security ApiKeyAuth
+// @Router /v1/rbac/roles/{role}/2fa [PUT]
+// @Summary Require two-factor authentication for role
+// @Description Require or stop requiring two-factor authentication for every user of the role
+// @Tags Rbac
+// @Accept json
+// @Produce json
+// @Param role path string true "role"
+// @Param body body models.SetRoleMFARequest true "body"
+// @Success 200 {object} models.Empty
+// @Failure 400 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+func (h rbacHandler) SetRoleMFA() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		role := chi.URLParam(r, "role")
+
+		// website is a machine account without interactive login
+		if role != entity.RoleAdmin && role != entity.RoleDentist && role != entity.RoleSecretary {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            errors.New("unknown role"),
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      "unknown role",
+			})
+			return
+		}
+
+		request := models.SetRoleMFARequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusBadRequest,
+				ErrorText:      err.Error(),
+			})
+			return
+		}
+
+		if err := h.mfaUsecase.SetRoleRequired(ctx, role, request.Required); err != nil {
+			render.Render(w, r, &errorsapi.ErrResponse{
+				Err:            err,
+				HTTPStatusCode: http.StatusInternalServerError,
+				ErrorText:      err.Error(),
+			})
+			return
 		}
 
 		render.JSON(w, r, models.Empty{})
@@ -0,0 +1,102 @@
+package v1_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestRbacUsers(t *testing.T) {
+	env := itest.New(t)
+	token := env.Login(t, entity.RoleAdmin)
+
+	resp := env.Request(t, http.MethodPost, "/v1/rbac/user", token, models.CreateUserRequest{
+		Role:      entity.RoleSecretary,
+		Firstname: "Malika",
+		Lastname:  "Usmanova",
+		Username:  "malika",
+		Password:  itest.Password,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("create user: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var user models.GUIDResponse
+	resp.Decode(t, &user)
+
+	// a rejected dentist gets one error and no author
+	resp = env.Request(t, http.MethodPost, "/v1/rbac/user", token, models.CreateUserRequest{
+		Role:      entity.RoleDentist,
+		Firstname: "Weak",
+		Lastname:  "Password",
+		Username:  "weak",
+		Password:  "weak",
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("create user with weak password: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	var rejected models.ResponseError
+	resp.Decode(t, &rejected)
+	var authors []models.Authors
+	env.Request(t, http.MethodGet, "/v1/authors", "", nil).Decode(t, &authors)
+	for _, v := range authors {
+		if v.GUID == "" || v.Name == "weak" {
+			t.Errorf("create user with weak password: author %+v is created", v)
+		}
+	}
+
+	resp = env.Request(t, http.MethodPut, "/v1/rbac/user/"+user.GUID, token, models.UpdateUserRequest{
+		Role:      entity.RoleSecretary,
+		Firstname: "Malika",
+		Lastname:  "Usmanova",
+		Username:  "malika",
+		Password:  "weak",
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("update user with weak password: got %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	resp.Decode(t, &rejected)
+
+	resp = env.Request(t, http.MethodPut, "/v1/rbac/user/"+user.GUID, token, models.UpdateUserRequest{
+		Role:      entity.RoleDentist,
+		Firstname: "Malika",
+		Lastname:  "Usmanova",
+		Username:  "malika",
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update user: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	var users []models.GetAllUsersResponse
+	env.Request(t, http.MethodGet, "/v1/rbac/users", token, nil).Decode(t, &users)
+	found := false
+	for _, v := range users {
+		if v.GUID == user.GUID {
+			found = true
+			if v.Role != entity.RoleDentist || v.Username != "malika" {
+				t.Errorf("list users: got %+v", v)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("list users: %s is not listed", user.GUID)
+	}
+
+	// empty password on update keeps the current one
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "malika", Password: itest.Password})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("login as updated user: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodDelete, "/v1/rbac/user/"+user.GUID, token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete user: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = env.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{Username: "malika", Password: itest.Password})
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("login as deleted user: got %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,177 @@
+package v1_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/itest"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/oidc/mockidp"
+)
+
+// browser keeps cookies like the one the frontend runs in, redirects of identity provider are read, not followed
+type browser struct {
+	t      *testing.T
+	client *http.Client
+}
+
+func newBrowser(t *testing.T) *browser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &browser{
+		t: t,
+		client: &http.Client{
+			Jar:     jar,
+			Timeout: 30 * time.Second,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (b *browser) do(method, rawURL string, body interface{}) *itest.Response {
+	b.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			b.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, rawURL, reader)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.t.Fatalf("%s %s: %v", method, rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+
+	return &itest.Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
+}
+
+// startSSO goes through /v1/sso/login and identity provider and returns code and state it redirects back with
+func (b *browser) startSSO(env *itest.Env) models.SSOCallbackRequest {
+	b.t.Helper()
+
+	resp := b.do(http.MethodGet, env.URL+"/v1/sso/login", nil)
+	if resp.StatusCode != http.StatusOK {
+		b.t.Fatalf("sso login: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var login models.SSOLoginResponse
+	resp.Decode(b.t, &login)
+
+	resp = b.do(http.MethodGet, login.AuthorizationURL, nil)
+	if resp.StatusCode != http.StatusFound {
+		b.t.Fatalf("authorize: got %d %s", resp.StatusCode, resp.Body)
+	}
+	redirect, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		b.t.Fatal(err)
+	}
+
+	return models.SSOCallbackRequest{
+		Code:  redirect.Query().Get("code"),
+		State: redirect.Query().Get("state"),
+	}
+}
+
+func TestSSO(t *testing.T) {
+	idp, err := mockidp.New("abclinic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.AddUser(mockidp.User{
+		Subject:           "mock-dentist",
+		PreferredUsername: "sso-dentist",
+		Email:             "sso-dentist@abclinic.local",
+		GivenName:         "Sso",
+		FamilyName:        "Dentist",
+		Groups:            []string{"clinic-dentists"},
+	})
+	idpServer := httptest.NewServer(idp)
+	t.Cleanup(idpServer.Close)
+
+	env := itest.New(t, func(cfg *config.Config) {
+		cfg.OIDC.IssuerURL = idpServer.URL
+		cfg.OIDC.ClientID = "abclinic"
+		cfg.OIDC.RedirectURL = "http://localhost:3000/sso/callback"
+		cfg.OIDC.RoleMapping = []string{"clinic-dentists=" + entity.RoleDentist}
+	})
+	callbackURL := env.URL + "/v1/sso/callback"
+
+	user := newBrowser(t)
+	attacker := newBrowser(t)
+
+	// login CSRF, the victim is sent a callback of the login started by somebody else
+	callback := attacker.startSSO(env)
+	user.startSSO(env)
+	resp := user.do(http.MethodPost, callbackURL, callback)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback of another browser: got %d %s, want %d", resp.StatusCode, resp.Body, http.StatusBadRequest)
+	}
+
+	callback = user.startSSO(env)
+	resp = newBrowser(t).do(http.MethodPost, callbackURL, callback)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback without state cookie: got %d %s, want %d", resp.StatusCode, resp.Body, http.StatusBadRequest)
+	}
+
+	resp = user.do(http.MethodPost, callbackURL, callback)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var login models.LoginResponse
+	resp.Decode(t, &login)
+
+	resp = env.Request(t, http.MethodGet, "/v1/rbac/user", login.AccessToken, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("user info of sso user: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = user.do(http.MethodPost, callbackURL, callback)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("replayed callback: got %d %s, want %d", resp.StatusCode, resp.Body, http.StatusBadRequest)
+	}
+
+	resp = env.Request(t, http.MethodPut, "/v1/rbac/roles/"+entity.RoleDentist+"/2fa", env.Login(t, entity.RoleAdmin), models.SetRoleMFARequest{Required: true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("require two-factor authentication: got %d %s", resp.StatusCode, resp.Body)
+	}
+
+	resp = user.do(http.MethodPost, callbackURL, user.startSSO(env))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("callback with two-factor authentication: got %d %s", resp.StatusCode, resp.Body)
+	}
+	var challenge models.LoginResponse
+	resp.Decode(t, &challenge)
+	if !challenge.MFARequired || !challenge.MFAEnrollmentRequired || challenge.MFAToken == "" || challenge.AccessToken != "" {
+		t.Errorf("callback with two-factor authentication: got %s, want mfa enrollment challenge", resp.Body)
+	}
+}
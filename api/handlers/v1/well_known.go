@@ -0,0 +1,45 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/AsaHero/abclinic/api/handlers"
+	"github.com/AsaHero/abclinic/internal/pkg/token"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+type wellKnownHandler struct {
+	tokenKeys *token.KeySet
+}
+
+func NewWellKnownHandler(args handlers.HandlerArguments) http.Handler {
+	handler := wellKnownHandler{
+		tokenKeys: args.TokenKeys,
+	}
+
+	router := chi.NewRouter()
+
+	router.Group(func(r chi.Router) {
+		// public apis
+		r.Get("/jwks.json", handler.GetJwks())
+	})
+
+	return router
+}
+
+// GetJwks
+// @Router /.well-known/jwks.json [GET]
+// @Summary Get JSON Web Key Set
+// @Description Public keys to verify access tokens, keys are matched by kid header
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} token.JWKS
+func (h wellKnownHandler) GetJwks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// verifiers should refetch soon enough to pick up rotated keys
+		w.Header().Set("Cache-Control", "public, max-age=300")
+
+		render.JSON(w, r, h.tokenKeys.JWKS())
+	}
+}
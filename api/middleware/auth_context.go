@@ -3,30 +3,55 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
 	tokenpkg "github.com/AsaHero/abclinic/internal/pkg/token"
+	"go.uber.org/zap"
 )
 
-func AuthContext(jwtsecret string) func(next http.Handler) http.Handler {
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeyAuthenticator resolves key from X-API-Key header into an active key, it refuses malformed keys
+// without looking them up
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*entity.APIKeys, error)
+}
+
+// AuthContext resolves bearer token of the request, requests with X-API-Key are left to APIKeyContext
+func AuthContext(keys *tokenpkg.KeySet, denylist *denylist.Denylist) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var (
-				token    string
-				authData = make(map[string]string)
-			)
+			if r.Header.Get(HeaderAPIKey) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var token string
 
 			if token = r.Header.Get("Authorization"); len(token) > 10 {
 				token = token[7:]
 			}
 
-			claims, err := tokenpkg.ParseJwtToken(token, jwtsecret)
-			if err == nil && len(claims) != 0 {
-				for k, v := range claims {
-					if value, ok := v.(string); ok {
-						authData[k] = value
-					}
+			claims, err := tokenpkg.ParseJwtToken(token, keys, tokenpkg.TypeAccess)
+			if err == nil {
+				// revoked tokens are treated as anonymous requests
+				if denylist.IsTokenRevoked(claims.ID) ||
+					denylist.IsUserRevoked(claims.UserID, claims.Issued()) {
+					next.ServeHTTP(w, r)
+					return
 				}
 
+				authData := map[string]string{
+					"sub":     claims.Role(),
+					"user_id": claims.UserID,
+					"jti":     claims.ID,
+				}
+				logger.With(r.Context(), zap.String("role", claims.Role()), zap.String("user_id", claims.UserID))
+
 				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxKeyAuthData, authData)))
 				return
 			}
@@ -35,3 +60,42 @@ func AuthContext(jwtsecret string) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// APIKeyContext resolves X-API-Key of the request, it runs before RateLimit so only verified keys get a bucket
+// of their own. Failed lookups are limited per address by failures, an address without failures left gets 429
+// before its keys are looked up.
+func APIKeyContext(apiKeys APIKeyAuthenticator, failures *ratelimit.FailureLimiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderAPIKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ip := ClientIP(r)
+			if result := failures.Check(ip); !result.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			// invalid api keys are treated as anonymous requests, same as invalid tokens
+			apiKey, err := apiKeys.Authenticate(r.Context(), key)
+			if err != nil {
+				failures.Fail(ip)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authData := map[string]string{
+				"sub":        apiKey.Role,
+				"api_key_id": apiKey.GUID,
+				"scopes":     strings.Join(apiKey.Scopes, ","),
+			}
+			logger.With(r.Context(), zap.String("role", apiKey.Role), zap.String("api_key_id", apiKey.GUID))
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxKeyAuthData, authData)))
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+)
+
+// fakeAPIKeys knows a single key and counts lookups
+type fakeAPIKeys struct {
+	lookups int
+}
+
+func (f *fakeAPIKeys) Authenticate(ctx context.Context, key string) (*entity.APIKeys, error) {
+	f.lookups++
+	if key != "valid" {
+		return nil, errors.New("invalid api key")
+	}
+	return &entity.APIKeys{GUID: "key", Role: entity.RoleAdmin}, nil
+}
+
+func TestAPIKeyContext(t *testing.T) {
+	store := ratelimit.NewMemoryStore(time.Minute)
+	defer store.Close()
+
+	apiKeys := &fakeAPIKeys{}
+	handler := APIKeyContext(apiKeys, ratelimit.NewFailureLimiter(store, "api key failures", ratelimit.Limit{Requests: 2, Period: time.Minute}))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if data, ok := r.Context().Value(CtxKeyAuthData).(map[string]string); ok {
+				w.Write([]byte(data["api_key_id"]))
+			}
+		}),
+	)
+
+	tests := []struct {
+		name        string
+		key         string
+		want        int
+		wantKeyID   string
+		wantLookups int
+	}{
+		{name: "valid key", key: "valid", want: http.StatusOK, wantKeyID: "key", wantLookups: 1},
+		{name: "valid keys don't use failures up", key: "valid", want: http.StatusOK, wantKeyID: "key", wantLookups: 2},
+		{name: "invalid key is anonymous", key: "guess-1", want: http.StatusOK, wantLookups: 3},
+		{name: "last failure left", key: "guess-2", want: http.StatusOK, wantLookups: 4},
+		{name: "out of failures", key: "guess-3", want: http.StatusTooManyRequests, wantLookups: 4},
+		{name: "valid key out of failures", key: "valid", want: http.StatusTooManyRequests, wantLookups: 4},
+		{name: "no key", want: http.StatusOK, wantLookups: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/dentists", nil)
+			r.RemoteAddr = "203.0.113.7:4000"
+			if tt.key != "" {
+				r.Header.Set(HeaderAPIKey, tt.key)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("got %d, want %d", w.Code, tt.want)
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.wantKeyID {
+				t.Errorf("got api key %q, want %q", w.Body.String(), tt.wantKeyID)
+			}
+			if apiKeys.lookups != tt.wantLookups {
+				t.Errorf("got %d lookups, want %d", apiKeys.lookups, tt.wantLookups)
+			}
+		})
+	}
+}
@@ -2,12 +2,17 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
 
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
 )
 
-func Authorizer(e *casbin.Enforcer, logger *zap.Logger) func(next http.Handler) http.Handler {
+func Authorizer(e *casbin.Enforcer, m *metrics.Metrics) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			data, ok := r.Context().Value(CtxKeyAuthData).(map[string]string)
@@ -16,15 +21,25 @@ func Authorizer(e *casbin.Enforcer, logger *zap.Logger) func(next http.Handler)
 				return
 			}
 
+			// api keys without write scope are limited to safe methods
+			if data["api_key_id"] != "" && !isSafeMethod(r.Method) &&
+				!strings.Contains(","+data["scopes"]+",", ","+entity.ScopeWrite+",") {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
 			ok, err := e.Enforce(data["sub"], r.URL.Path, r.Method)
 			if err != nil {
-				logger.Error("middleware authorizer",
+				logger.FromContext(r.Context()).Error("middleware authorizer",
 					zap.Error(err),
 					zap.String("sub", data["sub"]),
 					zap.String("path", r.URL.Path),
 					zap.String("method", r.Method),
 				)
 			}
+			if m != nil {
+				m.AuthzDecisions.WithLabelValues(data["sub"], authzDecision(ok)).Inc()
+			}
 			if ok {
 				next.ServeHTTP(w, r)
 				return
@@ -33,3 +48,27 @@ func Authorizer(e *casbin.Enforcer, logger *zap.Logger) func(next http.Handler)
 		})
 	}
 }
+
+// RequireUser rejects api keys on routes acting on the user of the request, they have no user
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := r.Context().Value(CtxKeyAuthData).(map[string]string)
+		if !ok || data["user_id"] == "" {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func authzDecision(allowed bool) string {
+	if allowed {
+		return "allow"
+	}
+	return "deny"
+}
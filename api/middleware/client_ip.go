@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the client address, RealIP middleware has already resolved proxy headers into RemoteAddr
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// RealIP sets RemoteAddr to the client address forwarded by one of trustedProxies, CIDRs of proxies in front of
+// the server. X-Forwarded-For is read from the right skipping trusted addresses, X-Real-IP is used without it.
+// Forwarding headers of other peers are ignored, they would let a client pick the address rate limits and
+// login backoff count it by.
+func RealIP(trustedProxies []string) func(next http.Handler) http.Handler {
+	var networks []*net.IPNet
+	for _, v := range trustedProxies {
+		// config validation has rejected malformed ones
+		if _, network, err := net.ParseCIDR(v); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	trusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if trusted(ClientIP(r)) {
+				if ip := forwardedIP(r, trusted); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the nearest address forwarded through trusted proxies, empty when headers are malformed
+func forwardedIP(r *http.Request, trusted func(addr string) bool) string {
+	if forwardedFor := r.Header.Values("X-Forwarded-For"); len(forwardedFor) > 0 {
+		addrs := strings.Split(strings.Join(forwardedFor, ","), ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(addrs[i]))
+			if ip == nil {
+				return ""
+			}
+			// every hop is a trusted proxy, the first one is the closest to the client there is
+			if !trusted(ip.String()) || i == 0 {
+				return ip.String()
+			}
+		}
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	return ""
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	handler := RealIP([]string{"10.0.0.0/8", "fd00::/8"})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"direct client", "203.0.113.7:4000", nil, "203.0.113.7"},
+		{"untrusted peer forwarding", "203.0.113.7:4000", http.Header{"X-Forwarded-For": {"198.51.100.1"}, "X-Real-Ip": {"198.51.100.2"}}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, "198.51.100.1"},
+		{"client spoofing behind trusted proxy", "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"192.0.2.1, 198.51.100.1"}}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"198.51.100.1, 10.0.0.3", "10.0.0.4"}}, "198.51.100.1"},
+		{"only trusted proxies", "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"10.0.0.5, 10.0.0.3"}}, "10.0.0.5"},
+		{"trusted ipv6 proxy", "[fd00::1]:4000", http.Header{"X-Forwarded-For": {"2001:db8::1"}}, "2001:db8::1"},
+		{"x-real-ip of trusted proxy", "10.0.0.2:4000", http.Header{"X-Real-Ip": {"198.51.100.1"}}, "198.51.100.1"},
+		{"malformed forwarded for", "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"198.51.100.1, unknown"}}, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+
+			var got string
+			handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("got client ip %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,4 +4,5 @@ type ctxKey int
 
 const (
 	CtxKeyAuthData ctxKey = 0 
+	CtxKeyRequestID ctxKey = 1
 )
\ No newline at end of file
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPCache makes successful GET responses revalidatable: ETag is a hash of the body, a request with
+// If-None-Match matching it gets 304. If-Modified-Since is compared only with Last-Modified a handler set
+// from the modification time of its data, none is made up here.
+// Cache-Control lets clients reuse responses for maxAge, with zero maxAge they revalidate every time.
+func HTTPCache(maxAge time.Duration) func(next http.Handler) http.Handler {
+	cacheControl := "no-cache"
+	if maxAge > 0 {
+		cacheControl = "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buffered := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+			next.ServeHTTP(buffered, r)
+
+			if buffered.status != http.StatusOK {
+				w.WriteHeader(buffered.status)
+				w.Write(buffered.body.Bytes())
+				return
+			}
+
+			sum := sha256.Sum256(buffered.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", cacheControl)
+
+			if notModified(r, etag, w.Header().Get("Last-Modified")) {
+				// 304 has no body, headers describing it are dropped
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(buffered.body.Len()))
+			w.WriteHeader(http.StatusOK)
+			w.Write(buffered.body.Bytes())
+		})
+	}
+}
+
+// notModified checks If-None-Match against etag, without it If-Modified-Since against lastModified
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, v := range strings.Split(ifNoneMatch, ",") {
+			// weak comparison, a W/ prefix doesn't matter
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == "*" || v == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+// bufferedResponse holds the body until it can be hashed, headers are written to the real response directly
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
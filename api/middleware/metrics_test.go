@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMetricsRouteLabel(t *testing.T) {
+	m := metrics.New()
+
+	dentists := chi.NewRouter()
+	dentists.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	router := chi.NewRouter()
+	router.Use(Metrics(m))
+	router.Route("/v1", func(r chi.Router) {
+		r.Mount("/dentists", dentists)
+	})
+
+	for _, path := range []string{"/v1/dentists/42", "/v1/dentists/43", "/unknown/42"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "route pattern", want: `abclinic_http_requests_total{method="GET",route="/v1/dentists/{id}",status="200"} 2`},
+		{name: "unmatched", want: `abclinic_http_requests_total{method="GET",route="unmatched",status="404"} 1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("%s is not recorded:\n%s", tt.want, body)
+			}
+		})
+	}
+	if strings.Contains(body, "/v1/dentists/42") {
+		t.Errorf("raw path is recorded as a label:\n%s", body)
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+)
+
+// RateLimit rejects requests over the quota of the first matching rule, it runs after AuthContext and APIKeyContext
+// to key by identity they verified
+func RateLimit(limiter *ratelimit.Limiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule, ok := limiter.Match(r.Method, r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result := limiter.Take(rule, rateLimitIdentity(r, rule.Key))
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitIdentity keys verified api keys and users by their ids, requests with an unknown key share the bucket
+// of their address with anonymous ones, so sending a new key every time doesn't get a new bucket
+func rateLimitIdentity(r *http.Request, key string) string {
+	if key == ratelimit.KeyIdentity {
+		if data, ok := r.Context().Value(CtxKeyAuthData).(map[string]string); ok {
+			if data["api_key_id"] != "" {
+				return "key:" + data["api_key_id"]
+			}
+			if data["user_id"] != "" {
+				return "user:" + data["user_id"]
+			}
+		}
+	}
+
+	return "ip:" + ClientIP(r)
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
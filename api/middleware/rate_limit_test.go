@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+)
+
+func TestRateLimitIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		authData map[string]string
+		apiKey   string
+		key      string
+		want     string
+	}{
+		{name: "anonymous", key: ratelimit.KeyIdentity, want: "ip:203.0.113.7"},
+		{name: "user", authData: map[string]string{"user_id": "user"}, key: ratelimit.KeyIdentity, want: "user:user"},
+		{name: "verified api key", authData: map[string]string{"api_key_id": "key"}, apiKey: "abk_000000000000_secret", key: ratelimit.KeyIdentity, want: "key:key"},
+		{name: "unverified api key", apiKey: "abk_000000000000_secret", key: ratelimit.KeyIdentity, want: "ip:203.0.113.7"},
+		{name: "user under ip rule", authData: map[string]string{"user_id": "user"}, key: ratelimit.KeyIP, want: "ip:203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/dentists", nil)
+			r.RemoteAddr = "203.0.113.7:4000"
+			if tt.apiKey != "" {
+				r.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+			if tt.authData != nil {
+				r = r.WithContext(context.WithValue(r.Context(), CtxKeyAuthData, tt.authData))
+			}
+
+			if got := rateLimitIdentity(r, tt.key); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID = "X-Request-Id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID keeps a well-formed X-Request-Id from the caller or generates one and echoes it in the response
+func RequestID() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(HeaderRequestID)
+			if !validRequestID(requestID) {
+				requestID = uuid.New().String()
+			}
+
+			w.Header().Set(HeaderRequestID, requestID)
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), CtxKeyRequestID, requestID)))
+		})
+	}
+}
+
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(CtxKeyRequestID).(string)
+	return requestID
+}
+
+// validRequestID accepts printable ascii only, the id ends up in logs and response headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// RequestLogger stores a request-scoped logger in the context and writes one access log line per request,
+// it runs after RequestID and Tracing to pick up their ids
+func RequestLogger(base *zap.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			fields := []zap.Field{
+				zap.String("request_id", GetRequestID(r.Context())),
+			}
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+			}
+
+			// route is resolved while the request goes through mounted routers, read it when logging
+			rctx := chi.RouteContext(r.Context())
+			ctx := logger.NewContext(r.Context(), base.With(fields...), func() zap.Field {
+				if rctx == nil {
+					return zap.Skip()
+				}
+				return zap.String("route", rctx.RoutePattern())
+			})
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			log := logger.FromContext(ctx)
+			accessFields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.String("remote_ip", ClientIP(r)),
+				zap.Int("status", status),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("duration", time.Since(start)),
+			}
+
+			switch {
+			case status >= http.StatusInternalServerError:
+				log.Error("http request", accessFields...)
+			case status >= http.StatusBadRequest:
+				log.Warn("http request", accessFields...)
+			default:
+				log.Info("http request", accessFields...)
+			}
+		})
+	}
+}
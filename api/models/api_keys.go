@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	GUID string `json:"guid"`
+	// Key is shown only once, send it in X-API-Key header
+	Key string `json:"key"`
+}
+
+type APIKeyResponse struct {
+	GUID       string     `json:"guid"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Role       string     `json:"role"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -3,11 +3,31 @@ package models
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// NewPassword is required when password was reset by admin
+	NewPassword string `json:"new_password,omitempty"`
 }
 
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// MFAToken is exchanged for tokens at /v1/login/mfa when MFARequired is set
+	MFARequired           bool     `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool     `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string   `json:"mfa_token,omitempty"`
+	RecoveryCodes         []string `json:"recovery_codes,omitempty"`
+	// PasswordChangeRequired asks for new_password at /v1/login/mfa, password was reset by admin
+	PasswordChangeRequired bool `json:"password_change_required,omitempty"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+	// NewPassword is required when login response has password_change_required
+	NewPassword string `json:"new_password,omitempty"`
+}
+
+type MFAEnrollRequest struct {
+	MFAToken string `json:"mfa_token"`
 }
 
 type RefreshTokenRequest struct {
@@ -18,3 +38,16 @@ type RefreshTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SSOLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type SSOCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
@@ -1,10 +1,20 @@
 package models
 
+import "time"
+
+type TierPrice struct {
+	Tier string `json:"tier"`
+	// Amount is in minor units of the currency, e.g. cents of USD
+	Amount int64 `json:"amount"`
+	// From makes amount the lowest price, "from X", the final one depends on the case
+	From bool `json:"from"`
+}
+
 type Services struct {
-	GUID        string  `json:"guid"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	UrgentPrice float64 `json:"urgent_price"`
+	GUID     string      `json:"guid"`
+	Name     string      `json:"name"`
+	Currency string      `json:"currency"`
+	Prices   []TierPrice `json:"prices"`
 }
 
 type ServicesGroup struct {
@@ -17,16 +27,72 @@ type GUIDResponse struct {
 }
 
 type CreateServiceRequest struct {
-	GroupID     string  `json:"group_id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	UrgentPrice float64 `json:"urgent_price"`
+	GroupID string `json:"group_id"`
+	Name    string `json:"name"`
+	// Currency is the clinic's default when omitted
+	Currency string      `json:"currency,omitempty"`
+	Prices   []TierPrice `json:"prices"`
 }
 
 type UpdateServiceRequest struct {
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	UrgentPrice float64 `json:"urgent_price"`
+	Name string `json:"name"`
+	// Currency is the clinic's default when omitted
+	Currency string `json:"currency,omitempty"`
+	// Prices are left as they are when omitted
+	Prices []TierPrice `json:"prices,omitempty"`
+}
+
+type ServicePrice struct {
+	GUID      string      `json:"guid"`
+	Currency  string      `json:"currency"`
+	Prices    []TierPrice `json:"prices"`
+	ValidFrom time.Time   `json:"valid_from"`
+	ValidTo   *time.Time  `json:"valid_to"`
+}
+
+type SchedulePriceRequest struct {
+	// Currency is the clinic's default when omitted
+	Currency string      `json:"currency,omitempty"`
+	Prices   []TierPrice `json:"prices"`
+	// ValidFrom is now when omitted
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+}
+
+type PriceTier struct {
+	GUID     string `json:"guid"`
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type CreatePriceTierRequest struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type UpdatePriceTierRequest struct {
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type ExchangeRate struct {
+	GUID  string `json:"guid"`
+	Base  string `json:"base"`
+	Quote string `json:"quote"`
+	// Rate is price of one unit of base in quote
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateExchangeRateRequest struct {
+	Base  string  `json:"base"`
+	Quote string  `json:"quote"`
+	Rate  float64 `json:"rate"`
+}
+
+type UpdateExchangeRateRequest struct {
+	Rate float64 `json:"rate"`
 }
 
 type CreateServiceGroupRequest struct {
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type GetRolesResponse struct {
 	Roles []string `json:"roles"`
 }
@@ -25,7 +27,18 @@ type UpdateUserRequest struct {
 	Firstname string `json:"firstname"`
 	Lastname  string `json:"lastname"`
 	Username  string `jsom:"username"`
-	Password  string `json:"password"`
+	// Password is kept unchanged if empty
+	Password string `json:"password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ResetPasswordResponse struct {
+	ResetToken string    `json:"reset_token"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
 
 type GetAllUsersResponse struct {
@@ -43,3 +56,25 @@ type GetUserResponse struct {
 	Lastname  string `json:"lastname"`
 	Username  string `jsom:"username"`
 }
+
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type RoleMFAResponse struct {
+	Role        string `json:"role"`
+	MFARequired bool   `json:"mfa_required"`
+}
+
+type SetRoleMFARequest struct {
+	Required bool `json:"required"`
+}
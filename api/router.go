@@ -12,6 +12,12 @@ import (
 	v1 "github.com/AsaHero/abclinic/api/handlers/v1"
 	"github.com/AsaHero/abclinic/api/middleware"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/health"
+	"github.com/AsaHero/abclinic/internal/pkg/loginguard"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+	"github.com/AsaHero/abclinic/internal/pkg/token"
 	"github.com/AsaHero/abclinic/internal/usecase"
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
@@ -22,12 +28,22 @@ type RouteArguments struct {
 	Config              *config.Config
 	Logger              *zap.Logger
 	Enforcer            *casbin.Enforcer
+	Denylist            *denylist.Denylist
+	TokenKeys           *token.KeySet
+	LoginGuard          *loginguard.Guard
+	Health              *health.Checker
+	Metrics             *metrics.Metrics
+	RateLimiter         *ratelimit.Limiter
+	APIKeyFailures      *ratelimit.FailureLimiter
 	DentistsUsecase     usecase.Denstists
 	PriceListUsecase    usecase.PriceList
 	InfoUsecase         usecase.InfoUsecase
 	BlogsUsecase        usecase.Blogs
 	RbacUsecase         usecase.Rbac
 	RefreshTokenUsecase usecase.RefreshToken
+	MFAUsecase          usecase.MFA
+	SSOUsecase          usecase.SSO
+	APIKeysUsecase      usecase.APIKeys
 }
 
 // NewRoute
@@ -39,27 +55,48 @@ func NewRouter(args RouteArguments) http.Handler {
 		Config:              args.Config,
 		Logger:              args.Logger,
 		Enforcer:            args.Enforcer,
+		Denylist:            args.Denylist,
+		TokenKeys:           args.TokenKeys,
+		LoginGuard:          args.LoginGuard,
+		Health:              args.Health,
+		Metrics:             args.Metrics,
 		DentistsUsecase:     args.DentistsUsecase,
 		PriceListUsecase:    args.PriceListUsecase,
 		InfoUsecase:         args.InfoUsecase,
 		BlogsUsecase:        args.BlogsUsecase,
 		RbacUsecase:         args.RbacUsecase,
 		RefreshTokenUsecase: args.RefreshTokenUsecase,
+		MFAUsecase:          args.MFAUsecase,
+		SSOUsecase:          args.SSOUsecase,
+		APIKeysUsecase:      args.APIKeysUsecase,
 	}
 
 	router := chi.NewRouter()
-	router.Use(chimiddleware.RealIP, chimiddleware.Logger, chimiddleware.Recoverer)
+	router.Use(
+		middleware.RealIP(args.Config.Server.TrustedProxies),
+		middleware.RequestID(),
+		middleware.Tracing(),
+		middleware.RequestLogger(args.Logger),
+		chimiddleware.Recoverer,
+	)
+	if args.Metrics != nil {
+		router.Use(middleware.Metrics(args.Metrics))
+	}
 	// router.Use(chimiddleware.Timeout(args.ContextTimeout))
 	router.Use(cors.Handler(cors.Options{
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Request-Id"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", middleware.HeaderRequestID, "Traceparent", "Tracestate", middleware.HeaderAPIKey, "If-None-Match", "If-Modified-Since"},
+		ExposedHeaders:   []string{"Link", middleware.HeaderRequestID, "ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
 	router.Route("/v1", func(r chi.Router) {
-		r.Use(middleware.AuthContext(args.Config.Token.Secret))
+		r.Use(middleware.AuthContext(args.TokenKeys, args.Denylist))
+		r.Use(middleware.APIKeyContext(args.APIKeysUsecase, args.APIKeyFailures))
+		if args.RateLimiter != nil {
+			r.Use(middleware.RateLimit(args.RateLimiter))
+		}
 		r.Mount("/", v1.NewAuthHandler(handlersArgs))
 		r.Mount("/dentists", v1.NewDentistsHandler(handlersArgs))
 		r.Mount("/services", v1.NewPriceListHandler(handlersArgs))
@@ -68,8 +105,12 @@ func NewRouter(args RouteArguments) http.Handler {
 		r.Mount("/authors", v1.NewAuthorsHandler(handlersArgs))
 		r.Mount("/file", v1.NewFilesHandler(handlersArgs))
 		r.Mount("/rbac", v1.NewRbacHandler(handlersArgs))
+		r.Mount("/api-keys", v1.NewAPIKeysHandler(handlersArgs))
 	})
 
+	router.Mount("/.well-known", v1.NewWellKnownHandler(handlersArgs))
+	router.Mount("/", v1.NewHealthHandler(handlersArgs))
+
 	// declare swagger api route
 	router.Get("/swagger/*", httpSwagger.Handler())
 	return router
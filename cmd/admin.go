@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/app"
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+)
+
+var roles = []string{
+	entity.RoleAdmin,
+	entity.RoleDentist,
+	entity.RoleSecretary,
+	entity.RoleWebsite,
+}
+
+// newAdmin connects maintenance commands to the configured database, settings of the http server aren't validated
+func newAdmin(configFile string) (*app.Admin, error) {
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load config: %w", err)
+	}
+
+	return app.NewAdmin(cfg)
+}
+
+func findUser(ctx context.Context, admin *app.Admin, username string) (*entity.Users, error) {
+	if username == "" {
+		return nil, errors.New("-username is required")
+	}
+
+	user, err := admin.RbacUsecase.GetUser(ctx, entity.UsersFilter{Username: username})
+	if err != nil {
+		if errors.Is(err, errorspkg.ErrorNotFound) {
+			return nil, fmt.Errorf("user %q not found", username)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func checkRole(role string) error {
+	for _, v := range roles {
+		if role == v {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown role %q, one of %s is expected", role, strings.Join(roles, ", "))
+}
+
+// readPassword reads the first line of stdin, so passwords don't end up in shell history
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("empty password")
+	}
+
+	return password, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+)
+
+func configCommand(configFile string, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New("usage: abclinic config print")
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	// printing an invalid config is still useful to find the wrong value
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	return nil
+}
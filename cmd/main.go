@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -11,36 +14,86 @@ import (
 	"go.uber.org/zap"
 )
 
+const usage = `Usage: abclinic [-config file] [command]
+
+Commands:
+  serve          run the http server, default
+  config print   print effective configuration with secrets redacted
+  migrate        apply or roll back schema migrations: up, down [-all] [steps], status, force version
+  user           create a user, reset password or set role: create, reset-password, set-role
+  token revoke   revoke tokens of a user or a single refresh token
+  policy sync    check policy.csv against routes, -prune removes stale policies
+  seed           create the first admin if it doesn't exist and load fixtures
+
+Configuration is read from defaults, then -config or CONFIG_FILE (.yaml, .yml or .toml),
+then environment variables.
+`
+
 func main() {
-	// load dot env file
-	// err := godotenv.Load()
-	// if err != nil {
-	// 	log.Fatalf("cannot Load .env: %v", err)
-	// }
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	var err error
+	switch args[0] {
+	case "serve":
+		err = serve(*configFile)
+	case "config":
+		err = configCommand(*configFile, args[1:])
+	case "migrate":
+		err = migrateCommand(*configFile, args[1:])
+	case "user":
+		err = userCommand(*configFile, args[1:])
+	case "token":
+		err = tokenCommand(*configFile, args[1:])
+	case "policy":
+		err = policyCommand(*configFile, args[1:])
+	case "seed":
+		err = seedCommand(*configFile, args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(configFile string) error {
 	// config init
-	cfg, err := config.NewConfig()
+	cfg, err := config.Load(configFile)
 	if err != nil {
-		log.Fatalf("cannot load env: %v", err)
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
 	}
 
 	// app init
-	app := app.NewApp(cfg)
-
-	// app runs
-	go func() {
-		app.Logger.Info("Listen:", zap.String("address", cfg.Server.Host+cfg.Server.Port))
-		if err := app.Run(); err != nil {
-			app.Logger.Error("error while running server", zap.Error(err))
-		}
-	}()
-
-	// wait for sigint
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	<-sigs
-
-	// app stops
-	app.Logger.Info("abclinic sevrver stops")
-	app.Stop()
+	app, err := app.NewApp(cfg)
+	if err != nil {
+		return fmt.Errorf("cannot init app: %w", err)
+	}
+
+	// stop on sigint or sigterm
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// app runs until stopped
+	if err := app.Run(ctx); err != nil {
+		app.Logger.Error("abclinic server stopped with error", zap.Error(err))
+		return err
+	}
+
+	app.Logger.Info("abclinic server stopped")
+	return nil
 }
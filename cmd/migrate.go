@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/migrate"
+)
+
+const migrateUsage = `usage: abclinic migrate up
+       abclinic migrate down [-all] [steps]
+       abclinic migrate status
+       abclinic migrate force version`
+
+func migrateCommand(configFile string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// only database settings matter here, so the rest of config isn't validated
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+
+	zapLogger, err := logger.New(cfg.LogLevel, cfg.Environment, cfg.APP+".log")
+	if err != nil {
+		return fmt.Errorf("error on logger init: %w", err)
+	}
+	defer zapLogger.Sync()
+
+	migrator, err := migrate.New(cfg, zapLogger)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch args[0] {
+	case "up":
+		return migrator.Up()
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		all := flags.Bool("all", false, "roll back every applied migration")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		steps := 1
+		switch {
+		case *all:
+			steps = 0
+		case flags.NArg() > 0:
+			steps, err = strconv.Atoi(flags.Arg(0))
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q", flags.Arg(0))
+			}
+		}
+
+		return migrator.Down(steps)
+	case "status":
+		return printMigrationStatus(migrator)
+	case "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		return migrator.Force(version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+func printMigrationStatus(migrator *migrate.Migrator) error {
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version: %d", status.Version)
+	if status.Dirty {
+		fmt.Print(" (dirty, fix the schema and run migrate force)")
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Applied {
+			state = "applied"
+		}
+		fmt.Fprintf(w, "%06d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+
+	return w.Flush()
+}
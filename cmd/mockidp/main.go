@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/pkg/oidc/mockidp"
+)
+
+// mockidp runs a local OpenID Connect provider for trying single sign-on,
+// point OIDC_ISSUER_URL to its address and OIDC_CLIENT_ID to -client-id.
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	clientID := flag.String("client-id", "abclinic", "accepted client id")
+	users := flag.String("users", "admin=clinic-admins,dentist=clinic-dentists,secretary=clinic-secretaries",
+		"comma separated username=group pairs, pick one with login_hint=<username>")
+	flag.Parse()
+
+	server, err := mockidp.New(*clientID)
+	if err != nil {
+		log.Fatalf("cannot create mock idp: %v", err)
+	}
+
+	for _, pair := range strings.Split(*users, ",") {
+		username, group, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if username == "" {
+			continue
+		}
+
+		server.AddUser(mockidp.User{
+			Subject:           "mock-" + username,
+			PreferredUsername: username,
+			Email:             username + "@abclinic.local",
+			GivenName:         username,
+			FamilyName:        "Mock",
+			Groups:            []string{group},
+		})
+	}
+
+	log.Printf("mock idp listens on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
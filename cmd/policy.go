@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/app"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"go.uber.org/zap"
+)
+
+const policyUsage = `usage: abclinic policy sync [-prune]
+
+Checks policy.csv, the only source of policies, against routes of the http server and
+prints policies granting a path and method no route serves, -prune also removes them
+from the file. Running servers load the file on start.`
+
+func policyCommand(configFile string, args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return errors.New(policyUsage)
+	}
+
+	flags := flag.NewFlagSet("policy sync", flag.ContinueOnError)
+	prune := flags.Bool("prune", false, "remove policies no route serves")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+
+	stale, err := app.SyncPolicy(cfg, zap.NewNop(), *prune)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range stale {
+		fmt.Println("- p, " + strings.Join(v, ", "))
+	}
+	if *prune {
+		fmt.Printf("%d removed\n", len(stale))
+	} else {
+		fmt.Printf("%d stale\n", len(stale))
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/AsaHero/abclinic/internal/app"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/fixtures"
+)
+
+const seedUsage = `usage: abclinic seed [-admin-username name] [-fixtures file]
+
+Creates the first admin unless a user with the name exists, so it's safe to run on every deploy.
+Password is taken from ADMIN_PASSWORD or read from stdin, empty -admin-username skips the admin.
+-fixtures loads .yaml, .yml or .json content, fixtures present by natural key are updated, not duplicated.`
+
+func seedCommand(configFile string, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), seedUsage)
+		flags.PrintDefaults()
+	}
+	adminUsername := flags.String("admin-username", "admin", "username of the first admin")
+	fixturesFile := flags.String("fixtures", "", "fixtures file to load, e.g. fixtures/demo.yaml")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return errors.New(seedUsage)
+	}
+
+	// fixtures are read first, so a broken file fails before touching database
+	var data *fixtures.Fixtures
+	if *fixturesFile != "" {
+		var err error
+		if data, err = fixtures.Read(*fixturesFile); err != nil {
+			return err
+		}
+	}
+
+	admin, err := newAdmin(configFile)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	ctx := context.Background()
+
+	if *adminUsername != "" {
+		if err := seedAdmin(ctx, admin, *adminUsername); err != nil {
+			return err
+		}
+	}
+
+	if data != nil {
+		loader := fixtures.NewLoader(admin.DentistsUsecase, admin.PriceListUsecase, admin.InfoUsecase, admin.BlogsUsecase)
+
+		result, err := loader.Load(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("fixtures loaded: %d created, %d updated, %d unchanged\n", result.Created, result.Updated, result.Unchanged)
+	}
+
+	return nil
+}
+
+func seedAdmin(ctx context.Context, admin *app.Admin, username string) error {
+	exists, _, err := admin.RbacUsecase.UsernameExists(ctx, username)
+	if err != nil {
+		return err
+	}
+	if exists {
+		fmt.Printf("user %s exists, skipped\n", username)
+		return nil
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		if password, err = readPassword(); err != nil {
+			return err
+		}
+	}
+
+	guid, err := admin.RbacUsecase.CreateUser(ctx, &entity.Users{
+		Role:     entity.RoleAdmin,
+		Username: username,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("user %s created: %s\n", username, guid)
+	return nil
+}
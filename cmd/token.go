@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+const tokenUsage = `usage: abclinic token revoke -username name
+       abclinic token revoke refresh-token
+
+Refresh tokens are deleted at once. Access tokens already issued stay valid until they expire,
+since running servers keep their denylist in memory.`
+
+func tokenCommand(configFile string, args []string) error {
+	if len(args) == 0 || args[0] != "revoke" {
+		return errors.New(tokenUsage)
+	}
+
+	flags := flag.NewFlagSet("token revoke", flag.ContinueOnError)
+	username := flags.String("username", "", "revoke every token of the user")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if (*username == "") == (flags.NArg() == 0) || flags.NArg() > 1 {
+		return errors.New(tokenUsage)
+	}
+
+	admin, err := newAdmin(configFile)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	ctx := context.Background()
+
+	if *username != "" {
+		user, err := findUser(ctx, admin, *username)
+		if err != nil {
+			return err
+		}
+
+		if err := admin.RbacUsecase.RevokeTokens(ctx, user.GUID); err != nil {
+			return err
+		}
+
+		fmt.Printf("tokens of %s revoked\n", user.Username)
+		return nil
+	}
+
+	refreshToken, err := admin.RefreshTokenUsecase.Get(ctx, flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("refresh token not found: %w", err)
+	}
+
+	if err := admin.RefreshTokenUsecase.Delete(ctx, refreshToken.RefreshToken); err != nil {
+		return err
+	}
+
+	fmt.Println("refresh token revoked")
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+const userUsage = `usage: abclinic user create -username name -role role [-firstname name] [-lastname name]
+       abclinic user reset-password -username name [-password-stdin]
+       abclinic user set-role -username name -role role
+
+Passwords are read from stdin. reset-password without -password-stdin issues a one-time password
+which must be changed on next login.`
+
+func userCommand(configFile string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+
+	switch args[0] {
+	case "create", "reset-password", "set-role":
+	default:
+		return errors.New(userUsage)
+	}
+
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	username := flags.String("username", "", "username of the user")
+	role := flags.String("role", "", "one of admin, dentist, secretary, website")
+	firstname := flags.String("firstname", "", "first name of the user")
+	lastname := flags.String("lastname", "", "last name of the user")
+	passwordStdin := flags.Bool("password-stdin", false, "read the new password from stdin")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	admin, err := newAdmin(configFile)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		if *username == "" {
+			return errors.New("-username is required")
+		}
+		if err := checkRole(*role); err != nil {
+			return err
+		}
+
+		exists, _, err := admin.RbacUsecase.UsernameExists(ctx, *username)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("user %q already exists", *username)
+		}
+
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+
+		guid, err := admin.RbacUsecase.CreateUser(ctx, &entity.Users{
+			Role:      *role,
+			Firstname: *firstname,
+			Lastname:  *lastname,
+			Username:  *username,
+			Password:  password,
+		})
+		if err != nil {
+			return err
+		}
+
+		// dentists publish as authors, same as users created by api
+		if *role == entity.RoleDentist {
+			if _, err := admin.BlogsUsecase.CreateAuthors(ctx, &entity.Authors{
+				GUID: guid,
+				Name: *username,
+			}); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(guid)
+		return nil
+	case "reset-password":
+		user, err := findUser(ctx, admin, *username)
+		if err != nil {
+			return err
+		}
+
+		if *passwordStdin {
+			password, err := readPassword()
+			if err != nil {
+				return err
+			}
+
+			return admin.RbacUsecase.SetPassword(ctx, user.GUID, password)
+		}
+
+		password, expiresAt, err := admin.RbacUsecase.ResetPassword(ctx, user.GUID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("one-time password: %s\nexpires at: %s\n", password, expiresAt.Format(time.RFC3339))
+		return nil
+	case "set-role":
+		if err := checkRole(*role); err != nil {
+			return err
+		}
+
+		user, err := findUser(ctx, admin, *username)
+		if err != nil {
+			return err
+		}
+
+		// empty password keeps the current one, changing role revokes tokens of the user
+		user.Role = *role
+		user.Password = ""
+
+		return admin.RbacUsecase.UpdateUser(ctx, user)
+	}
+
+	return nil
+}
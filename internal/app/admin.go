@@ -0,0 +1,81 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/memory"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	"github.com/AsaHero/abclinic/internal/usecase"
+	"go.uber.org/zap"
+)
+
+// Admin wires usecases for command line maintenance against the configured database without serving http.
+// Token denylist of running servers is in memory, so revoked access tokens stay valid there until they expire.
+type Admin struct {
+	Logger              *zap.Logger
+	Config              *config.Config
+	DB                  *postgres.PostgresDB
+	DentistsUsecase     usecase.Denstists
+	PriceListUsecase    usecase.PriceList
+	InfoUsecase         usecase.InfoUsecase
+	BlogsUsecase        usecase.Blogs
+	RbacUsecase         usecase.Rbac
+	RefreshTokenUsecase usecase.RefreshToken
+	denylistStore       *denylist.MemoryStore
+}
+
+func NewAdmin(cfg *config.Config) (*Admin, error) {
+	logger, err := logger.New(cfg.LogLevel, cfg.Environment, cfg.APP+".log")
+	if err != nil {
+		return nil, fmt.Errorf("error on logger init: %w", err)
+	}
+	zap.ReplaceGlobals(logger)
+
+	db, err := postgres.NewPostgresDB(*cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	admin := newAdmin(cfg, postgresRepositories(db))
+	admin.Logger = logger
+	admin.DB = db
+
+	return admin, nil
+}
+
+// NewMemoryAdmin wires usecases with repositories of store, it arranges data of an application of NewMemoryApp
+func NewMemoryAdmin(cfg *config.Config, store *memory.Store) *Admin {
+	admin := newAdmin(cfg, memoryRepositories(store))
+	admin.Logger = zap.L()
+
+	return admin
+}
+
+func newAdmin(cfg *config.Config, repos repositories) *Admin {
+	contextTimeout := cfg.Context.Timeout
+
+	denylistStore := denylist.NewMemoryStore(time.Minute)
+
+	return &Admin{
+		Config:              cfg,
+		DentistsUsecase:     usecase.NewDentistsUsecase(contextTimeout, repos.dentists),
+		PriceListUsecase:    usecase.NewPriceListUsecase(contextTimeout, repos.services, repos.serviceGroups, repos.servicePrices, repos.priceTiers, repos.exchangeRates, cfg.PriceList.Currency),
+		InfoUsecase:         usecase.NewinfoUsecase(contextTimeout, repos.articles, repos.chapters),
+		BlogsUsecase:        usecase.NewBlogsUsecase(contextTimeout, repos.publications, repos.categories, repos.authors),
+		RbacUsecase:         usecase.NewRbacUsecase(contextTimeout, repos.users, repos.refreshTokens, denylist.New(denylistStore), passwordPolicy(cfg), cfg.Token.AccessTTL, cfg.Password.ResetTTL),
+		RefreshTokenUsecase: usecase.NewRefreshTokenService(contextTimeout, repos.refreshTokens),
+		denylistStore:       denylistStore,
+	}
+}
+
+func (a *Admin) Close() {
+	a.denylistStore.Close()
+	if a.DB != nil {
+		a.DB.Close()
+	}
+	_ = a.Logger.Sync()
+}
@@ -2,92 +2,377 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/AsaHero/abclinic/api"
-	"github.com/AsaHero/abclinic/internal/infrastructure/repository/postgresql"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/memory"
+	"github.com/AsaHero/abclinic/internal/pkg/cache"
 	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/health"
+	"github.com/AsaHero/abclinic/internal/pkg/lifecycle"
 	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/loginguard"
+	"github.com/AsaHero/abclinic/internal/pkg/metrics"
+	"github.com/AsaHero/abclinic/internal/pkg/migrate"
+	"github.com/AsaHero/abclinic/internal/pkg/oidc"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+	"github.com/AsaHero/abclinic/internal/pkg/token"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"github.com/AsaHero/abclinic/internal/pkg/validation"
 	"github.com/AsaHero/abclinic/internal/usecase"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
 )
 
+const (
+	authModelFile = "./auth_model.conf"
+	policyFile    = "./policy.csv"
+)
+
 type App struct {
-	Logger   *zap.Logger
-	Config   *config.Config
-	DB       *postgres.PostgresDB
-	server   *http.Server
-	Enforcer *casbin.Enforcer
+	Logger    *zap.Logger
+	Config    *config.Config
+	DB        *postgres.PostgresDB
+	memory    *memory.Store
+	server    *http.Server
+	addr      string
+	metrics   *http.Server
+	Enforcer  *casbin.Enforcer
+	Tracer    *tracing.Provider
+	health    *health.Checker
+	lifecycle *lifecycle.Lifecycle
+	serverErr chan error
 }
 
-func NewApp(cfg *config.Config) *App {
+// NewApp only prepares the application, connections are opened by Run
+func NewApp(cfg *config.Config) (*App, error) {
+	return newApp(cfg, nil)
+}
+
+// NewMemoryApp prepares the application with repositories of store instead of Postgres, it's used by tests
+// running without a database
+func NewMemoryApp(cfg *config.Config, store *memory.Store) (*App, error) {
+	return newApp(cfg, store)
+}
 
-	enforcer, err := casbin.NewEnforcer("./auth_model.conf", "./policy.csv")
+func newApp(cfg *config.Config, store *memory.Store) (*App, error) {
+	enforcer, err := casbin.NewEnforcer(authModelFile, policyFile)
 	if err != nil {
-		log.Fatalf("error on casbin init: %v", err)
+		return nil, fmt.Errorf("error on casbin init: %w", err)
 	}
 
 	// logger init
 	logger, err := logger.New(cfg.LogLevel, cfg.Environment, cfg.APP+".log")
 	if err != nil {
-		log.Fatalf("error on logger init: %v", err)
+		return nil, fmt.Errorf("error on logger init: %w", err)
 	}
+	// code without a request-scoped logger falls back to the global one
+	zap.ReplaceGlobals(logger)
 
-	// db init
-	db, err := postgres.NewPostgresDB(*cfg)
-	if err != nil {
-		log.Fatalf("error on db init: %v", err)
+	a := &App{
+		Logger:    logger,
+		Config:    cfg,
+		Enforcer:  enforcer,
+		memory:    store,
+		lifecycle: lifecycle.New(logger),
+		serverErr: make(chan error, 1),
+	}
+
+	// hooks stop in reverse order: logger is synced last, db is closed after everything using it
+	a.lifecycle.Append(lifecycle.Hook{
+		Name: "logger",
+		OnStop: func(ctx context.Context) error {
+			// syncing stdout fails on some platforms, it's not worth reporting
+			_ = logger.Sync()
+			return nil
+		},
+	})
+	a.lifecycle.Append(lifecycle.Hook{
+		Name:    "tracing",
+		OnStart: a.startTracing,
+		OnStop: func(ctx context.Context) error {
+			return a.Tracer.Shutdown(ctx)
+		},
+	})
+	if store == nil {
+		a.lifecycle.Append(lifecycle.Hook{
+			Name: "postgres",
+			OnStart: func(ctx context.Context) error {
+				db, err := postgres.NewPostgresDB(*cfg)
+				if err != nil {
+					return err
+				}
+				a.DB = db
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				a.DB.Close()
+				return nil
+			},
+		})
 	}
+	if store == nil && cfg.DB.AutoMigrate {
+		a.lifecycle.Append(lifecycle.Hook{
+			Name:    "migrations",
+			OnStart: a.migrate,
+		})
+	}
+	a.lifecycle.Append(lifecycle.Hook{
+		Name:    "application",
+		OnStart: a.build,
+	})
+
+	return a, nil
+}
+
+// Run starts the application and blocks until ctx is done or the server fails, then stops it within shutdown timeout
+func (a *App) Run(ctx context.Context) error {
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		a.Logger.Info("shutting down")
+	case err = <-a.serverErr:
+		a.Logger.Error("server stopped unexpectedly", zap.Error(err))
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), a.Config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if stopErr := a.Stop(stopCtx); stopErr != nil {
+		if err != nil {
+			return fmt.Errorf("%w; shutdown: %v", err, stopErr)
+		}
+		return fmt.Errorf("shutdown: %w", stopErr)
+	}
+
+	return err
+}
+
+// Start starts every component in order, it's used instead of Run when the caller controls shutdown, like tests do
+func (a *App) Start(ctx context.Context) error {
+	return a.lifecycle.Start(ctx)
+}
+
+// Stop stops started components in reverse order
+func (a *App) Stop(ctx context.Context) error {
+	return a.lifecycle.Stop(ctx)
+}
 
-	return &App{
-		Logger:   logger,
-		Config:   cfg,
-		DB:       db,
-		Enforcer: enforcer,
+// Addr is the address the server listens on once started, port 0 of config is resolved
+func (a *App) Addr() string {
+	return a.addr
+}
+
+// startTracing falls back to no-op for otlp so local runs don't need a collector
+func (a *App) startTracing(ctx context.Context) error {
+	tracer, err := tracing.New(ctx, tracing.Options{
+		Exporter:    a.Config.Tracing.Exporter,
+		Endpoint:    a.Config.Tracing.OTLPEndpoint,
+		Insecure:    a.Config.Tracing.OTLPInsecure,
+		SampleRatio: a.Config.Tracing.SampleRatio,
+		ServiceName: a.Config.APP,
+		Environment: a.Config.Environment,
+	})
+	if err != nil {
+		if a.Config.Tracing.Exporter != tracing.ExporterOTLP {
+			return err
+		}
+		a.Logger.Warn("tracing disabled", zap.Error(err))
+		tracer, _ = tracing.New(ctx, tracing.Options{Exporter: tracing.ExporterNone})
 	}
+
+	a.Tracer = tracer
+	return nil
 }
 
-func (a *App) Run() error {
-	contextTimeout, err := time.ParseDuration(a.Config.Context.Timeout)
+// migrate applies pending migrations, instances started together wait for each other on the migration lock
+func (a *App) migrate(ctx context.Context) error {
+	migrator, err := migrate.New(a.Config, a.Logger)
 	if err != nil {
-		return fmt.Errorf("error while parsing context timeout: %v", err)
+		return err
 	}
+	defer migrator.Close()
+
+	return migrator.Up()
+}
+
+// build wires repositories, usecases and the router, and appends hooks of background workers and the server
+func (a *App) build(ctx context.Context) error {
+	contextTimeout := a.Config.Context.Timeout
 
 	// repo init
-	dentistsRepo := postgresql.NewDentistsRepo(a.DB)
-	serviceRepo := postgresql.NewServicesRepo(a.DB)
-	serviceGroupdRepo := postgresql.NewServiceGroupsRepo(a.DB)
-	artcileRepo := postgresql.NewArticlesRepo(a.DB)
-	chapterRepo := postgresql.NewChaptersRepo(a.DB)
-	authorsRepo := postgresql.NewAuthorsRepo(a.DB)
-	categoriesRepo := postgresql.NewCategoriesRepo(a.DB)
-	publicationsRepo := postgresql.NewPublicationsRepo(a.DB)
-	userRepo := postgresql.NewUsersRepo(a.DB)
-	refreshTokenRepo := postgresql.NewRefreshTokenRepo(a.DB)
+	var repos repositories
+	if a.memory != nil {
+		repos = memoryRepositories(a.memory)
+	} else {
+		repos = postgresRepositories(a.DB)
+	}
+
+	// token denylist init
+	denylistStore := denylist.NewMemoryStore(time.Minute)
+	a.closeOnStop("denylist", denylistStore.Close)
+	tokenDenylist := denylist.New(denylistStore)
+
+	// login guard init
+	loginGuardStore := loginguard.NewMemoryStore(time.Minute)
+	a.closeOnStop("login guard", loginGuardStore.Close)
+	loginGuard := loginguard.New(loginGuardStore, loginguard.Options{
+		MaxAttempts:     a.Config.Login.MaxAttempts,
+		IPMaxAttempts:   a.Config.Login.IPMaxAttempts,
+		BaseDelay:       a.Config.Login.BaseDelay,
+		MaxDelay:        a.Config.Login.MaxDelay,
+		LockoutDuration: a.Config.Login.LockoutDuration,
+		Window:          a.Config.Login.Window,
+	})
+
+	// token keys init
+	tokenKeys, err := token.NewKeySet(token.KeySetOptions{
+		Algorithm:    a.Config.Token.Algorithm,
+		Secret:       a.Config.Token.Secret,
+		Dir:          a.Config.Token.KeysDir,
+		SigningKeyID: a.Config.Token.SigningKeyID,
+		Rotation:     a.Config.Token.KeyRotation,
+		Retention:    a.Config.Token.RefreshTTL,
+		Issuer:       a.Config.Token.Issuer,
+		Audience:     a.Config.Token.Audience,
+		Leeway:       a.Config.Token.ClockSkew,
+		Logger:       a.Logger,
+	})
+	if err != nil {
+		return fmt.Errorf("error while token keys init: %w", err)
+	}
+	a.closeOnStop("token keys", tokenKeys.Close)
+
+	// sso init
+	var oidcProvider *oidc.Provider
+	if a.Config.OIDC.IssuerURL != "" {
+		oidcProvider = oidc.NewProvider(oidc.Options{
+			IssuerURL:    a.Config.OIDC.IssuerURL,
+			ClientID:     a.Config.OIDC.ClientID,
+			ClientSecret: a.Config.OIDC.ClientSecret,
+			RedirectURL:  a.Config.OIDC.RedirectURL,
+			Scopes:       a.Config.OIDC.Scopes,
+			Leeway:       a.Config.Token.ClockSkew,
+		})
+	}
+
+	ssoRoleMapping, err := parseSSORoleMapping(a.Config.OIDC.RoleMapping)
+	if err != nil {
+		return fmt.Errorf("error while parsing sso role mapping: %w", err)
+	}
+
+	// rate limiter init
+	var rateLimiter *ratelimit.Limiter
+	if a.Config.RateLimit.Enabled {
+		rateLimitRules, err := ratelimit.ParseRules(a.Config.RateLimit.Rules)
+		if err != nil {
+			return fmt.Errorf("error while parsing rate limit rules: %w", err)
+		}
+		rateLimitStore := ratelimit.NewMemoryStore(time.Minute)
+		a.closeOnStop("rate limit", rateLimitStore.Close)
+		rateLimiter = ratelimit.New(rateLimitStore, rateLimitRules)
+	}
+
+	// failed api key lookups are limited with rate limiting disabled too, they guess keys and load the database
+	apiKeyFailuresLimit, err := ratelimit.ParseLimit(a.Config.RateLimit.APIKeyFailures)
+	if err != nil {
+		return fmt.Errorf("error while parsing api key failures limit: %w", err)
+	}
+	apiKeyFailuresStore := ratelimit.NewMemoryStore(time.Minute)
+	a.closeOnStop("api key failures", apiKeyFailuresStore.Close)
+	apiKeyFailures := ratelimit.NewFailureLimiter(apiKeyFailuresStore, "api key failures", apiKeyFailuresLimit)
+
+	// metrics init
+	var appMetrics *metrics.Metrics
+	if a.Config.Metrics.Enabled {
+		appMetrics = metrics.New()
+		if a.DB != nil {
+			appMetrics.RegisterPool(a.DB.Pool)
+		}
+	}
 
 	// usecase init
-	dentistsUsecase := usecase.NewDentistsUsecase(contextTimeout, dentistsRepo)
-	priceListUsecase := usecase.NewPriceListUsecase(contextTimeout, serviceRepo, serviceGroupdRepo)
-	infoUsecase := usecase.NewinfoUsecase(contextTimeout, artcileRepo, chapterRepo)
-	blogsUsecase := usecase.NewBlogsUsecase(contextTimeout, publicationsRepo, categoriesRepo, authorsRepo)
-	rbacUsecase := usecase.NewRbacUsecase(contextTimeout, userRepo)
-	refreshTokenUsecase := usecase.NewRefreshTokenService(contextTimeout, refreshTokenRepo)
+	dentistsUsecase := usecase.NewDentistsUsecase(contextTimeout, repos.dentists)
+	priceListUsecase := usecase.NewPriceListUsecase(contextTimeout, repos.services, repos.serviceGroups, repos.servicePrices, repos.priceTiers, repos.exchangeRates, a.Config.PriceList.Currency)
+	infoUsecase := usecase.NewinfoUsecase(contextTimeout, repos.articles, repos.chapters)
+	blogsUsecase := usecase.NewBlogsUsecase(contextTimeout, repos.publications, repos.categories, repos.authors)
+	rbacUsecase := usecase.NewRbacUsecase(contextTimeout, repos.users, repos.refreshTokens, tokenDenylist, passwordPolicy(a.Config), a.Config.Token.AccessTTL, a.Config.Password.ResetTTL)
+	refreshTokenUsecase := usecase.NewRefreshTokenService(contextTimeout, repos.refreshTokens)
+	apiKeysUsecase := usecase.NewAPIKeysUsecase(contextTimeout, repos.apiKeys)
+	mfaUsecase := usecase.NewMFAUsecase(contextTimeout, repos.users, repos.roleSettings, a.Config.MFA.Issuer)
+	ssoSessions := oidc.NewMemoryStore(time.Minute)
+	a.closeOnStop("sso sessions", ssoSessions.Close)
+	ssoUsecase := usecase.NewSSOUsecase(contextTimeout, repos.users, repos.refreshTokens, tokenDenylist, oidcProvider, ssoSessions, usecase.SSOOptions{
+		GroupsClaim: a.Config.OIDC.GroupsClaim,
+		RoleMapping: ssoRoleMapping,
+		StateTTL:    a.Config.OIDC.StateTTL,
+	}, a.Config.Token.AccessTTL)
+
+	// content cache init, reads of public content are served from it until a change through these usecases
+	if a.Config.Cache.Enabled {
+		contentCache := cache.New(a.Config.Cache.TTL, time.Minute)
+		a.closeOnStop("cache", contentCache.Close)
+		dentistsUsecase = usecase.NewCachedDentists(dentistsUsecase, contentCache)
+		priceListUsecase = usecase.NewCachedPriceList(priceListUsecase, contentCache)
+		infoUsecase = usecase.NewCachedInfo(infoUsecase, contentCache)
+		blogsUsecase = usecase.NewCachedBlogs(blogsUsecase, contentCache)
+	}
+
+	// health checks init
+	a.health = health.NewChecker(a.Config.Health.CheckTimeout)
+	if a.DB != nil {
+		a.health.Register("postgres", health.Postgres(a.DB))
+	}
+	a.health.Register("casbin", health.Casbin(a.Enforcer))
+	// object storage isn't configured without a bucket, there is nothing to check
+	if a.Config.CDN.BucketName != "" {
+		cdnSession, err := session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials(a.Config.CDN.AwsAccessKeyID, a.Config.CDN.AwsSecretAccessKey, ""),
+			Endpoint:    aws.String(a.Config.CDN.AwsEndpoint),
+			Region:      aws.String("us-east-1"),
+		})
+		if err != nil {
+			return fmt.Errorf("error while creating object storage session: %w", err)
+		}
+		a.health.Register("object_storage", health.ObjectStorage(s3.New(cdnSession), a.Config.CDN.BucketName))
+	}
 
 	routerArgs := api.RouteArguments{
 		Config:              a.Config,
 		Logger:              a.Logger,
 		Enforcer:            a.Enforcer,
+		Denylist:            tokenDenylist,
+		TokenKeys:           tokenKeys,
+		LoginGuard:          loginGuard,
+		Health:              a.health,
+		Metrics:             appMetrics,
+		RateLimiter:         rateLimiter,
+		APIKeyFailures:      apiKeyFailures,
 		DentistsUsecase:     dentistsUsecase,
 		PriceListUsecase:    priceListUsecase,
 		InfoUsecase:         infoUsecase,
 		BlogsUsecase:        blogsUsecase,
 		RbacUsecase:         rbacUsecase,
 		RefreshTokenUsecase: refreshTokenUsecase,
+		MFAUsecase:          mfaUsecase,
+		SSOUsecase:          ssoUsecase,
+		APIKeysUsecase:      apiKeysUsecase,
 	}
 
 	// router init
@@ -98,24 +383,127 @@ func (a *App) Run() error {
 	}
 
 	// server init
-	a.server, err = api.NewServer(a.Config, handlers)
+	a.server = api.NewServer(a.Config, handlers)
+
+	if appMetrics != nil {
+		a.metrics = api.NewMetricsServer(a.Config, appMetrics.Handler())
+
+		// stops after the http server so requests being drained are still counted
+		a.lifecycle.Append(lifecycle.Hook{
+			Name: "metrics server",
+			OnStart: func(ctx context.Context) error {
+				addr, err := a.serve(a.metrics)
+				if err != nil {
+					return err
+				}
+				a.Logger.Info("Metrics listen:", zap.String("address", addr))
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return a.metrics.Shutdown(ctx)
+			},
+		})
+	}
+
+	a.lifecycle.Append(lifecycle.Hook{
+		Name:    "http server",
+		OnStart: a.startServer,
+		OnStop: func(ctx context.Context) error {
+			// waits for in-flight requests until ctx deadline
+			return a.server.Shutdown(ctx)
+		},
+	})
+
+	// stops first: fail readiness and keep serving so load balancers stop routing before the server closes
+	a.lifecycle.Append(lifecycle.Hook{
+		Name: "readiness",
+		OnStop: func(ctx context.Context) error {
+			a.health.Shutdown()
+
+			select {
+			case <-time.After(a.Config.Health.DrainDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	return nil
+}
+
+// startServer serves the router, Addr reports the bound address
+func (a *App) startServer(ctx context.Context) error {
+	addr, err := a.serve(a.server)
+	if err != nil {
+		return err
+	}
+
+	a.addr = addr
+	a.Logger.Info("Listen:", zap.String("address", a.addr))
+
+	return nil
+}
+
+// serve binds the address synchronously so start fails on a busy port, then serves in background.
+// It returns the bound address, port 0 is resolved
+func (a *App) serve(server *http.Server) (string, error) {
+	listener, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		return fmt.Errorf("error while server init: %v", err)
+		return "", err
 	}
 
-	return a.server.ListenAndServe()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.serverErr <- err
+		}
+	}()
+
+	return listener.Addr().String(), nil
+}
+
+// closeOnStop stops a background worker along with the application
+func (a *App) closeOnStop(name string, close func()) {
+	a.lifecycle.Append(lifecycle.Hook{
+		Name: name,
+		OnStop: func(ctx context.Context) error {
+			close()
+			return nil
+		},
+	})
+}
+
+func passwordPolicy(cfg *config.Config) validation.PasswordPolicy {
+	return validation.PasswordPolicy{
+		MinLength:      cfg.Password.MinLength,
+		RequireUpper:   cfg.Password.RequireUpper,
+		RequireLower:   cfg.Password.RequireLower,
+		RequireDigit:   cfg.Password.RequireDigit,
+		RequireSpecial: cfg.Password.RequireSpecial,
+		Denylist:       cfg.Password.Denylist,
+	}
 }
 
-func (a *App) Stop() {
+// parseSSORoleMapping parses group=role pairs, only staff roles can be granted by identity provider
+func parseSSORoleMapping(pairs []string) ([]usecase.SSORoleMapping, error) {
+	var mapping []usecase.SSORoleMapping
+	for _, pair := range pairs {
+		group, role, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a group=role pair", pair)
+		}
 
-	// close db pool
-	a.DB.Close()
+		switch role {
+		case entity.RoleAdmin, entity.RoleDentist, entity.RoleSecretary:
+		default:
+			return nil, fmt.Errorf("%q grants unknown role %q", pair, role)
+		}
 
-	// shutdown server http
-	if err := a.server.Shutdown(context.Background()); err != nil {
-		a.Logger.Error("shutdown server http", zap.Error(err))
+		mapping = append(mapping, usecase.SSORoleMapping{
+			Group: strings.TrimSpace(group),
+			Role:  role,
+		})
 	}
 
-	// logger sync
-	a.Logger.Sync()
+	return mapping, nil
 }
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AsaHero/abclinic/api"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/casbin/casbin/v2"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// SyncPolicy checks the policy file, the only source of policies, against routes of the http server.
+// It returns policies granting a path and method no route serves, with prune they are also removed from the file.
+func SyncPolicy(cfg *config.Config, logger *zap.Logger, prune bool) (stale [][]string, err error) {
+	enforcer, err := casbin.NewEnforcer(authModelFile, policyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error on casbin init: %w", err)
+	}
+
+	router, ok := api.NewRouter(api.RouteArguments{
+		Config:   cfg,
+		Logger:   logger,
+		Enforcer: enforcer,
+	}).(chi.Routes)
+	if !ok {
+		return nil, fmt.Errorf("error on walking routes: router is not a chi router")
+	}
+
+	routes := make(map[string]bool)
+	err = chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[policyRoute(route, method)] = true
+		routes[policyRoute(route, "*")] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on walking routes: %w", err)
+	}
+
+	for _, v := range enforcer.GetPolicy() {
+		if len(v) < 3 || !routes[policyRoute(v[1], v[2])] {
+			stale = append(stale, v)
+		}
+	}
+
+	if !prune || len(stale) == 0 {
+		return stale, nil
+	}
+
+	if _, err := enforcer.RemovePolicies(stale); err != nil {
+		return nil, fmt.Errorf("error on casbin remove policies: %w", err)
+	}
+
+	if err := enforcer.SavePolicy(); err != nil {
+		return nil, fmt.Errorf("error on casbin save policy: %w", err)
+	}
+
+	return stale, nil
+}
+
+// policyRoute is the key of a route, paths of mounted routers end with a slash the request path doesn't have
+func policyRoute(path, method string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return method + " " + path
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/memory"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/postgresql"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+)
+
+// repositories are storages of usecases, Postgres ones serve production and memory ones run tests without a database
+type repositories struct {
+	dentists      repository.Denstists
+	services      repository.Services
+	serviceGroups repository.ServiceGroups
+	servicePrices repository.ServicePrices
+	priceTiers    repository.PriceTiers
+	exchangeRates repository.ExchangeRates
+	articles      repository.Articles
+	chapters      repository.Chapters
+	authors       repository.Authors
+	categories    repository.Categories
+	publications  repository.Publications
+	users         repository.Users
+	refreshTokens repository.RefreshTokenRepo
+	roleSettings  repository.RoleSettings
+	apiKeys       repository.APIKeys
+}
+
+func postgresRepositories(db *postgres.PostgresDB) repositories {
+	return repositories{
+		dentists:      postgresql.NewDentistsRepo(db),
+		services:      postgresql.NewServicesRepo(db),
+		serviceGroups: postgresql.NewServiceGroupsRepo(db),
+		servicePrices: postgresql.NewServicePricesRepo(db),
+		priceTiers:    postgresql.NewPriceTiersRepo(db),
+		exchangeRates: postgresql.NewExchangeRatesRepo(db),
+		articles:      postgresql.NewArticlesRepo(db),
+		chapters:      postgresql.NewChaptersRepo(db),
+		authors:       postgresql.NewAuthorsRepo(db),
+		categories:    postgresql.NewCategoriesRepo(db),
+		publications:  postgresql.NewPublicationsRepo(db),
+		users:         postgresql.NewUsersRepo(db),
+		refreshTokens: postgresql.NewRefreshTokenRepo(db),
+		roleSettings:  postgresql.NewRoleSettingsRepo(db),
+		apiKeys:       postgresql.NewAPIKeysRepo(db),
+	}
+}
+
+func memoryRepositories(store *memory.Store) repositories {
+	return repositories{
+		dentists:      memory.NewDentistsRepo(store),
+		services:      memory.NewServicesRepo(store),
+		serviceGroups: memory.NewServiceGroupsRepo(store),
+		servicePrices: memory.NewServicePricesRepo(store),
+		priceTiers:    memory.NewPriceTiersRepo(store),
+		exchangeRates: memory.NewExchangeRatesRepo(store),
+		articles:      memory.NewArticlesRepo(store),
+		chapters:      memory.NewChaptersRepo(store),
+		authors:       memory.NewAuthorsRepo(store),
+		categories:    memory.NewCategoriesRepo(store),
+		publications:  memory.NewPublicationsRepo(store),
+		users:         memory.NewUsersRepo(store),
+		refreshTokens: memory.NewRefreshTokenRepo(store),
+		roleSettings:  memory.NewRoleSettingsRepo(store),
+		apiKeys:       memory.NewAPIKeysRepo(store),
+	}
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+type APIKeys struct {
+	GUID string
+	Name string
+	// Prefix is the public part of the key used for lookup, only hash of the whole key is stored
+	Prefix     string
+	KeyHash    string
+	Role       string
+	Scopes     []string
+	CreatedBy  string
+	LastUsedAt *time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (k APIKeys) HasScope(scope string) bool {
+	for _, v := range k.Scopes {
+		if v == scope {
+			return true
+		}
+	}
+
+	return false
+}
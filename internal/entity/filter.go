@@ -0,0 +1,190 @@
+package entity
+
+import "time"
+
+// Filters select rows matching every set field, zero fields and empty lists match any row.
+// Lists match any of their values, Search matches a substring case-insensitively.
+
+// TimeRange matches times from From inclusive to To exclusive, zero bound leaves the range open
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.From.IsZero() && t.Before(r.From) {
+		return false
+	}
+	if !r.To.IsZero() && !t.Before(r.To) {
+		return false
+	}
+	return true
+}
+
+type APIKeysFilter struct {
+	GUIDs  []string
+	Prefix string
+	Roles  []string
+	// Revoked selects revoked keys when true and active ones when false
+	Revoked   *bool
+	CreatedAt TimeRange
+}
+
+func (f APIKeysFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && f.Prefix == "" && len(f.Roles) == 0 && f.Revoked == nil && f.CreatedAt.IsZero()
+}
+
+type ArticlesFilter struct {
+	GUIDs      []string
+	ChapterIDs []string
+	Side       string
+	CreatedAt  TimeRange
+}
+
+func (f ArticlesFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.ChapterIDs) == 0 && f.Side == "" && f.CreatedAt.IsZero()
+}
+
+type AuthorsFilter struct {
+	GUIDs []string
+	// Search is matched against name
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f AuthorsFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && f.Search == "" && f.CreatedAt.IsZero()
+}
+
+type CategoriesFilter struct {
+	GUIDs []string
+	// Search is matched against title
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f CategoriesFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && f.Search == "" && f.CreatedAt.IsZero()
+}
+
+type ChaptersFilter struct {
+	GUIDs []string
+	// Search is matched against title
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f ChaptersFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && f.Search == "" && f.CreatedAt.IsZero()
+}
+
+type DentistsFilter struct {
+	IDs       []int64
+	CloneName string
+	Side      string
+	// Search is matched against name
+	Search string
+}
+
+func (f DentistsFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.CloneName == "" && f.Side == "" && f.Search == ""
+}
+
+type PublicationsFilter struct {
+	GUIDs       []string
+	CategoryIDs []string
+	AuthorIDs   []string
+	Type        string
+	// Search is matched against title
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f PublicationsFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.CategoryIDs) == 0 && len(f.AuthorIDs) == 0 && f.Type == "" &&
+		f.Search == "" && f.CreatedAt.IsZero()
+}
+
+type ServiceGroupsFilter struct {
+	GUIDs []string
+	// Search is matched against name
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f ServiceGroupsFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && f.Search == "" && f.CreatedAt.IsZero()
+}
+
+type ServicesFilter struct {
+	GUIDs    []string
+	GroupIDs []string
+	// Search is matched against name
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f ServicesFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.GroupIDs) == 0 && f.Search == "" && f.CreatedAt.IsZero()
+}
+
+// ServicesListOptions change how listed services are priced, they don't select services
+type ServicesListOptions struct {
+	// At picks prices in effect at the time, zero is now. Services without a price then are not listed.
+	At time.Time
+	// Currency converts prices with exchange rates, empty leaves them in their own currencies
+	Currency string
+}
+
+type ServicePricesFilter struct {
+	GUIDs      []string
+	ServiceIDs []string
+	// Tiers selects prices having an amount in one of the tiers
+	Tiers []string
+	// At selects prices in effect at the time
+	At time.Time
+}
+
+func (f ServicePricesFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.ServiceIDs) == 0 && len(f.Tiers) == 0 && f.At.IsZero()
+}
+
+type PriceTiersFilter struct {
+	GUIDs []string
+	Codes []string
+}
+
+func (f PriceTiersFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.Codes) == 0
+}
+
+type ExchangeRatesFilter struct {
+	GUIDs []string
+	// Currencies selects rates having one of the currencies either as base or quote
+	Currencies []string
+}
+
+func (f ExchangeRatesFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.Currencies) == 0
+}
+
+type UsersFilter struct {
+	GUIDs      []string
+	Roles      []string
+	Firstname  string
+	Lastname   string
+	Username   string
+	SSOSubject string
+	// Search is matched against firstname, lastname and username
+	Search    string
+	CreatedAt TimeRange
+}
+
+func (f UsersFilter) IsEmpty() bool {
+	return len(f.GUIDs) == 0 && len(f.Roles) == 0 && f.Firstname == "" && f.Lastname == "" && f.Username == "" &&
+		f.SSOSubject == "" && f.Search == "" && f.CreatedAt.IsZero()
+}
@@ -3,14 +3,61 @@ package entity
 import "time"
 
 type Services struct {
-	GUID      string
-	GroupID   string
-	Name      string
-	Price     []float64
+	GUID    string
+	GroupID string
+	Name    string
+	// Prices and Currency are the ones in effect at the time of the read, they're the first price of history on create
+	Prices    []TierPrice
+	Currency  string
 	CreatedAt time.Time
 	UpdateAt  time.Time
 }
 
+// ServicePrices is a price of a service in effect from ValidFrom inclusive to ValidTo exclusive,
+// nil ValidTo leaves it in effect until a later change
+type ServicePrices struct {
+	GUID      string
+	ServiceID string
+	// Prices are ordered by position of their tiers
+	Prices    []TierPrice
+	Currency  string
+	ValidFrom time.Time
+	ValidTo   *time.Time
+	CreatedAt time.Time
+}
+
+// TierPrice is the amount of a service in a price tier
+type TierPrice struct {
+	// Tier is code of the tier
+	Tier string
+	// Amount is in minor units of the currency of the price, e.g. tiyins of UZS
+	Amount int64
+	// From makes Amount the lowest price, the final one depends on the case
+	From bool
+}
+
+// PriceTiers are kinds of price the clinic quotes for its services, e.g. regular, urgent or child
+type PriceTiers struct {
+	GUID string
+	// Code keys prices of the tier, it's fixed once created
+	Code string
+	Name string
+	// Position orders tiers in the price list
+	Position  int
+	CreatedAt time.Time
+}
+
+// ExchangeRates are rates prices are converted with, one unit of Base is Rate units of Quote.
+// A pair converts both ways, so it's stored once for either direction.
+type ExchangeRates struct {
+	GUID      string
+	Base      string
+	Quote     string
+	Rate      float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type ServiceGroups struct {
 	GUID      string
 	Name      string
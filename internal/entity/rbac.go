@@ -11,12 +11,28 @@ const (
 
 type Users struct {
 	GUID      string
-	Role   string
+	Role      string
 	Firstname string
 	Lastname  string
 	Username  string
 	Password  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// MustChangePassword is set by admin reset, Password then holds the one-time token
+	MustChangePassword bool
+	PasswordExpiresAt  *time.Time
+	// TOTPSecret is pending until TOTPEnabled is set by a verified code
+	TOTPSecret  string
+	TOTPEnabled bool
+	// TOTPLastStep is the time step of the last accepted code, codes of it and earlier steps are refused
+	TOTPLastStep  int64
+	RecoveryCodes []string
+	// SSOSubject is issuer and subject of identity provider account, empty for local users
+	SSOSubject string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
+type RoleSettings struct {
+	Role        string
+	MFARequired bool
+	UpdatedAt   time.Time
+}
@@ -4,6 +4,7 @@ import "time"
 
 type RefreshToken struct {
 	GUID         string
+	UserID       string
 	RefreshToken string
 	ExpiryDate   time.Time
 	CreatedAt    time.Time
@@ -3,6 +3,8 @@ package errors
 var (
 	ErrorConflict = NewErrConflict("object")
 	ErrorNotFound = NewErrNotFound("object")
+	// ErrorEmptyFilter refuses get or delete without criteria, it would touch an arbitrary or every row
+	ErrorEmptyFilter = NewErrEmptyFilter("object")
 )
 
 // error not found
@@ -34,3 +36,18 @@ func NewErrConflict(text string) *ErrConflict {
 func (e *ErrConflict) Error() string {
 	return e.text + " already exist"
 }
+
+// error empty filter
+type ErrEmptyFilter struct {
+	text string
+}
+
+func NewErrEmptyFilter(text string) *ErrEmptyFilter {
+	return &ErrEmptyFilter{
+		text: text,
+	}
+}
+
+func (e *ErrEmptyFilter) Error() string {
+	return e.text + " filter has no criteria"
+}
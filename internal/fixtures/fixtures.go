@@ -0,0 +1,128 @@
+// Package fixtures loads declarative demo and test data through usecases
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures describe content by natural keys, nested items belong to their parent:
+// dentists by clone name and side, price tiers by code, exchange rates by base and quote, service groups, chapters, authors and categories by name or title,
+// services by name within group, articles by side within chapter, publications by title within category.
+type Fixtures struct {
+	Dentists      []Dentist      `yaml:"dentists" json:"dentists"`
+	PriceTiers    []PriceTier    `yaml:"price_tiers" json:"price_tiers"`
+	ExchangeRates []ExchangeRate `yaml:"exchange_rates" json:"exchange_rates"`
+	ServiceGroups []ServiceGroup `yaml:"service_groups" json:"service_groups"`
+	Chapters      []Chapter      `yaml:"chapters" json:"chapters"`
+	Authors       []Author       `yaml:"authors" json:"authors"`
+	Categories    []Category     `yaml:"categories" json:"categories"`
+}
+
+type Dentist struct {
+	CloneName string `yaml:"clone_name" json:"clone_name"`
+	Side      string `yaml:"side" json:"side"`
+	Name      string `yaml:"name" json:"name"`
+	Info      string `yaml:"info" json:"info"`
+	URL       string `yaml:"url" json:"url"`
+	Priority  int16  `yaml:"priority" json:"priority"`
+}
+
+type PriceTier struct {
+	Code     string `yaml:"code" json:"code"`
+	Name     string `yaml:"name" json:"name"`
+	Position int    `yaml:"position" json:"position"`
+}
+
+// ExchangeRate is one unit of Base in Quote
+type ExchangeRate struct {
+	Base  string  `yaml:"base" json:"base"`
+	Quote string  `yaml:"quote" json:"quote"`
+	Rate  float64 `yaml:"rate" json:"rate"`
+}
+
+type ServiceGroup struct {
+	Name     string    `yaml:"name" json:"name"`
+	Services []Service `yaml:"services" json:"services"`
+}
+
+type Service struct {
+	Name string `yaml:"name" json:"name"`
+	// Currency is the clinic's default when empty
+	Currency string  `yaml:"currency" json:"currency"`
+	Prices   []Price `yaml:"prices" json:"prices"`
+}
+
+// Price is the amount of a service in a tier of fixtures or database, in minor units of the currency
+type Price struct {
+	Tier   string `yaml:"tier" json:"tier"`
+	Amount int64  `yaml:"amount" json:"amount"`
+	From   bool   `yaml:"from" json:"from"`
+}
+
+type Chapter struct {
+	Title    string    `yaml:"title" json:"title"`
+	Articles []Article `yaml:"articles" json:"articles"`
+}
+
+type Article struct {
+	Side string `yaml:"side" json:"side"`
+	Info string `yaml:"info" json:"info"`
+	Img  string `yaml:"img" json:"img"`
+}
+
+type Author struct {
+	Name string `yaml:"name" json:"name"`
+}
+
+type Category struct {
+	Title        string        `yaml:"title" json:"title"`
+	Description  string        `yaml:"description" json:"description"`
+	URL          string        `yaml:"url" json:"url"`
+	Publications []Publication `yaml:"publications" json:"publications"`
+}
+
+type Publication struct {
+	Title       string `yaml:"title" json:"title"`
+	Description string `yaml:"description" json:"description"`
+	// Author is name of an author of fixtures or database
+	Author  string   `yaml:"author" json:"author"`
+	Type    string   `yaml:"type" json:"type"`
+	Content []string `yaml:"content" json:"content"`
+}
+
+// Read decodes .yaml, .yml or .json file, unknown fields are rejected
+func Read(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := &Fixtures{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(fixtures); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+		}
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(fixtures); err != nil {
+			return nil, fmt.Errorf("cannot decode %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixtures file %s, .yaml, .yml or .json is expected", path)
+	}
+
+	return fixtures, nil
+}
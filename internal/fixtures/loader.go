@@ -0,0 +1,560 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/usecase"
+)
+
+// Result counts fixtures by what loading did to them
+type Result struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Loader inserts fixtures missing by natural key and updates the ones that differ, so loading is idempotent
+type Loader struct {
+	dentists  usecase.Denstists
+	priceList usecase.PriceList
+	info      usecase.InfoUsecase
+	blogs     usecase.Blogs
+}
+
+func NewLoader(dentists usecase.Denstists, priceList usecase.PriceList, info usecase.InfoUsecase, blogs usecase.Blogs) *Loader {
+	return &Loader{
+		dentists:  dentists,
+		priceList: priceList,
+		info:      info,
+		blogs:     blogs,
+	}
+}
+
+func (l *Loader) Load(ctx context.Context, fixtures *Fixtures) (Result, error) {
+	var result Result
+
+	steps := []struct {
+		name string
+		load func(ctx context.Context, fixtures *Fixtures, result *Result) error
+	}{
+		{"dentists", l.loadDentists},
+		{"price tiers", l.loadPriceTiers},
+		{"exchange rates", l.loadExchangeRates},
+		{"service groups", l.loadServiceGroups},
+		{"chapters", l.loadChapters},
+		{"authors", l.loadAuthors},
+		{"categories", l.loadCategories},
+	}
+
+	for _, step := range steps {
+		if err := step.load(ctx, fixtures, &result); err != nil {
+			return result, fmt.Errorf("cannot load %s: %w", step.name, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (l *Loader) loadDentists(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	existing, err := l.dentists.List(ctx, entity.DentistsFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.Dentists {
+		if v.CloneName == "" || v.Side == "" {
+			return fmt.Errorf("dentist %q: clone_name and side are required", v.CloneName)
+		}
+
+		dentist := findDentist(existing, v.CloneName, v.Side)
+		if dentist == nil {
+			if _, err := l.dentists.Create(ctx, &entity.Dentists{
+				CloneName: v.CloneName,
+				Name:      v.Name,
+				Info:      v.Info,
+				URL:       v.URL,
+				Side:      v.Side,
+				Priority:  v.Priority,
+			}); err != nil {
+				return err
+			}
+			result.Created++
+			continue
+		}
+
+		// clone name, side and priority are fixed once created
+		if dentist.Name == v.Name && dentist.Info == v.Info && dentist.URL == v.URL {
+			result.Unchanged++
+			continue
+		}
+
+		dentist.Name = v.Name
+		dentist.Info = v.Info
+		dentist.URL = v.URL
+		if err := l.dentists.Update(ctx, dentist); err != nil {
+			return err
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadPriceTiers(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	tiers, err := l.priceList.ListPriceTiers(ctx, entity.PriceTiersFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.PriceTiers {
+		if v.Code == "" {
+			return fmt.Errorf("price tier code is required")
+		}
+
+		var tier *entity.PriceTiers
+		for _, t := range tiers {
+			if t.Code == v.Code {
+				tier = t
+				break
+			}
+		}
+
+		if tier == nil {
+			if _, err := l.priceList.CreatePriceTier(ctx, &entity.PriceTiers{
+				Code:     v.Code,
+				Name:     v.Name,
+				Position: v.Position,
+			}); err != nil {
+				return fmt.Errorf("price tier %q: %w", v.Code, err)
+			}
+			result.Created++
+			continue
+		}
+
+		if tier.Name == v.Name && tier.Position == v.Position {
+			result.Unchanged++
+			continue
+		}
+
+		tier.Name = v.Name
+		tier.Position = v.Position
+		if err := l.priceList.UpdatePriceTier(ctx, tier); err != nil {
+			return fmt.Errorf("price tier %q: %w", v.Code, err)
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadExchangeRates(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	rates, err := l.priceList.ListExchangeRates(ctx, entity.ExchangeRatesFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.ExchangeRates {
+		if v.Base == "" || v.Quote == "" {
+			return fmt.Errorf("exchange rate %s/%s: base and quote are required", v.Base, v.Quote)
+		}
+
+		var rate *entity.ExchangeRates
+		for _, r := range rates {
+			if r.Base == v.Base && r.Quote == v.Quote {
+				rate = r
+				break
+			}
+		}
+
+		if rate == nil {
+			if _, err := l.priceList.CreateExchangeRate(ctx, &entity.ExchangeRates{
+				Base:  v.Base,
+				Quote: v.Quote,
+				Rate:  v.Rate,
+			}); err != nil {
+				return fmt.Errorf("exchange rate %s/%s: %w", v.Base, v.Quote, err)
+			}
+			result.Created++
+			continue
+		}
+
+		if rate.Rate == v.Rate {
+			result.Unchanged++
+			continue
+		}
+
+		rate.Rate = v.Rate
+		if err := l.priceList.UpdateExchangeRate(ctx, rate); err != nil {
+			return fmt.Errorf("exchange rate %s/%s: %w", v.Base, v.Quote, err)
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadServiceGroups(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	groups, err := l.priceList.ListServiceGroups(ctx, entity.ServiceGroupsFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.ServiceGroups {
+		if v.Name == "" {
+			return fmt.Errorf("service group name is required")
+		}
+
+		var groupID string
+		for _, group := range groups {
+			if group.Name == v.Name {
+				groupID = group.GUID
+				break
+			}
+		}
+
+		if groupID == "" {
+			groupID, err = l.priceList.CreateServiceGroup(ctx, &entity.ServiceGroups{Name: v.Name})
+			if err != nil {
+				return err
+			}
+			result.Created++
+		} else {
+			result.Unchanged++
+		}
+
+		if err := l.loadServices(ctx, groupID, v.Services, result); err != nil {
+			return fmt.Errorf("group %q: %w", v.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) loadServices(ctx context.Context, groupID string, fixtures []Service, result *Result) error {
+	services, err := l.priceList.ListServices(ctx, entity.ServicesFilter{GroupIDs: []string{groupID}}, entity.ServicesListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures {
+		if v.Name == "" {
+			return fmt.Errorf("service name is required")
+		}
+
+		var prices []entity.TierPrice
+		for _, p := range v.Prices {
+			prices = append(prices, entity.TierPrice{Tier: p.Tier, Amount: p.Amount, From: p.From})
+		}
+
+		var service *entity.Services
+		for _, s := range services {
+			if s.Name == v.Name {
+				service = s
+				break
+			}
+		}
+
+		if service == nil {
+			if _, err := l.priceList.CreateService(ctx, &entity.Services{
+				GroupID:  groupID,
+				Name:     v.Name,
+				Currency: v.Currency,
+				Prices:   prices,
+			}); err != nil {
+				return fmt.Errorf("service %q: %w", v.Name, err)
+			}
+			result.Created++
+			continue
+		}
+
+		// empty currency leaves the one of database
+		if equalPrices(service.Prices, prices) && (v.Currency == "" || v.Currency == service.Currency) {
+			result.Unchanged++
+			continue
+		}
+
+		service.Prices = prices
+		if v.Currency != "" {
+			service.Currency = v.Currency
+		}
+		if err := l.priceList.UpdateService(ctx, service); err != nil {
+			return fmt.Errorf("service %q: %w", v.Name, err)
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadChapters(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	chapters, err := l.info.ListArticlesChapters(ctx, entity.ChaptersFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.Chapters {
+		if v.Title == "" {
+			return fmt.Errorf("chapter title is required")
+		}
+
+		var chapterID string
+		for _, chapter := range chapters {
+			if chapter.Title == v.Title {
+				chapterID = chapter.GUID
+				break
+			}
+		}
+
+		if chapterID == "" {
+			chapterID, err = l.info.CreateArticlesChapter(ctx, &entity.Chapters{Title: v.Title})
+			if err != nil {
+				return err
+			}
+			result.Created++
+		} else {
+			result.Unchanged++
+		}
+
+		if err := l.loadArticles(ctx, chapterID, v.Articles, result); err != nil {
+			return fmt.Errorf("chapter %q: %w", v.Title, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) loadArticles(ctx context.Context, chapterID string, fixtures []Article, result *Result) error {
+	articles, err := l.info.ListArticles(ctx, entity.ArticlesFilter{ChapterIDs: []string{chapterID}})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures {
+		if v.Side == "" {
+			return fmt.Errorf("article side is required")
+		}
+
+		var article *entity.Articles
+		for _, a := range articles {
+			if a.Side == v.Side {
+				article = a
+				break
+			}
+		}
+
+		if article == nil {
+			if _, err := l.info.CreateArticle(ctx, &entity.Articles{
+				ChapterID: chapterID,
+				Info:      v.Info,
+				Img:       v.Img,
+				Side:      v.Side,
+			}); err != nil {
+				return err
+			}
+			result.Created++
+			continue
+		}
+
+		if article.Info == v.Info && article.Img == v.Img {
+			result.Unchanged++
+			continue
+		}
+
+		article.Info = v.Info
+		article.Img = v.Img
+		if err := l.info.UpdateArticles(ctx, article); err != nil {
+			return err
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadAuthors(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	authors, err := l.blogs.ListAuthors(ctx, entity.AuthorsFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.Authors {
+		if v.Name == "" {
+			return fmt.Errorf("author name is required")
+		}
+
+		if findAuthor(authors, v.Name) != nil {
+			result.Unchanged++
+			continue
+		}
+
+		if _, err := l.blogs.CreateAuthors(ctx, &entity.Authors{Name: v.Name}); err != nil {
+			return err
+		}
+		result.Created++
+	}
+
+	return nil
+}
+
+func (l *Loader) loadCategories(ctx context.Context, fixtures *Fixtures, result *Result) error {
+	categories, err := l.blogs.ListPublicationsCategories(ctx, entity.CategoriesFilter{})
+	if err != nil {
+		return err
+	}
+
+	// authors are listed again, so publications see the ones created by fixtures
+	authors, err := l.blogs.ListAuthors(ctx, entity.AuthorsFilter{})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures.Categories {
+		if v.Title == "" {
+			return fmt.Errorf("category title is required")
+		}
+
+		var category *entity.Categories
+		for _, c := range categories {
+			if c.Title == v.Title {
+				category = c
+				break
+			}
+		}
+
+		switch {
+		case category == nil:
+			category = &entity.Categories{
+				Title:       v.Title,
+				Description: v.Description,
+				URL:         v.URL,
+			}
+			if _, err := l.blogs.CreatePublicationsCategories(ctx, category); err != nil {
+				return err
+			}
+			result.Created++
+		case category.Description == v.Description && category.URL == v.URL:
+			result.Unchanged++
+		default:
+			category.Description = v.Description
+			category.URL = v.URL
+			if err := l.blogs.UpdatePublicationsCategories(ctx, category); err != nil {
+				return err
+			}
+			result.Updated++
+		}
+
+		if err := l.loadPublications(ctx, category.GUID, authors, v.Publications, result); err != nil {
+			return fmt.Errorf("category %q: %w", v.Title, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Loader) loadPublications(ctx context.Context, categoryID string, authors []*entity.Authors, fixtures []Publication, result *Result) error {
+	publications, err := l.blogs.ListPublications(ctx, entity.PublicationsFilter{CategoryIDs: []string{categoryID}})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range fixtures {
+		if v.Title == "" {
+			return fmt.Errorf("publication title is required")
+		}
+		if v.Type != entity.PublicationTypeVideo && v.Type != entity.PublicationTypeSwiper {
+			return fmt.Errorf("publication %q: type must be %s or %s", v.Title, entity.PublicationTypeVideo, entity.PublicationTypeSwiper)
+		}
+
+		author := findAuthor(authors, v.Author)
+		if author == nil {
+			return fmt.Errorf("publication %q: author %q not found", v.Title, v.Author)
+		}
+
+		var publication *entity.Publications
+		for _, p := range publications {
+			if p.Title == v.Title {
+				publication = p
+				break
+			}
+		}
+
+		if publication == nil {
+			if _, err := l.blogs.CreatePublications(ctx, &entity.Publications{
+				CategoryID:  categoryID,
+				AuthorID:    author.GUID,
+				Title:       v.Title,
+				Description: v.Description,
+				Type:        v.Type,
+				Content:     v.Content,
+			}); err != nil {
+				return err
+			}
+			result.Created++
+			continue
+		}
+
+		// author and type are fixed once created
+		if publication.Description == v.Description && equalStrings(publication.Content, v.Content) {
+			result.Unchanged++
+			continue
+		}
+
+		publication.Description = v.Description
+		publication.Content = v.Content
+		if err := l.blogs.UpdatePublications(ctx, publication); err != nil {
+			return err
+		}
+		result.Updated++
+	}
+
+	return nil
+}
+
+func findDentist(dentists []*entity.Dentists, cloneName, side string) *entity.Dentists {
+	for _, v := range dentists {
+		if v.CloneName == cloneName && v.Side == side {
+			return v
+		}
+	}
+	return nil
+}
+
+func findAuthor(authors []*entity.Authors, name string) *entity.Authors {
+	for _, v := range authors {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// equalPrices compares amounts by tier, order of tiers doesn't matter
+func equalPrices(a, b []entity.TierPrice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	amounts := make(map[string]entity.TierPrice, len(a))
+	for _, v := range a {
+		amounts[v.Tier] = v
+	}
+	for _, v := range b {
+		if amount, ok := amounts[v.Tier]; !ok || amount != v {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
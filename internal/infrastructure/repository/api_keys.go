@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+type APIKeys interface {
+	// Get refuses an empty filter with errors.ErrorEmptyFilter
+	Get(ctx context.Context, filter entity.APIKeysFilter) (*entity.APIKeys, error)
+	Create(ctx context.Context, req *entity.APIKeys) error
+	List(ctx context.Context, filter entity.APIKeysFilter) ([]*entity.APIKeys, error)
+	Update(ctx context.Context, req *entity.APIKeys) error
+}
@@ -8,7 +8,8 @@ import (
 
 type Articles interface {
 	Create(ctx context.Context, req *entity.Articles) error
-	List(ctx context.Context, filter map[string]string) ([]*entity.Articles, error)
+	List(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error)
 	Update(ctx context.Context, req *entity.Articles) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.ArticlesFilter) error
 }
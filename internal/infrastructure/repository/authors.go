@@ -9,7 +9,8 @@ import (
 type Authors interface {
 	Create(ctx context.Context, req *entity.Authors) error
 	Get(ctx context.Context, guid string) (*entity.Authors, error)
-	List(ctx context.Context, filter map[string]string) ([]*entity.Authors, error)
+	List(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error)
 	Update(ctx context.Context, req *entity.Authors) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.AuthorsFilter) error
 }
@@ -7,8 +7,9 @@ import (
 )
 
 type Categories interface {
-	List(ctx context.Context, filter map[string]string) ([]*entity.Categories, error)
+	List(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error)
 	Create(ctx context.Context, req *entity.Categories) error
 	Update(ctx context.Context, req *entity.Categories) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.CategoriesFilter) error
 }
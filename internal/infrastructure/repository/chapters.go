@@ -7,7 +7,7 @@ import (
 )
 
 type Chapters interface {
-	List(ctx context.Context, filter map[string]string) ([]*entity.Chapters, error)
+	List(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error)
 	Create(ctx context.Context, req *entity.Chapters) error
 	Update(ctx context.Context, req *entity.Chapters) error
 	Delete(ctx context.Context, id string) error
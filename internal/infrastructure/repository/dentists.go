@@ -8,6 +8,7 @@ import (
 
 type Denstists interface {
 	Get(ctx context.Context, id int64) (*entity.Dentists, error)
-	List(ctx context.Context, filter map[string]string) ([]*entity.Dentists, error)
+	List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error)
+	Create(ctx context.Context, req *entity.Dentists) error
 	Update(ctx context.Context, req *entity.Dentists) error
 }
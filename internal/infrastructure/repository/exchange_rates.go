@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+type ExchangeRates interface {
+	// Create fails on a pair already stored in either direction
+	Create(ctx context.Context, req *entity.ExchangeRates) error
+	// List orders rates by base, then by quote
+	List(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error)
+	// Update changes rate and updated_at
+	Update(ctx context.Context, req *entity.ExchangeRates) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type apiKeysRepo struct {
+	store *Store
+}
+
+func NewAPIKeysRepo(store *Store) repository.APIKeys {
+	return &apiKeysRepo{
+		store: store,
+	}
+}
+
+func (r apiKeysRepo) Get(ctx context.Context, filter entity.APIKeysFilter) (*entity.APIKeys, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.IsEmpty() {
+		return nil, errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, v := range r.store.apiKeys {
+		if matchAPIKey(v, filter) {
+			return copyAPIKey(v), nil
+		}
+	}
+
+	return nil, errorspkg.ErrorNotFound
+}
+
+// Create doesn't store usage and revocation time, new keys have none
+func (r apiKeysRepo) Create(ctx context.Context, req *entity.APIKeys) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.apiKeys {
+		if v.GUID == req.GUID || v.Prefix == req.Prefix {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	key := copyAPIKey(req)
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+	r.store.apiKeys = append(r.store.apiKeys, key)
+
+	return nil
+}
+
+// List returns the newest keys first
+func (r apiKeysRepo) List(ctx context.Context, filter entity.APIKeysFilter) ([]*entity.APIKeys, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var keys []*entity.APIKeys
+	for _, v := range r.store.apiKeys {
+		if !matchAPIKey(v, filter) {
+			continue
+		}
+
+		keys = append(keys, copyAPIKey(v))
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+func (r apiKeysRepo) Update(ctx context.Context, req *entity.APIKeys) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var key *entity.APIKeys
+	for _, v := range r.store.apiKeys {
+		if v.GUID == req.GUID {
+			key = v
+		} else if v.Prefix == req.Prefix {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	if key == nil {
+		return errorspkg.ErrorNotFound
+	}
+
+	key.Name = req.Name
+	key.Prefix = req.Prefix
+	key.KeyHash = req.KeyHash
+	key.Role = req.Role
+	key.Scopes = copyStrings(req.Scopes)
+	key.LastUsedAt = copyTime(req.LastUsedAt)
+	key.ExpiresAt = copyTime(req.ExpiresAt)
+	key.RevokedAt = copyTime(req.RevokedAt)
+	key.UpdatedAt = req.UpdatedAt
+
+	return nil
+}
+
+func matchAPIKey(v *entity.APIKeys, filter entity.APIKeysFilter) bool {
+	if filter.Revoked != nil && *filter.Revoked != (v.RevokedAt != nil) {
+		return false
+	}
+
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchText(filter.Prefix, v.Prefix) &&
+		matchAny(filter.Roles, v.Role) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
+
+func copyAPIKey(v *entity.APIKeys) *entity.APIKeys {
+	key := *v
+	key.Scopes = copyStrings(v.Scopes)
+	key.LastUsedAt = copyTime(v.LastUsedAt)
+	key.ExpiresAt = copyTime(v.ExpiresAt)
+	key.RevokedAt = copyTime(v.RevokedAt)
+	return &key
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type articlesRepo struct {
+	store *Store
+}
+
+func NewArticlesRepo(store *Store) repository.Articles {
+	return &articlesRepo{
+		store: store,
+	}
+}
+
+func (r articlesRepo) Create(ctx context.Context, req *entity.Articles) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.articles {
+		if v.GUID == req.GUID {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	if r.store.findChapter(req.ChapterID) == nil {
+		return errForeignKey
+	}
+
+	r.store.articles = append(r.store.articles, &entity.Articles{
+		GUID:      req.GUID,
+		ChapterID: req.ChapterID,
+		Info:      req.Info,
+		Img:       req.Img,
+		Side:      req.Side,
+		CreatedAt: req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r articlesRepo) List(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var articles []*entity.Articles
+	for _, v := range r.store.articles {
+		if !matchArticle(v, filter) {
+			continue
+		}
+
+		article := *v
+		articles = append(articles, &article)
+	}
+
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].CreatedAt.Before(articles[j].CreatedAt)
+	})
+
+	return articles, nil
+}
+
+func (r articlesRepo) Update(ctx context.Context, req *entity.Articles) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.articles {
+		if v.GUID == req.GUID {
+			v.Info = req.Info
+			v.Img = req.Img
+			v.Side = req.Side
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func (r articlesRepo) Delete(ctx context.Context, filter entity.ArticlesFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Articles
+	for _, v := range r.store.articles {
+		if !matchArticle(v, filter) {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(kept) == len(r.store.articles) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.articles = kept
+
+	return nil
+}
+
+func matchArticle(v *entity.Articles, filter entity.ArticlesFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.ChapterIDs, v.ChapterID) &&
+		matchText(filter.Side, v.Side) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type authorsRepo struct {
+	store *Store
+}
+
+func NewAuthorsRepo(store *Store) repository.Authors {
+	return &authorsRepo{
+		store: store,
+	}
+}
+
+func (r authorsRepo) Create(ctx context.Context, req *entity.Authors) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.findAuthor(req.GUID) != nil {
+		return errorspkg.ErrorConflict
+	}
+
+	r.store.authors = append(r.store.authors, &entity.Authors{
+		GUID:      req.GUID,
+		Name:      req.Name,
+		Img:       copyBytes(req.Img),
+		CreatedAt: req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r authorsRepo) Get(ctx context.Context, guid string) (*entity.Authors, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	author := r.store.findAuthor(guid)
+	if author == nil {
+		return nil, errorspkg.ErrorNotFound
+	}
+
+	return copyAuthor(author), nil
+}
+
+func (r authorsRepo) List(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var authors []*entity.Authors
+	for _, v := range r.store.authors {
+		if !matchAuthor(v, filter) {
+			continue
+		}
+
+		authors = append(authors, copyAuthor(v))
+	}
+
+	sort.SliceStable(authors, func(i, j int) bool {
+		return authors[i].CreatedAt.Before(authors[j].CreatedAt)
+	})
+
+	return authors, nil
+}
+
+func (r authorsRepo) Update(ctx context.Context, req *entity.Authors) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	author := r.store.findAuthor(req.GUID)
+	if author == nil {
+		return errorspkg.ErrorNotFound
+	}
+	author.Name = req.Name
+	author.Img = copyBytes(req.Img)
+
+	return nil
+}
+
+func (r authorsRepo) Delete(ctx context.Context, filter entity.AuthorsFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Authors
+	for _, v := range r.store.authors {
+		if !matchAuthor(v, filter) {
+			kept = append(kept, v)
+			continue
+		}
+
+		for _, publication := range r.store.publications {
+			if publication.AuthorID == v.GUID {
+				return errForeignKey
+			}
+		}
+	}
+
+	if len(kept) == len(r.store.authors) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.authors = kept
+
+	return nil
+}
+
+// findAuthor is called with the lock held
+func (s *Store) findAuthor(guid string) *entity.Authors {
+	for _, v := range s.authors {
+		if v.GUID == guid {
+			return v
+		}
+	}
+	return nil
+}
+
+func matchAuthor(v *entity.Authors, filter entity.AuthorsFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchSearch(filter.Search, v.Name) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
+
+func copyAuthor(v *entity.Authors) *entity.Authors {
+	author := *v
+	author.Img = copyBytes(v.Img)
+	return &author
+}
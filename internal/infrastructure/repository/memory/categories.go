@@ -0,0 +1,137 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type categoriesRepo struct {
+	store *Store
+}
+
+func NewCategoriesRepo(store *Store) repository.Categories {
+	return &categoriesRepo{
+		store: store,
+	}
+}
+
+func (r categoriesRepo) Create(ctx context.Context, req *entity.Categories) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.findCategory(req.GUID) != nil {
+		return errorspkg.ErrorConflict
+	}
+
+	r.store.categories = append(r.store.categories, &entity.Categories{
+		GUID:        req.GUID,
+		Title:       req.Title,
+		Description: req.Description,
+		URL:         req.URL,
+		CreatedAt:   req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r categoriesRepo) List(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var categories []*entity.Categories
+	for _, v := range r.store.categories {
+		if !matchCategory(v, filter) {
+			continue
+		}
+
+		category := *v
+		categories = append(categories, &category)
+	}
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].CreatedAt.Before(categories[j].CreatedAt)
+	})
+
+	return categories, nil
+}
+
+func (r categoriesRepo) Update(ctx context.Context, req *entity.Categories) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	category := r.store.findCategory(req.GUID)
+	if category == nil {
+		return errorspkg.ErrorNotFound
+	}
+	category.Title = req.Title
+	category.Description = req.Description
+	category.URL = req.URL
+
+	return nil
+}
+
+func (r categoriesRepo) Delete(ctx context.Context, filter entity.CategoriesFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Categories
+	for _, v := range r.store.categories {
+		if !matchCategory(v, filter) {
+			kept = append(kept, v)
+			continue
+		}
+
+		for _, publication := range r.store.publications {
+			if publication.CategoryID == v.GUID {
+				return errForeignKey
+			}
+		}
+	}
+
+	if len(kept) == len(r.store.categories) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.categories = kept
+
+	return nil
+}
+
+func matchCategory(v *entity.Categories, filter entity.CategoriesFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchSearch(filter.Search, v.Title) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
+
+// findCategory is called with the lock held
+func (s *Store) findCategory(guid string) *entity.Categories {
+	for _, v := range s.categories {
+		if v.GUID == guid {
+			return v
+		}
+	}
+	return nil
+}
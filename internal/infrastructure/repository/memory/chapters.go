@@ -0,0 +1,125 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type chaptersRepo struct {
+	store *Store
+}
+
+func NewChaptersRepo(store *Store) repository.Chapters {
+	return &chaptersRepo{
+		store: store,
+	}
+}
+
+func (r chaptersRepo) Create(ctx context.Context, req *entity.Chapters) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.findChapter(req.GUID) != nil {
+		return errorspkg.ErrorConflict
+	}
+
+	r.store.chapters = append(r.store.chapters, &entity.Chapters{
+		GUID:      req.GUID,
+		Title:     req.Title,
+		CreatedAt: req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r chaptersRepo) List(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var chapters []*entity.Chapters
+	for _, v := range r.store.chapters {
+		if !matchChapter(v, filter) {
+			continue
+		}
+
+		chapter := *v
+		chapters = append(chapters, &chapter)
+	}
+
+	sort.SliceStable(chapters, func(i, j int) bool {
+		return chapters[i].CreatedAt.Before(chapters[j].CreatedAt)
+	})
+
+	return chapters, nil
+}
+
+func (r chaptersRepo) Update(ctx context.Context, req *entity.Chapters) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	chapter := r.store.findChapter(req.GUID)
+	if chapter == nil {
+		return errorspkg.ErrorNotFound
+	}
+	chapter.Title = req.Title
+
+	return nil
+}
+
+func (r chaptersRepo) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, v := range r.store.chapters {
+		if v.GUID != id {
+			continue
+		}
+
+		for _, article := range r.store.articles {
+			if article.ChapterID == id {
+				return errForeignKey
+			}
+		}
+
+		r.store.chapters = append(r.store.chapters[:i], r.store.chapters[i+1:]...)
+		return nil
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+// findChapter is called with the lock held
+func (s *Store) findChapter(guid string) *entity.Chapters {
+	for _, v := range s.chapters {
+		if v.GUID == guid {
+			return v
+		}
+	}
+	return nil
+}
+
+func matchChapter(v *entity.Chapters, filter entity.ChaptersFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchSearch(filter.Search, v.Title) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
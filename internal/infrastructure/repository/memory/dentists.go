@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type dentistsRepo struct {
+	store *Store
+}
+
+func NewDentistsRepo(store *Store) repository.Denstists {
+	return &dentistsRepo{
+		store: store,
+	}
+}
+
+func (r dentistsRepo) Get(ctx context.Context, id int64) (*entity.Dentists, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, v := range r.store.dentists {
+		if v.ID == id {
+			dentist := *v
+			return &dentist, nil
+		}
+	}
+
+	return nil, errorspkg.ErrorNotFound
+}
+
+// List returns dentists in insertion order, Postgres repository doesn't sort them either
+func (r dentistsRepo) List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	dentists := []*entity.Dentists{}
+	for _, v := range r.store.dentists {
+		if !matchDentist(v, filter) {
+			continue
+		}
+
+		dentist := *v
+		dentists = append(dentists, &dentist)
+	}
+
+	return dentists, nil
+}
+
+// Create sets ID generated by sequence
+func (r dentistsRepo) Create(ctx context.Context, req *entity.Dentists) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.dentistsSeq++
+	req.ID = r.store.dentistsSeq
+
+	r.store.dentists = append(r.store.dentists, &entity.Dentists{
+		ID:        req.ID,
+		CloneName: req.CloneName,
+		Name:      req.Name,
+		Info:      req.Info,
+		URL:       req.URL,
+		Side:      req.Side,
+		Priority:  req.Priority,
+	})
+
+	return nil
+}
+
+func (r dentistsRepo) Update(ctx context.Context, req *entity.Dentists) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.dentists {
+		if v.ID == req.ID {
+			v.Info = req.Info
+			v.Name = req.Name
+			v.URL = req.URL
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func matchDentist(v *entity.Dentists, filter entity.DentistsFilter) bool {
+	if len(filter.IDs) > 0 && !containsID(filter.IDs, v.ID) {
+		return false
+	}
+
+	return matchText(filter.CloneName, v.CloneName) &&
+		matchText(filter.Side, v.Side) &&
+		matchSearch(filter.Search, v.Name)
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type exchangeRatesRepo struct {
+	store *Store
+}
+
+func NewExchangeRatesRepo(store *Store) repository.ExchangeRates {
+	return &exchangeRatesRepo{
+		store: store,
+	}
+}
+
+func (r exchangeRatesRepo) Create(ctx context.Context, req *entity.ExchangeRates) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// unique index of the pair doesn't depend on the direction
+	for _, v := range r.store.exchangeRates {
+		if v.GUID == req.GUID ||
+			(v.Base == req.Base && v.Quote == req.Quote) ||
+			(v.Base == req.Quote && v.Quote == req.Base) {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	rate := *req
+	r.store.exchangeRates = append(r.store.exchangeRates, &rate)
+
+	return nil
+}
+
+func (r exchangeRatesRepo) List(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var rates []*entity.ExchangeRates
+	for _, v := range r.store.exchangeRates {
+		if !matchExchangeRate(v, filter) {
+			continue
+		}
+
+		rate := *v
+		rates = append(rates, &rate)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].Base != rates[j].Base {
+			return rates[i].Base < rates[j].Base
+		}
+		return rates[i].Quote < rates[j].Quote
+	})
+
+	return rates, nil
+}
+
+func (r exchangeRatesRepo) Update(ctx context.Context, req *entity.ExchangeRates) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.exchangeRates {
+		if v.GUID == req.GUID {
+			v.Rate = req.Rate
+			v.UpdatedAt = req.UpdatedAt
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func (r exchangeRatesRepo) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, v := range r.store.exchangeRates {
+		if v.GUID == id {
+			r.store.exchangeRates = append(r.store.exchangeRates[:i], r.store.exchangeRates[i+1:]...)
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func matchExchangeRate(v *entity.ExchangeRates, filter entity.ExchangeRatesFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		(matchAny(filter.Currencies, v.Base) || matchAny(filter.Currencies, v.Quote))
+}
@@ -0,0 +1,32 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/memory"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/repositorytest"
+)
+
+func TestContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := memory.NewStore()
+
+		return repositorytest.Repositories{
+			APIKeys:       memory.NewAPIKeysRepo(store),
+			Articles:      memory.NewArticlesRepo(store),
+			Authors:       memory.NewAuthorsRepo(store),
+			Categories:    memory.NewCategoriesRepo(store),
+			Chapters:      memory.NewChaptersRepo(store),
+			Dentists:      memory.NewDentistsRepo(store),
+			ExchangeRates: memory.NewExchangeRatesRepo(store),
+			PriceTiers:    memory.NewPriceTiersRepo(store),
+			Publications:  memory.NewPublicationsRepo(store),
+			RefreshTokens: memory.NewRefreshTokenRepo(store),
+			RoleSettings:  memory.NewRoleSettingsRepo(store),
+			ServiceGroups: memory.NewServiceGroupsRepo(store),
+			Services:      memory.NewServicesRepo(store),
+			ServicePrices: memory.NewServicePricesRepo(store),
+			Users:         memory.NewUsersRepo(store),
+		}
+	})
+}
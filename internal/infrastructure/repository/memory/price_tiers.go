@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type priceTiersRepo struct {
+	store *Store
+}
+
+func NewPriceTiersRepo(store *Store) repository.PriceTiers {
+	return &priceTiersRepo{
+		store: store,
+	}
+}
+
+func (r priceTiersRepo) Create(ctx context.Context, req *entity.PriceTiers) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.priceTiers {
+		if v.GUID == req.GUID || v.Code == req.Code {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	tier := *req
+	r.store.priceTiers = append(r.store.priceTiers, &tier)
+
+	return nil
+}
+
+func (r priceTiersRepo) List(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var tiers []*entity.PriceTiers
+	for _, v := range r.store.priceTiers {
+		if !matchPriceTier(v, filter) {
+			continue
+		}
+
+		tier := *v
+		tiers = append(tiers, &tier)
+	}
+
+	sort.SliceStable(tiers, func(i, j int) bool {
+		if tiers[i].Position != tiers[j].Position {
+			return tiers[i].Position < tiers[j].Position
+		}
+		return tiers[i].Code < tiers[j].Code
+	})
+
+	return tiers, nil
+}
+
+func (r priceTiersRepo) Update(ctx context.Context, req *entity.PriceTiers) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.priceTiers {
+		if v.GUID == req.GUID {
+			v.Name = req.Name
+			v.Position = req.Position
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func (r priceTiersRepo) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, v := range r.store.priceTiers {
+		if v.GUID != id {
+			continue
+		}
+
+		for _, price := range r.store.servicePrices {
+			if hasTier(price.Prices, []string{v.Code}) {
+				return errForeignKey
+			}
+		}
+
+		r.store.priceTiers = append(r.store.priceTiers[:i], r.store.priceTiers[i+1:]...)
+		return nil
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+// findPriceTier is called with the lock held
+func (s *Store) findPriceTier(code string) *entity.PriceTiers {
+	for _, v := range s.priceTiers {
+		if v.Code == code {
+			return v
+		}
+	}
+	return nil
+}
+
+func matchPriceTier(v *entity.PriceTiers, filter entity.PriceTiersFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.Codes, v.Code)
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type publicationsRepo struct {
+	store *Store
+}
+
+func NewPublicationsRepo(store *Store) repository.Publications {
+	return &publicationsRepo{
+		store: store,
+	}
+}
+
+func (r publicationsRepo) Create(ctx context.Context, req *entity.Publications) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.publications {
+		if v.GUID == req.GUID {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	if r.store.findCategory(req.CategoryID) == nil || r.store.findAuthor(req.AuthorID) == nil {
+		return errForeignKey
+	}
+
+	r.store.publications = append(r.store.publications, &entity.Publications{
+		GUID:        req.GUID,
+		CategoryID:  req.CategoryID,
+		AuthorID:    req.AuthorID,
+		Title:       req.Title,
+		Description: req.Description,
+		Type:        req.Type,
+		Content:     copyStrings(req.Content),
+		CreatedAt:   req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r publicationsRepo) List(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var publications []*entity.Publications
+	for _, v := range r.store.publications {
+		if !matchPublication(v, filter) {
+			continue
+		}
+
+		publication := *v
+		publication.Content = copyStrings(v.Content)
+		publications = append(publications, &publication)
+	}
+
+	sort.SliceStable(publications, func(i, j int) bool {
+		return publications[i].CreatedAt.Before(publications[j].CreatedAt)
+	})
+
+	return publications, nil
+}
+
+// Update keeps category, author and type, like Postgres repository does
+func (r publicationsRepo) Update(ctx context.Context, req *entity.Publications) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.publications {
+		if v.GUID == req.GUID {
+			v.Title = req.Title
+			v.Description = req.Description
+			v.Content = copyStrings(req.Content)
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func (r publicationsRepo) Delete(ctx context.Context, filter entity.PublicationsFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Publications
+	for _, v := range r.store.publications {
+		if !matchPublication(v, filter) {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(kept) == len(r.store.publications) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.publications = kept
+
+	return nil
+}
+
+func matchPublication(v *entity.Publications, filter entity.PublicationsFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.CategoryIDs, v.CategoryID) &&
+		matchAny(filter.AuthorIDs, v.AuthorID) &&
+		matchText(filter.Type, v.Type) &&
+		matchSearch(filter.Search, v.Title) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type refreshTokenRepo struct {
+	store *Store
+}
+
+func NewRefreshTokenRepo(store *Store) repository.RefreshTokenRepo {
+	return &refreshTokenRepo{
+		store: store,
+	}
+}
+
+// Get doesn't return UserID, like Postgres repository doesn't
+func (r *refreshTokenRepo) Get(ctx context.Context, refreshToken string) (*entity.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, v := range r.store.refreshTokens {
+		if v.RefreshToken == refreshToken {
+			return &entity.RefreshToken{
+				GUID:         v.GUID,
+				UserID:       v.UserID,
+				RefreshToken: v.RefreshToken,
+				ExpiryDate:   v.ExpiryDate,
+				CreatedAt:    v.CreatedAt,
+			}, nil
+		}
+	}
+
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, m *entity.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.refreshTokens {
+		if v.GUID == m.GUID {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	token := *m
+	r.store.refreshTokens = append(r.store.refreshTokens, &token)
+
+	return nil
+}
+
+func (r *refreshTokenRepo) Delete(ctx context.Context, refreshToken string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.RefreshToken
+	for _, v := range r.store.refreshTokens {
+		if v.RefreshToken != refreshToken {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(kept) == len(r.store.refreshTokens) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.refreshTokens = kept
+
+	return nil
+}
+
+func (r *refreshTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.RefreshToken
+	for _, v := range r.store.refreshTokens {
+		if v.UserID != userID {
+			kept = append(kept, v)
+		}
+	}
+	r.store.refreshTokens = kept
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type roleSettingsRepo struct {
+	store *Store
+}
+
+func NewRoleSettingsRepo(store *Store) repository.RoleSettings {
+	return &roleSettingsRepo{
+		store: store,
+	}
+}
+
+func (r roleSettingsRepo) Get(ctx context.Context, role string) (*entity.RoleSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, v := range r.store.roleSettings {
+		if v.Role == role {
+			settings := *v
+			return &settings, nil
+		}
+	}
+
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (r roleSettingsRepo) List(ctx context.Context) ([]*entity.RoleSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var settings []*entity.RoleSettings
+	for _, v := range r.store.roleSettings {
+		setting := *v
+		settings = append(settings, &setting)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Role < settings[j].Role
+	})
+
+	return settings, nil
+}
+
+func (r roleSettingsRepo) Upsert(ctx context.Context, req *entity.RoleSettings) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.roleSettings {
+		if v.Role == req.Role {
+			v.MFARequired = req.MFARequired
+			v.UpdatedAt = req.UpdatedAt
+			return nil
+		}
+	}
+
+	settings := *req
+	r.store.roleSettings = append(r.store.roleSettings, &settings)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type serviceGroupsRepo struct {
+	store *Store
+}
+
+func NewServiceGroupsRepo(store *Store) repository.ServiceGroups {
+	return &serviceGroupsRepo{
+		store: store,
+	}
+}
+
+// Create sets creation time itself, like Postgres repository does
+func (r serviceGroupsRepo) Create(ctx context.Context, req *entity.ServiceGroups) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.findServiceGroup(req.GUID) != nil {
+		return errorspkg.ErrorConflict
+	}
+
+	r.store.serviceGroups = append(r.store.serviceGroups, &entity.ServiceGroups{
+		GUID:      req.GUID,
+		Name:      req.Name,
+		CreatedAt: time.Now().Local(),
+	})
+
+	return nil
+}
+
+func (r serviceGroupsRepo) List(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var groups []*entity.ServiceGroups
+	for _, v := range r.store.serviceGroups {
+		if !matchServiceGroup(v, filter) {
+			continue
+		}
+
+		group := *v
+		groups = append(groups, &group)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+	})
+
+	return groups, nil
+}
+
+func (r serviceGroupsRepo) Update(ctx context.Context, req *entity.ServiceGroups) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	group := r.store.findServiceGroup(req.GUID)
+	if group == nil {
+		return errorspkg.ErrorNotFound
+	}
+	group.Name = req.Name
+
+	return nil
+}
+
+func (r serviceGroupsRepo) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for i, v := range r.store.serviceGroups {
+		if v.GUID != id {
+			continue
+		}
+
+		for _, service := range r.store.services {
+			if service.GroupID == id {
+				return errForeignKey
+			}
+		}
+
+		r.store.serviceGroups = append(r.store.serviceGroups[:i], r.store.serviceGroups[i+1:]...)
+		return nil
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+// findServiceGroup is called with the lock held
+func (s *Store) findServiceGroup(guid string) *entity.ServiceGroups {
+	for _, v := range s.serviceGroups {
+		if v.GUID == guid {
+			return v
+		}
+	}
+	return nil
+}
+
+func matchServiceGroup(v *entity.ServiceGroups, filter entity.ServiceGroupsFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchSearch(filter.Search, v.Name) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
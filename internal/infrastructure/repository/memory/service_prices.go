@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type servicePricesRepo struct {
+	store *Store
+}
+
+func NewServicePricesRepo(store *Store) repository.ServicePrices {
+	return &servicePricesRepo{
+		store: store,
+	}
+}
+
+func (r servicePricesRepo) Create(ctx context.Context, req *entity.ServicePrices) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.store.findService(req.ServiceID) == nil {
+		return errorspkg.ErrorNotFound
+	}
+
+	for _, v := range r.store.servicePrices {
+		if v.GUID == req.GUID || (v.ServiceID == req.ServiceID && v.ValidFrom.Equal(req.ValidFrom)) {
+			return errorspkg.ErrorConflict
+		}
+	}
+	if err := r.store.checkTierPrices(req.Prices); err != nil {
+		return err
+	}
+
+	var validTo *time.Time
+	var kept []*entity.ServicePrices
+	for _, v := range r.store.servicePrices {
+		if v.ServiceID != req.ServiceID {
+			kept = append(kept, v)
+			continue
+		}
+
+		if v.ValidFrom.After(req.ValidFrom) && (validTo == nil || v.ValidFrom.Before(*validTo)) {
+			validTo = copyTime(&v.ValidFrom)
+		}
+		if v.ValidFrom.Before(req.ValidFrom) && (v.ValidTo == nil || v.ValidTo.After(req.ValidFrom)) {
+			v.ValidTo = copyTime(&req.ValidFrom)
+		}
+
+		kept = append(kept, v)
+	}
+
+	r.store.servicePrices = append(kept, &entity.ServicePrices{
+		GUID:      req.GUID,
+		ServiceID: req.ServiceID,
+		Prices:    copyTierPrices(req.Prices),
+		Currency:  req.Currency,
+		ValidFrom: req.ValidFrom,
+		ValidTo:   copyTime(validTo),
+		CreatedAt: req.CreatedAt,
+	})
+
+	req.ValidTo = validTo
+	return nil
+}
+
+func (r servicePricesRepo) List(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var prices []*entity.ServicePrices
+	for _, v := range r.store.servicePrices {
+		if !matchServicePrice(v, filter) {
+			continue
+		}
+
+		price := copyServicePrice(v)
+		price.Prices = r.store.sortTierPrices(v.Prices)
+		prices = append(prices, price)
+	}
+
+	sort.SliceStable(prices, func(i, j int) bool {
+		return prices[i].ValidFrom.Before(prices[j].ValidFrom)
+	})
+
+	return prices, nil
+}
+
+func (r servicePricesRepo) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deleted *entity.ServicePrices
+	var kept []*entity.ServicePrices
+	for _, v := range r.store.servicePrices {
+		if v.GUID == id {
+			deleted = v
+			continue
+		}
+		kept = append(kept, v)
+	}
+
+	if deleted == nil {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.servicePrices = kept
+
+	for _, v := range r.store.servicePrices {
+		if v.ServiceID == deleted.ServiceID && v.ValidTo != nil && v.ValidTo.Equal(deleted.ValidFrom) {
+			v.ValidTo = copyTime(deleted.ValidTo)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) findService(guid string) *entity.Services {
+	for _, v := range s.services {
+		if v.GUID == guid {
+			return v
+		}
+	}
+	return nil
+}
+
+// findServicePrice returns the price of the service in effect at the time
+func (s *Store) findServicePrice(serviceID string, at time.Time) *entity.ServicePrices {
+	for _, v := range s.servicePrices {
+		if v.ServiceID == serviceID && inEffect(v, at) {
+			return v
+		}
+	}
+	return nil
+}
+
+// checkTierPrices follows primary and foreign key of service price tiers
+func (s *Store) checkTierPrices(prices []entity.TierPrice) error {
+	seen := make(map[string]bool, len(prices))
+	for _, v := range prices {
+		if seen[v.Tier] {
+			return errorspkg.ErrorConflict
+		}
+		seen[v.Tier] = true
+
+		if s.findPriceTier(v.Tier) == nil {
+			return errForeignKey
+		}
+	}
+	return nil
+}
+
+// sortTierPrices returns a copy of prices ordered by position of their tiers, then by code
+func (s *Store) sortTierPrices(prices []entity.TierPrice) []entity.TierPrice {
+	sorted := copyTierPrices(prices)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := s.findPriceTier(sorted[i].Tier), s.findPriceTier(sorted[j].Tier)
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Code < b.Code
+	})
+	return sorted
+}
+
+func matchServicePrice(v *entity.ServicePrices, filter entity.ServicePricesFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.ServiceIDs, v.ServiceID) &&
+		(len(filter.Tiers) == 0 || hasTier(v.Prices, filter.Tiers)) &&
+		(filter.At.IsZero() || inEffect(v, filter.At))
+}
+
+func hasTier(prices []entity.TierPrice, tiers []string) bool {
+	for _, v := range prices {
+		if matchAny(tiers, v.Tier) {
+			return true
+		}
+	}
+	return false
+}
+
+func inEffect(v *entity.ServicePrices, at time.Time) bool {
+	return !v.ValidFrom.After(at) && (v.ValidTo == nil || v.ValidTo.After(at))
+}
+
+func copyServicePrice(v *entity.ServicePrices) *entity.ServicePrices {
+	price := *v
+	price.Prices = copyTierPrices(v.Prices)
+	price.ValidTo = copyTime(v.ValidTo)
+	return &price
+}
+
+// copyTierPrices keeps no amounts as nil, same as Postgres repositories return them
+func copyTierPrices(prices []entity.TierPrice) []entity.TierPrice {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	return append([]entity.TierPrice{}, prices...)
+}
@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/google/uuid"
+)
+
+type servicesRepo struct {
+	store *Store
+}
+
+func NewServicesRepo(store *Store) repository.Services {
+	return &servicesRepo{
+		store: store,
+	}
+}
+
+func (r servicesRepo) Create(ctx context.Context, req *entity.Services) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.services {
+		if v.GUID == req.GUID {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	if r.store.findServiceGroup(req.GroupID) == nil {
+		return errForeignKey
+	}
+	if err := r.store.checkTierPrices(req.Prices); err != nil {
+		return err
+	}
+
+	r.store.services = append(r.store.services, &entity.Services{
+		GUID:      req.GUID,
+		GroupID:   req.GroupID,
+		Name:      req.Name,
+		CreatedAt: req.CreatedAt,
+	})
+	r.store.servicePrices = append(r.store.servicePrices, &entity.ServicePrices{
+		GUID:      uuid.New().String(),
+		ServiceID: req.GUID,
+		Prices:    copyTierPrices(req.Prices),
+		Currency:  req.Currency,
+		ValidFrom: req.CreatedAt,
+		CreatedAt: req.CreatedAt,
+	})
+
+	return nil
+}
+
+func (r servicesRepo) List(ctx context.Context, filter entity.ServicesFilter, at time.Time) ([]*entity.Services, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var services []*entity.Services
+	for _, v := range r.store.services {
+		if !matchService(v, filter) {
+			continue
+		}
+
+		price := r.store.findServicePrice(v.GUID, at)
+		if price == nil {
+			continue
+		}
+
+		service := *v
+		service.Prices = r.store.sortTierPrices(price.Prices)
+		service.Currency = price.Currency
+		services = append(services, &service)
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].CreatedAt.Before(services[j].CreatedAt)
+	})
+
+	return services, nil
+}
+
+func (r servicesRepo) Update(ctx context.Context, req *entity.Services) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.services {
+		if v.GUID == req.GUID {
+			v.Name = req.Name
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorNotFound
+}
+
+func (r servicesRepo) Delete(ctx context.Context, filter entity.ServicesFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Services
+	deleted := make(map[string]bool)
+	for _, v := range r.store.services {
+		if matchService(v, filter) {
+			deleted[v.GUID] = true
+		} else {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(deleted) == 0 {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.services = kept
+
+	// prices are deleted on cascade
+	var keptPrices []*entity.ServicePrices
+	for _, v := range r.store.servicePrices {
+		if !deleted[v.ServiceID] {
+			keptPrices = append(keptPrices, v)
+		}
+	}
+	r.store.servicePrices = keptPrices
+
+	return nil
+}
+
+func matchService(v *entity.Services, filter entity.ServicesFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.GroupIDs, v.GroupID) &&
+		matchSearch(filter.Search, v.Name) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
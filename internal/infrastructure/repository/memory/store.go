@@ -0,0 +1,106 @@
+// Package memory implements repository interfaces in memory for tests.
+//
+// Repositories of one Store see each other's rows like tables of one database, so primary keys, unique indexes
+// and foreign keys of migrations are enforced. Filters, ordering, updated columns and errors follow the Postgres
+// repositories, the contract tests of repositorytest keep both implementations identical.
+package memory
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+// errForeignKey is returned on insert of a missing parent or delete of a referenced one
+var errForeignKey = errors.New("violates foreign key constraint")
+
+// Store holds tables of in-memory repositories, rows are kept in insertion order
+type Store struct {
+	mu sync.RWMutex
+
+	apiKeys       []*entity.APIKeys
+	articles      []*entity.Articles
+	authors       []*entity.Authors
+	categories    []*entity.Categories
+	chapters      []*entity.Chapters
+	dentists      []*entity.Dentists
+	exchangeRates []*entity.ExchangeRates
+	priceTiers    []*entity.PriceTiers
+	publications  []*entity.Publications
+	refreshTokens []*entity.RefreshToken
+	roleSettings  []*entity.RoleSettings
+	serviceGroups []*entity.ServiceGroups
+	servicePrices []*entity.ServicePrices
+	services      []*entity.Services
+	users         []*entity.Users
+
+	// dentistsSeq is the last id of dentists serial column
+	dentistsSeq int64
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// copyStrings keeps nil as NULL and empty slice as empty array
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+
+	return append([]string{}, s...)
+}
+
+func copyBytes(s []byte) []byte {
+	if s == nil {
+		return nil
+	}
+
+	return append([]byte{}, s...)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	return &c
+}
+
+// matchAny follows IN of filter lists, empty values match any v
+func matchAny(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// matchText follows equality of filter fields, empty value matches any s
+func matchText(value, s string) bool {
+	return value == "" || value == s
+}
+
+// matchSearch follows ILIKE of filter search, empty search matches any of texts
+func matchSearch(search string, texts ...string) bool {
+	if search == "" {
+		return true
+	}
+
+	search = strings.ToLower(search)
+	for _, text := range texts {
+		if strings.Contains(strings.ToLower(text), search) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+)
+
+type usersRepo struct {
+	store *Store
+}
+
+func NewUsersRepo(store *Store) repository.Users {
+	return &usersRepo{
+		store: store,
+	}
+}
+
+func (r usersRepo) Get(ctx context.Context, filter entity.UsersFilter) (*entity.Users, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.IsEmpty() {
+		return nil, errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, v := range r.store.users {
+		if matchUser(v, filter) {
+			return copyUser(v), nil
+		}
+	}
+
+	return nil, errorspkg.ErrorNotFound
+}
+
+func (r usersRepo) Create(ctx context.Context, req *entity.Users) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.users {
+		if v.GUID == req.GUID || (req.SSOSubject != "" && v.SSOSubject == req.SSOSubject) {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	user := copyUser(req)
+	user.RecoveryCodes = recoveryCodes(req.RecoveryCodes)
+	r.store.users = append(r.store.users, user)
+
+	return nil
+}
+
+// List returns users in insertion order, Postgres repository doesn't sort them either
+func (r usersRepo) List(ctx context.Context, filter entity.UsersFilter) ([]*entity.Users, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var users []*entity.Users
+	for _, v := range r.store.users {
+		if !matchUser(v, filter) {
+			continue
+		}
+
+		users = append(users, copyUser(v))
+	}
+
+	return users, nil
+}
+
+func (r usersRepo) Update(ctx context.Context, req *entity.Users) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var user *entity.Users
+	for _, v := range r.store.users {
+		if v.GUID == req.GUID {
+			user = v
+		} else if req.SSOSubject != "" && v.SSOSubject == req.SSOSubject {
+			return errorspkg.ErrorConflict
+		}
+	}
+
+	if user == nil {
+		return errorspkg.ErrorNotFound
+	}
+
+	user.Role = req.Role
+	user.Firstname = req.Firstname
+	user.Lastname = req.Lastname
+	user.Username = req.Username
+	user.Password = req.Password
+	user.MustChangePassword = req.MustChangePassword
+	user.PasswordExpiresAt = copyTime(req.PasswordExpiresAt)
+	user.TOTPSecret = req.TOTPSecret
+	user.TOTPEnabled = req.TOTPEnabled
+	user.RecoveryCodes = recoveryCodes(req.RecoveryCodes)
+	user.SSOSubject = req.SSOSubject
+	user.UpdatedAt = req.UpdatedAt
+
+	return nil
+}
+
+func (r usersRepo) UseTOTPStep(ctx context.Context, guid string, step int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.users {
+		if v.GUID == guid && v.TOTPLastStep < step {
+			v.TOTPLastStep = step
+			return nil
+		}
+	}
+
+	return errorspkg.ErrorConflict
+}
+
+func (r usersRepo) UseRecoveryCode(ctx context.Context, guid, hash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.store.users {
+		if v.GUID != guid {
+			continue
+		}
+		for i, code := range v.RecoveryCodes {
+			if code == hash {
+				v.RecoveryCodes = append(append([]string{}, v.RecoveryCodes[:i]...), v.RecoveryCodes[i+1:]...)
+				return nil
+			}
+		}
+	}
+
+	return errorspkg.ErrorConflict
+}
+
+func (r usersRepo) Delete(ctx context.Context, filter entity.UsersFilter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var kept []*entity.Users
+	for _, v := range r.store.users {
+		if !matchUser(v, filter) {
+			kept = append(kept, v)
+		}
+	}
+
+	if len(kept) == len(r.store.users) {
+		return errorspkg.ErrorNotFound
+	}
+	r.store.users = kept
+
+	return nil
+}
+
+func matchUser(v *entity.Users, filter entity.UsersFilter) bool {
+	return matchAny(filter.GUIDs, v.GUID) &&
+		matchAny(filter.Roles, v.Role) &&
+		matchText(filter.Firstname, v.Firstname) &&
+		matchText(filter.Lastname, v.Lastname) &&
+		matchText(filter.Username, v.Username) &&
+		matchText(filter.SSOSubject, v.SSOSubject) &&
+		matchSearch(filter.Search, v.Firstname, v.Lastname, v.Username) &&
+		filter.CreatedAt.Contains(v.CreatedAt)
+}
+
+func copyUser(v *entity.Users) *entity.Users {
+	user := *v
+	user.PasswordExpiresAt = copyTime(v.PasswordExpiresAt)
+	user.RecoveryCodes = copyStrings(v.RecoveryCodes)
+	return &user
+}
+
+// recoveryCodes stores no codes as empty array, recovery_codes column is not null
+func recoveryCodes(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+
+	return copyStrings(codes)
+}
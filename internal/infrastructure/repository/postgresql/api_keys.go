@@ -0,0 +1,223 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
+)
+
+var (
+	tableAPIKeys = "api_keys"
+)
+
+type apiKeysRepo struct {
+	table string
+	db    *postgres.PostgresDB
+}
+
+func NewAPIKeysRepo(db *postgres.PostgresDB) repository.APIKeys {
+	return &apiKeysRepo{
+		table: tableAPIKeys,
+		db:    db,
+	}
+}
+
+func (r apiKeysRepo) Get(ctx context.Context, filter entity.APIKeysFilter) (*entity.APIKeys, error) {
+	if filter.IsEmpty() {
+		return nil, errorspkg.ErrorEmptyFilter
+	}
+
+	queryBuilder := r.db.Sq.Builder.Select(
+		"guid",
+		"name",
+		"prefix",
+		"key_hash",
+		"role",
+		"scopes",
+		"COALESCE(created_by::text, '')",
+		"last_used_at",
+		"expires_at",
+		"revoked_at",
+		"created_at",
+		"updated_at",
+	).From(r.table).Where(r.where(filter))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" Get")
+	}
+
+	var key entity.APIKeys
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&key.GUID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Role,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.LastUsedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &key, nil
+}
+
+func (r apiKeysRepo) Create(ctx context.Context, req *entity.APIKeys) error {
+	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+		map[string]interface{}{
+			"guid":       req.GUID,
+			"name":       req.Name,
+			"prefix":     req.Prefix,
+			"key_hash":   req.KeyHash,
+			"role":       req.Role,
+			"scopes":     req.Scopes,
+			"created_by": nullUUID(req.CreatedBy),
+			"expires_at": req.ExpiresAt,
+			"created_at": req.CreatedAt,
+			"updated_at": req.UpdatedAt,
+		},
+	)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Create")
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
+func (r apiKeysRepo) List(ctx context.Context, filter entity.APIKeysFilter) ([]*entity.APIKeys, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"guid",
+		"name",
+		"prefix",
+		"key_hash",
+		"role",
+		"scopes",
+		"COALESCE(created_by::text, '')",
+		"last_used_at",
+		"expires_at",
+		"revoked_at",
+		"created_at",
+		"updated_at",
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at DESC")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	var keys []*entity.APIKeys
+	for rows.Next() {
+		var key entity.APIKeys
+		if err := rows.Scan(
+			&key.GUID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&key.Role,
+			&key.Scopes,
+			&key.CreatedBy,
+			&key.LastUsedAt,
+			&key.ExpiresAt,
+			&key.RevokedAt,
+			&key.CreatedAt,
+			&key.UpdatedAt,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		keys = append(keys, &key)
+	}
+
+	return keys, nil
+}
+
+func (r apiKeysRepo) Update(ctx context.Context, req *entity.APIKeys) error {
+	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
+		map[string]interface{}{
+			"name":         req.Name,
+			"prefix":       req.Prefix,
+			"key_hash":     req.KeyHash,
+			"role":         req.Role,
+			"scopes":       req.Scopes,
+			"last_used_at": req.LastUsedAt,
+			"expires_at":   req.ExpiresAt,
+			"revoked_at":   req.RevokedAt,
+			"updated_at":   req.UpdatedAt,
+		},
+	).Where(r.db.Sq.Equal("guid", req.GUID))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Update")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	return nil
+}
+
+// nullUUID stores empty id as NULL
+func nullUUID(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+
+	return id
+}
+
+// where matches every set field of filter
+func (r apiKeysRepo) where(filter entity.APIKeysFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if filter.Prefix != "" {
+		cond = append(cond, r.db.Sq.Equal("prefix", filter.Prefix))
+	}
+	if len(filter.Roles) > 0 {
+		cond = append(cond, r.db.Sq.Equal("role", filter.Roles))
+	}
+	if filter.Revoked != nil {
+		if *filter.Revoked {
+			cond = append(cond, r.db.Sq.NotEqual("revoked_at", nil))
+		} else {
+			cond = append(cond, r.db.Sq.Equal("revoked_at", nil))
+		}
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
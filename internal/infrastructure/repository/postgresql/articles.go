@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -50,7 +51,7 @@ func (r articlesRepo) Create(ctx context.Context, req *entity.Articles) error {
 	return nil
 }
 
-func (r articlesRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Articles, error) {
+func (r articlesRepo) List(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"chapter_id",
@@ -58,14 +59,7 @@ func (r articlesRepo) List(ctx context.Context, filter map[string]string) ([]*en
 		"img",
 		"side",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
-
-	for k, v := range filter {
-		switch k {
-		case "chapter_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
-	}
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -76,6 +70,7 @@ func (r articlesRepo) List(ctx context.Context, filter map[string]string) ([]*en
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var services []*entity.Articles
 	for rows.Next() {
@@ -117,24 +112,19 @@ func (r articlesRepo) Update(ctx context.Context, req *entity.Articles) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r articlesRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "guid":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		case "chapter_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
+func (r articlesRepo) Delete(ctx context.Context, filter entity.ArticlesFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
 	}
 
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Delete")
@@ -146,8 +136,27 @@ func (r articlesRepo) Delete(ctx context.Context, filter map[string]string) erro
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r articlesRepo) where(filter entity.ArticlesFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.ChapterIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("chapter_id", filter.ChapterIDs))
+	}
+	if filter.Side != "" {
+		cond = append(cond, r.db.Sq.Equal("side", filter.Side))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -74,13 +75,13 @@ func (r authorsRepo) Get(ctx context.Context, guid string) (*entity.Authors, err
 	return &author, nil
 }
 
-func (r authorsRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Authors, error) {
+func (r authorsRepo) List(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"name",
 		"img",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -91,6 +92,7 @@ func (r authorsRepo) List(ctx context.Context, filter map[string]string) ([]*ent
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var authors []*entity.Authors
 	for rows.Next() {
@@ -129,22 +131,19 @@ func (r authorsRepo) Update(ctx context.Context, req *entity.Authors) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r authorsRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "guid":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
+func (r authorsRepo) Delete(ctx context.Context, filter entity.AuthorsFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
 	}
 
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Delete")
@@ -156,8 +155,24 @@ func (r authorsRepo) Delete(ctx context.Context, filter map[string]string) error
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r authorsRepo) where(filter entity.AuthorsFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("name", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
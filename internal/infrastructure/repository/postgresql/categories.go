@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -49,14 +50,14 @@ func (r categoriesRepo) Create(ctx context.Context, req *entity.Categories) erro
 	return nil
 }
 
-func (r categoriesRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Categories, error) {
+func (r categoriesRepo) List(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"title",
 		"description",
 		"url",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -67,6 +68,7 @@ func (r categoriesRepo) List(ctx context.Context, filter map[string]string) ([]*
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var categories []*entity.Categories
 	for rows.Next() {
@@ -107,14 +109,18 @@ func (r categoriesRepo) Update(ctx context.Context, req *entity.Categories) erro
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r categoriesRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.db.Sq.Equal("guid", filter["guid"]))
+func (r categoriesRepo) Delete(ctx context.Context, filter entity.CategoriesFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -127,8 +133,24 @@ func (r categoriesRepo) Delete(ctx context.Context, filter map[string]string) er
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r categoriesRepo) where(filter entity.CategoriesFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("title", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
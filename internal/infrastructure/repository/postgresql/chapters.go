@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -47,12 +48,12 @@ func (r chaptersRepo) Create(ctx context.Context, req *entity.Chapters) error {
 	return nil
 }
 
-func (r chaptersRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Chapters, error) {
+func (r chaptersRepo) List(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"title",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -63,6 +64,7 @@ func (r chaptersRepo) List(ctx context.Context, filter map[string]string) ([]*en
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var groups []*entity.Chapters
 	for rows.Next() {
@@ -99,7 +101,7 @@ func (r chaptersRepo) Update(ctx context.Context, req *entity.Chapters) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
@@ -119,8 +121,24 @@ func (r chaptersRepo) Delete(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r chaptersRepo) where(filter entity.ChaptersFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("title", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
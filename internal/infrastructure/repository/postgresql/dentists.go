@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -43,7 +44,7 @@ func (r dentistsRepo) Get(ctx context.Context, id int64) (*entity.Dentists, erro
 
 	var d entity.Dentists
 
-	err = r.db.Pool.QueryRow(ctx, query, args...).Scan(
+	err = r.db.QueryRow(ctx, query, args...).Scan(
 		&d.ID,
 		&d.CloneName,
 		&d.Name,
@@ -59,7 +60,7 @@ func (r dentistsRepo) Get(ctx context.Context, id int64) (*entity.Dentists, erro
 	return &d, nil
 }
 
-func (r dentistsRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Dentists, error) {
+func (r dentistsRepo) List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"id",
 		"clone_name",
@@ -68,14 +69,7 @@ func (r dentistsRepo) List(ctx context.Context, filter map[string]string) ([]*en
 		"url",
 		"side",
 		"priority",
-	).From(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "side":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
-	}
+	).From(r.table).Where(r.where(filter))
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -86,6 +80,7 @@ func (r dentistsRepo) List(ctx context.Context, filter map[string]string) ([]*en
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	dentists := []*entity.Dentists{}
 	for rows.Next() {
@@ -104,6 +99,32 @@ func (r dentistsRepo) List(ctx context.Context, filter map[string]string) ([]*en
 
 	return dentists, nil
 }
+
+// Create sets ID generated by database
+func (r dentistsRepo) Create(ctx context.Context, req *entity.Dentists) error {
+	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+		map[string]interface{}{
+			"clone_name": req.CloneName,
+			"name":       req.Name,
+			"info":       req.Info,
+			"url":        req.URL,
+			"side":       req.Side,
+			"priority":   req.Priority,
+		},
+	).Suffix("RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Create")
+	}
+
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&req.ID); err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
 func (r dentistsRepo) Update(ctx context.Context, req *entity.Dentists) error {
 	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
 		map[string]interface{}{
@@ -124,8 +145,27 @@ func (r dentistsRepo) Update(ctx context.Context, req *entity.Dentists) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r dentistsRepo) where(filter entity.DentistsFilter) sq.And {
+	var cond sq.And
+	if len(filter.IDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("id", filter.IDs))
+	}
+	if filter.CloneName != "" {
+		cond = append(cond, r.db.Sq.Equal("clone_name", filter.CloneName))
+	}
+	if filter.Side != "" {
+		cond = append(cond, r.db.Sq.Equal("side", filter.Side))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("name", filter.Search))
+	}
+
+	return cond
+}
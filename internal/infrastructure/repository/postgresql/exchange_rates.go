@@ -0,0 +1,151 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
+)
+
+var (
+	tableExchangeRates = "exchange_rates"
+)
+
+type exchangeRatesRepo struct {
+	table string
+	db    *postgres.PostgresDB
+}
+
+func NewExchangeRatesRepo(db *postgres.PostgresDB) repository.ExchangeRates {
+	return &exchangeRatesRepo{
+		table: tableExchangeRates,
+		db:    db,
+	}
+}
+
+func (r exchangeRatesRepo) Create(ctx context.Context, req *entity.ExchangeRates) error {
+	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+		map[string]interface{}{
+			"guid":       req.GUID,
+			"base":       req.Base,
+			"quote":      req.Quote,
+			"rate":       req.Rate,
+			"created_at": req.CreatedAt,
+			"updated_at": req.UpdatedAt,
+		},
+	)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Create")
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
+func (r exchangeRatesRepo) List(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"guid",
+		"base",
+		"quote",
+		"rate",
+		"created_at",
+		"updated_at",
+	).From(r.table).Where(r.where(filter)).OrderBy("base asc", "quote asc")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	var rates []*entity.ExchangeRates
+	for rows.Next() {
+		var rate entity.ExchangeRates
+		if err := rows.Scan(
+			&rate.GUID,
+			&rate.Base,
+			&rate.Quote,
+			&rate.Rate,
+			&rate.CreatedAt,
+			&rate.UpdatedAt,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	return rates, nil
+}
+
+func (r exchangeRatesRepo) Update(ctx context.Context, req *entity.ExchangeRates) error {
+	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
+		map[string]interface{}{
+			"rate":       req.Rate,
+			"updated_at": req.UpdatedAt,
+		},
+	).Where(r.db.Sq.Equal("guid", req.GUID))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Update")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (r exchangeRatesRepo) Delete(ctx context.Context, id string) error {
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.db.Sq.Equal("guid", id))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Delete")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	return nil
+}
+
+// where matches every set field of filter
+func (r exchangeRatesRepo) where(filter entity.ExchangeRatesFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.Currencies) > 0 {
+		cond = append(cond, sq.Or{r.db.Sq.Equal("base", filter.Currencies), r.db.Sq.Equal("quote", filter.Currencies)})
+	}
+
+	return cond
+}
@@ -0,0 +1,38 @@
+package postgresql_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/postgresql"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/repositorytest"
+	"github.com/AsaHero/abclinic/internal/itest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(itest.Main(m))
+}
+
+func TestContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		db := itest.DB(t)
+
+		return repositorytest.Repositories{
+			APIKeys:       postgresql.NewAPIKeysRepo(db),
+			Articles:      postgresql.NewArticlesRepo(db),
+			Authors:       postgresql.NewAuthorsRepo(db),
+			Categories:    postgresql.NewCategoriesRepo(db),
+			Chapters:      postgresql.NewChaptersRepo(db),
+			Dentists:      postgresql.NewDentistsRepo(db),
+			ExchangeRates: postgresql.NewExchangeRatesRepo(db),
+			PriceTiers:    postgresql.NewPriceTiersRepo(db),
+			Publications:  postgresql.NewPublicationsRepo(db),
+			RefreshTokens: postgresql.NewRefreshTokenRepo(db),
+			RoleSettings:  postgresql.NewRoleSettingsRepo(db),
+			ServiceGroups: postgresql.NewServiceGroupsRepo(db),
+			Services:      postgresql.NewServicesRepo(db),
+			ServicePrices: postgresql.NewServicePricesRepo(db),
+			Users:         postgresql.NewUsersRepo(db),
+		}
+	})
+}
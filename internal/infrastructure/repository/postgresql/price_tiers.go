@@ -0,0 +1,148 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
+)
+
+var (
+	tablePriceTiers = "price_tiers"
+)
+
+type priceTiersRepo struct {
+	table string
+	db    *postgres.PostgresDB
+}
+
+func NewPriceTiersRepo(db *postgres.PostgresDB) repository.PriceTiers {
+	return &priceTiersRepo{
+		table: tablePriceTiers,
+		db:    db,
+	}
+}
+
+func (r priceTiersRepo) Create(ctx context.Context, req *entity.PriceTiers) error {
+	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+		map[string]interface{}{
+			"guid":       req.GUID,
+			"code":       req.Code,
+			"name":       req.Name,
+			"position":   req.Position,
+			"created_at": req.CreatedAt,
+		},
+	)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Create")
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
+func (r priceTiersRepo) List(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"guid",
+		"code",
+		"name",
+		"position",
+		"created_at",
+	).From(r.table).Where(r.where(filter)).OrderBy("position asc", "code asc")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	var tiers []*entity.PriceTiers
+	for rows.Next() {
+		var tier entity.PriceTiers
+		if err := rows.Scan(
+			&tier.GUID,
+			&tier.Code,
+			&tier.Name,
+			&tier.Position,
+			&tier.CreatedAt,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		tiers = append(tiers, &tier)
+	}
+
+	return tiers, nil
+}
+
+func (r priceTiersRepo) Update(ctx context.Context, req *entity.PriceTiers) error {
+	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
+		map[string]interface{}{
+			"name":     req.Name,
+			"position": req.Position,
+		},
+	).Where(r.db.Sq.Equal("guid", req.GUID))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Update")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	return nil
+}
+
+func (r priceTiersRepo) Delete(ctx context.Context, id string) error {
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.db.Sq.Equal("guid", id))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Delete")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	return nil
+}
+
+// where matches every set field of filter
+func (r priceTiersRepo) where(filter entity.PriceTiersFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.Codes) > 0 {
+		cond = append(cond, r.db.Sq.Equal("code", filter.Codes))
+	}
+
+	return cond
+}
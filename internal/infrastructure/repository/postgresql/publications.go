@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -52,7 +53,7 @@ func (r publicationsRepo) Create(ctx context.Context, req *entity.Publications)
 	return nil
 }
 
-func (r publicationsRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Publications, error) {
+func (r publicationsRepo) List(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"category_id",
@@ -62,14 +63,7 @@ func (r publicationsRepo) List(ctx context.Context, filter map[string]string) ([
 		"type",
 		"content",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
-
-	for k, v := range filter {
-		switch k {
-		case "category_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
-	}
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -80,6 +74,7 @@ func (r publicationsRepo) List(ctx context.Context, filter map[string]string) ([
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var publications []*entity.Publications
 	for rows.Next() {
@@ -123,26 +118,19 @@ func (r publicationsRepo) Update(ctx context.Context, req *entity.Publications)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r publicationsRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "guid":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		case "category_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		case "author_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
+func (r publicationsRepo) Delete(ctx context.Context, filter entity.PublicationsFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
 	}
 
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Delete")
@@ -154,8 +142,33 @@ func (r publicationsRepo) Delete(ctx context.Context, filter map[string]string)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r publicationsRepo) where(filter entity.PublicationsFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.CategoryIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("category_id", filter.CategoryIDs))
+	}
+	if len(filter.AuthorIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("author_id", filter.AuthorIDs))
+	}
+	if filter.Type != "" {
+		cond = append(cond, r.db.Sq.Equal("type", filter.Type))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("title", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
@@ -2,9 +2,9 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
 )
@@ -25,6 +25,7 @@ func (r *refreshTokenRepo) Get(ctx context.Context, refreshToken string) (*entit
 	query := r.db.Sq.Builder.
 		Select(
 			"guid",
+			"user_id",
 			"refresh_token",
 			"expiry_date",
 			"created_at",
@@ -40,6 +41,7 @@ func (r *refreshTokenRepo) Get(ctx context.Context, refreshToken string) (*entit
 	var m entity.RefreshToken
 	err = r.db.QueryRow(ctx, sqlStr, args...).Scan(
 		&m.GUID,
+		&m.UserID,
 		&m.RefreshToken,
 		&m.ExpiryDate,
 		&m.CreatedAt,
@@ -55,6 +57,7 @@ func (r *refreshTokenRepo) Get(ctx context.Context, refreshToken string) (*entit
 func (r *refreshTokenRepo) Create(ctx context.Context, m *entity.RefreshToken) error {
 	clauses := map[string]interface{}{
 		"guid":          m.GUID,
+		"user_id":       m.UserID,
 		"refresh_token": m.RefreshToken,
 		"expiry_date":   m.ExpiryDate,
 		"created_at":    m.CreatedAt,
@@ -85,7 +88,22 @@ func (r *refreshTokenRepo) Delete(ctx context.Context, refreshToken string) erro
 		return r.db.Error(err)
 	}
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("failed token delete: no such token"))
+		return errorspkg.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *refreshTokenRepo) DeleteByUserID(ctx context.Context, userID string) error {
+	sqlStr, args, err := r.db.Sq.Builder.
+		Delete(r.tableName).
+		Where(r.db.Sq.Equal("user_id", userID)).
+		ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.tableName+" delete by user id")
+	}
+
+	if _, err = r.db.Exec(ctx, sqlStr, args...); err != nil {
+		return r.db.Error(err)
 	}
 	return nil
 }
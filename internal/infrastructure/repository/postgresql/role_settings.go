@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+)
+
+var (
+	tableRoleSettings = "role_settings"
+)
+
+type roleSettingsRepo struct {
+	table string
+	db    *postgres.PostgresDB
+}
+
+func NewRoleSettingsRepo(db *postgres.PostgresDB) repository.RoleSettings {
+	return &roleSettingsRepo{
+		table: tableRoleSettings,
+		db:    db,
+	}
+}
+
+func (r roleSettingsRepo) Get(ctx context.Context, role string) (*entity.RoleSettings, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"role",
+		"mfa_required",
+		"updated_at",
+	).From(r.table).Where(r.db.Sq.Equal("role", role))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" Get")
+	}
+
+	var settings entity.RoleSettings
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&settings.Role,
+		&settings.MFARequired,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+
+	return &settings, nil
+}
+
+func (r roleSettingsRepo) List(ctx context.Context) ([]*entity.RoleSettings, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"role",
+		"mfa_required",
+		"updated_at",
+	).From(r.table).OrderBy("role")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	var settings []*entity.RoleSettings
+	for rows.Next() {
+		var setting entity.RoleSettings
+		if err := rows.Scan(
+			&setting.Role,
+			&setting.MFARequired,
+			&setting.UpdatedAt,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		settings = append(settings, &setting)
+	}
+
+	return settings, nil
+}
+
+func (r roleSettingsRepo) Upsert(ctx context.Context, req *entity.RoleSettings) error {
+	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+		map[string]interface{}{
+			"role":         req.Role,
+			"mfa_required": req.MFARequired,
+			"updated_at":   req.UpdatedAt,
+		},
+	).Suffix("ON CONFLICT (role) DO UPDATE SET mfa_required = EXCLUDED.mfa_required, updated_at = EXCLUDED.updated_at")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Upsert")
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
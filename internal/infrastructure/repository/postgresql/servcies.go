@@ -2,11 +2,15 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 var (
@@ -14,57 +18,86 @@ var (
 )
 
 type servicesRepo struct {
-	table string
-	db    *postgres.PostgresDB
+	table  string
+	db     *postgres.PostgresDB
+	prices servicePricesRepo
 }
 
 func NewServicesRepo(db *postgres.PostgresDB) repository.Services {
 	return &servicesRepo{
 		table: tableServices,
 		db:    db,
+		prices: servicePricesRepo{
+			table: tableServicePrices,
+			db:    db,
+		},
 	}
 }
 
+// Create inserts the service with its price as the first of history, in effect from CreatedAt
 func (r servicesRepo) Create(ctx context.Context, req *entity.Services) error {
-	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
+	serviceBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
 		map[string]interface{}{
 			"guid":       req.GUID,
 			"group_id":   req.GroupID,
 			"name":       req.Name,
-			"price":      req.Price,
 			"created_at": req.CreatedAt,
 		},
 	)
 
-	query, args, err := queryBuilder.ToSql()
+	serviceQuery, serviceArgs, err := serviceBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Create")
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
+	priceID := uuid.New().String()
+	priceBuilder := r.db.Sq.Builder.Insert(tableServicePrices).SetMap(
+		map[string]interface{}{
+			"guid":       priceID,
+			"service_id": req.GUID,
+			"currency":   req.Currency,
+			"valid_from": req.CreatedAt,
+			"created_at": req.CreatedAt,
+		},
+	)
+
+	priceQuery, priceArgs, err := priceBuilder.ToSql()
 	if err != nil {
-		return r.db.Error(err)
+		return r.db.ErrSQLBuild(err, tableServicePrices+" Create")
 	}
 
-	return nil
-}
+	return r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, serviceQuery, serviceArgs...); err != nil {
+			return r.db.Error(err)
+		}
 
-func (r servicesRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Services, error) {
-	queryBuilder := r.db.Sq.Builder.Select(
-		"guid",
-		"group_id",
-		"name",
-		"price",
-		"created_at",
-	).From(r.table).OrderBy("created_at asc")
-
-	for k, v := range filter {
-		switch k {
-		case "group_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
+		if _, err := tx.Exec(ctx, priceQuery, priceArgs...); err != nil {
+			return r.db.Error(err)
 		}
+
+		return r.prices.insertTiers(ctx, tx, priceID, req.Prices)
+	})
+}
+
+func (r servicesRepo) List(ctx context.Context, filter entity.ServicesFilter, at time.Time) ([]*entity.Services, error) {
+	if at.IsZero() {
+		at = time.Now()
 	}
 
+	queryBuilder := r.db.Sq.Builder.Select(
+		r.table+".guid",
+		r.table+".group_id",
+		r.table+".name",
+		tableServicePrices+".guid",
+		tableServicePrices+".currency",
+		r.table+".created_at",
+	).
+		From(r.table).
+		Join(tableServicePrices + " ON " + tableServicePrices + ".service_id = " + r.table + ".guid").
+		Where(r.where(filter)).
+		Where(r.db.Sq.Effective(tableServicePrices+".valid_from", tableServicePrices+".valid_to", at)).
+		OrderBy(r.table + ".created_at asc")
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return nil, r.db.ErrSQLBuild(err, r.table+" List")
@@ -74,21 +107,35 @@ func (r servicesRepo) List(ctx context.Context, filter map[string]string) ([]*en
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var services []*entity.Services
+	var priceIDs []string
 	for rows.Next() {
 		var service entity.Services
+		var priceID string
 		if err := rows.Scan(
 			&service.GUID,
 			&service.GroupID,
 			&service.Name,
-			&service.Price,
+			&priceID,
+			&service.Currency,
 			&service.CreatedAt,
 		); err != nil {
 			return nil, r.db.Error(err)
 		}
 
 		services = append(services, &service)
+		priceIDs = append(priceIDs, priceID)
+	}
+
+	tiers, err := r.prices.listTiers(ctx, priceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range services {
+		v.Prices = tiers[priceIDs[i]]
 	}
 
 	return services, nil
@@ -97,8 +144,7 @@ func (r servicesRepo) List(ctx context.Context, filter map[string]string) ([]*en
 func (r servicesRepo) Update(ctx context.Context, req *entity.Services) error {
 	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
 		map[string]interface{}{
-			"name":  req.Name,
-			"price": req.Price,
+			"name": req.Name,
 		},
 	).Where(r.db.Sq.Equal("guid", req.GUID))
 
@@ -113,24 +159,19 @@ func (r servicesRepo) Update(ctx context.Context, req *entity.Services) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r servicesRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "guid":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		case "group_id":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
+func (r servicesRepo) Delete(ctx context.Context, filter entity.ServicesFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
 	}
 
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Delete")
@@ -142,8 +183,27 @@ func (r servicesRepo) Delete(ctx context.Context, filter map[string]string) erro
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter, columns are qualified for List joining prices
+func (r servicesRepo) where(filter entity.ServicesFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal(r.table+".guid", filter.GUIDs))
+	}
+	if len(filter.GroupIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal(r.table+".group_id", filter.GroupIDs))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains(r.table+".name", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range(r.table+".created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
@@ -2,12 +2,13 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -48,12 +49,12 @@ func (r serviceGroupsRepo) Create(ctx context.Context, req *entity.ServiceGroups
 	return nil
 }
 
-func (r serviceGroupsRepo) List(ctx context.Context, filter map[string]string) ([]*entity.ServiceGroups, error) {
+func (r serviceGroupsRepo) List(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"name",
 		"created_at",
-	).From(r.table).OrderBy("created_at asc")
+	).From(r.table).Where(r.where(filter)).OrderBy("created_at asc")
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -64,6 +65,7 @@ func (r serviceGroupsRepo) List(ctx context.Context, filter map[string]string) (
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var groups []*entity.ServiceGroups
 	for rows.Next() {
@@ -100,7 +102,7 @@ func (r serviceGroupsRepo) Update(ctx context.Context, req *entity.ServiceGroups
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
@@ -120,8 +122,24 @@ func (r serviceGroupsRepo) Delete(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r serviceGroupsRepo) where(filter entity.ServiceGroupsFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Contains("name", filter.Search))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
@@ -0,0 +1,305 @@
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+)
+
+var (
+	tableServicePrices     = "service_prices"
+	tableServicePriceTiers = "service_price_tiers"
+)
+
+type servicePricesRepo struct {
+	table string
+	db    *postgres.PostgresDB
+}
+
+func NewServicePricesRepo(db *postgres.PostgresDB) repository.ServicePrices {
+	return &servicePricesRepo{
+		table: tableServicePrices,
+		db:    db,
+	}
+}
+
+func (r servicePricesRepo) Create(ctx context.Context, req *entity.ServicePrices) error {
+	return r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := r.lockService(ctx, tx, req.ServiceID); err != nil {
+			return err
+		}
+
+		// the next price bounds the new one
+		nextQuery, nextArgs, err := r.db.Sq.Builder.Select("min(valid_from)").From(r.table).Where(sq.And{
+			r.db.Sq.Equal("service_id", req.ServiceID),
+			r.db.Sq.Gt("valid_from", req.ValidFrom),
+		}).ToSql()
+		if err != nil {
+			return r.db.ErrSQLBuild(err, r.table+" Create")
+		}
+
+		var validTo *time.Time
+		if err := tx.QueryRow(ctx, nextQuery, nextArgs...).Scan(&validTo); err != nil {
+			return r.db.Error(err)
+		}
+
+		// the price in effect at the start ends there
+		endQuery, endArgs, err := r.db.Sq.Builder.Update(r.table).Set("valid_to", req.ValidFrom).Where(sq.And{
+			r.db.Sq.Equal("service_id", req.ServiceID),
+			r.db.Sq.Lt("valid_from", req.ValidFrom),
+			r.db.Sq.Or(r.db.Sq.Equal("valid_to", nil), r.db.Sq.Gt("valid_to", req.ValidFrom)),
+		}).ToSql()
+		if err != nil {
+			return r.db.ErrSQLBuild(err, r.table+" Create")
+		}
+
+		if _, err := tx.Exec(ctx, endQuery, endArgs...); err != nil {
+			return r.db.Error(err)
+		}
+
+		insertQuery, insertArgs, err := r.db.Sq.Builder.Insert(r.table).SetMap(
+			map[string]interface{}{
+				"guid":       req.GUID,
+				"service_id": req.ServiceID,
+				"currency":   req.Currency,
+				"valid_from": req.ValidFrom,
+				"valid_to":   validTo,
+				"created_at": req.CreatedAt,
+			},
+		).ToSql()
+		if err != nil {
+			return r.db.ErrSQLBuild(err, r.table+" Create")
+		}
+
+		// a price starting at the same time violates the unique index, the transaction rolls back ending the one in effect
+		if _, err := tx.Exec(ctx, insertQuery, insertArgs...); err != nil {
+			return r.db.Error(err)
+		}
+
+		if err := r.insertTiers(ctx, tx, req.GUID, req.Prices); err != nil {
+			return err
+		}
+
+		req.ValidTo = validTo
+		return nil
+	})
+}
+
+func (r servicePricesRepo) List(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error) {
+	queryBuilder := r.db.Sq.Builder.Select(
+		"guid",
+		"service_id",
+		"currency",
+		"valid_from",
+		"valid_to",
+		"created_at",
+	).From(r.table).Where(r.where(filter)).OrderBy("valid_from asc")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, r.table+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	var prices []*entity.ServicePrices
+	for rows.Next() {
+		var price entity.ServicePrices
+		if err := rows.Scan(
+			&price.GUID,
+			&price.ServiceID,
+			&price.Currency,
+			&price.ValidFrom,
+			&price.ValidTo,
+			&price.CreatedAt,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		prices = append(prices, &price)
+	}
+
+	var ids []string
+	for _, v := range prices {
+		ids = append(ids, v.GUID)
+	}
+
+	tiers, err := r.listTiers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range prices {
+		v.Prices = tiers[v.GUID]
+	}
+
+	return prices, nil
+}
+
+func (r servicePricesRepo) Delete(ctx context.Context, id string) error {
+	serviceQuery, serviceArgs, err := r.db.Sq.Builder.Select("service_id").From(r.table).
+		Where(r.db.Sq.Equal("guid", id)).ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" Delete")
+	}
+
+	var serviceID string
+	if err := r.db.QueryRow(ctx, serviceQuery, serviceArgs...).Scan(&serviceID); err != nil {
+		if err == pgx.ErrNoRows {
+			return errorspkg.ErrorNotFound
+		}
+		return r.db.Error(err)
+	}
+
+	return r.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := r.lockService(ctx, tx, serviceID); err != nil {
+			return err
+		}
+
+		// the period is read again under the lock, history could change before it was taken
+		deleteQuery, deleteArgs, err := r.db.Sq.Builder.Delete(r.table).Where(r.db.Sq.Equal("guid", id)).
+			Suffix("RETURNING valid_from, valid_to").ToSql()
+		if err != nil {
+			return r.db.ErrSQLBuild(err, r.table+" Delete")
+		}
+
+		var validFrom time.Time
+		var validTo *time.Time
+		if err := tx.QueryRow(ctx, deleteQuery, deleteArgs...).Scan(&validFrom, &validTo); err != nil {
+			if err == pgx.ErrNoRows {
+				return errorspkg.ErrorNotFound
+			}
+			return r.db.Error(err)
+		}
+
+		extendQuery, extendArgs, err := r.db.Sq.Builder.Update(r.table).Set("valid_to", validTo).Where(sq.And{
+			r.db.Sq.Equal("service_id", serviceID),
+			r.db.Sq.Equal("valid_to", validFrom),
+		}).ToSql()
+		if err != nil {
+			return r.db.ErrSQLBuild(err, r.table+" Delete")
+		}
+
+		if _, err := tx.Exec(ctx, extendQuery, extendArgs...); err != nil {
+			return r.db.Error(err)
+		}
+
+		return nil
+	})
+}
+
+// insertTiers inserts amounts of the price in its tiers
+func (r servicePricesRepo) insertTiers(ctx context.Context, tx pgx.Tx, priceID string, prices []entity.TierPrice) error {
+	if len(prices) == 0 {
+		return nil
+	}
+
+	queryBuilder := r.db.Sq.Builder.Insert(tableServicePriceTiers).Columns("service_price_id", "tier", "amount", "is_from")
+	for _, v := range prices {
+		queryBuilder = queryBuilder.Values(priceID, v.Tier, v.Amount, v.From)
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, tableServicePriceTiers+" Create")
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
+// listTiers returns amounts of the prices by guid of the price, ordered by position of their tiers
+func (r servicePricesRepo) listTiers(ctx context.Context, priceIDs []string) (map[string][]entity.TierPrice, error) {
+	tiers := make(map[string][]entity.TierPrice, len(priceIDs))
+	if len(priceIDs) == 0 {
+		return tiers, nil
+	}
+
+	queryBuilder := r.db.Sq.Builder.Select(
+		tableServicePriceTiers+".service_price_id",
+		tableServicePriceTiers+".tier",
+		tableServicePriceTiers+".amount",
+		tableServicePriceTiers+".is_from",
+	).
+		From(tableServicePriceTiers).
+		Join(tablePriceTiers+" ON "+tablePriceTiers+".code = "+tableServicePriceTiers+".tier").
+		Where(r.db.Sq.Equal(tableServicePriceTiers+".service_price_id", priceIDs)).
+		OrderBy(tablePriceTiers+".position asc", tablePriceTiers+".code asc")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, r.db.ErrSQLBuild(err, tableServicePriceTiers+" List")
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, r.db.Error(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var priceID string
+		var price entity.TierPrice
+		if err := rows.Scan(
+			&priceID,
+			&price.Tier,
+			&price.Amount,
+			&price.From,
+		); err != nil {
+			return nil, r.db.Error(err)
+		}
+
+		tiers[priceID] = append(tiers[priceID], price)
+	}
+
+	return tiers, nil
+}
+
+// lockService makes changes of history of one service wait for each other
+func (r servicePricesRepo) lockService(ctx context.Context, tx pgx.Tx, serviceID string) error {
+	query, args, err := r.db.Sq.Builder.Select("guid").From(tableServices).
+		Where(r.db.Sq.Equal("guid", serviceID)).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, tableServices+" lock")
+	}
+
+	var guid string
+	if err := tx.QueryRow(ctx, query, args...).Scan(&guid); err != nil {
+		return r.db.Error(err)
+	}
+
+	return nil
+}
+
+// where matches every set field of filter
+func (r servicePricesRepo) where(filter entity.ServicePricesFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.ServiceIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("service_id", filter.ServiceIDs))
+	}
+	if len(filter.Tiers) > 0 {
+		tiers := sq.Select("service_price_id").From(tableServicePriceTiers).Where(r.db.Sq.Equal("tier", filter.Tiers))
+		cond = append(cond, sq.Expr("guid IN (?)", tiers))
+	}
+	if !filter.At.IsZero() {
+		cond = append(cond, r.db.Sq.Effective("valid_from", "valid_to", filter.At))
+	}
+
+	return cond
+}
@@ -2,11 +2,12 @@ package postgresql
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	sq "github.com/Masterminds/squirrel"
 )
 
 var (
@@ -25,7 +26,11 @@ func NewUsersRepo(db *postgres.PostgresDB) repository.Users {
 	}
 }
 
-func (r usersRepo) Get(ctx context.Context, filter map[string]string) (*entity.Users, error) {
+func (r usersRepo) Get(ctx context.Context, filter entity.UsersFilter) (*entity.Users, error) {
+	if filter.IsEmpty() {
+		return nil, errorspkg.ErrorEmptyFilter
+	}
+
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"role",
@@ -33,16 +38,16 @@ func (r usersRepo) Get(ctx context.Context, filter map[string]string) (*entity.U
 		"lastname",
 		"username",
 		"password",
+		"must_change_password",
+		"password_expires_at",
+		"totp_secret",
+		"totp_enabled",
+		"totp_last_step",
+		"recovery_codes",
+		"sso_subject",
 		"created_at",
 		"updated_at",
-	).From(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "guid", "firstname", "lastname", "username":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
-	}
+	).From(r.table).Where(r.where(filter))
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -57,6 +62,13 @@ func (r usersRepo) Get(ctx context.Context, filter map[string]string) (*entity.U
 		&user.Lastname,
 		&user.Username,
 		&user.Password,
+		&user.MustChangePassword,
+		&user.PasswordExpiresAt,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
+		&user.TOTPLastStep,
+		&user.RecoveryCodes,
+		&user.SSOSubject,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -70,14 +82,21 @@ func (r usersRepo) Get(ctx context.Context, filter map[string]string) (*entity.U
 func (r usersRepo) Create(ctx context.Context, req *entity.Users) error {
 	queryBuilder := r.db.Sq.Builder.Insert(r.table).SetMap(
 		map[string]interface{}{
-			"guid":       req.GUID,
-			"role":       req.Role,
-			"firstname":  req.Firstname,
-			"lastname":   req.Lastname,
-			"username":   req.Username,
-			"password":   req.Password,
-			"created_at": req.CreatedAt,
-			"updated_at": req.UpdatedAt,
+			"guid":                 req.GUID,
+			"role":                 req.Role,
+			"firstname":            req.Firstname,
+			"lastname":             req.Lastname,
+			"username":             req.Username,
+			"password":             req.Password,
+			"must_change_password": req.MustChangePassword,
+			"password_expires_at":  req.PasswordExpiresAt,
+			"totp_secret":          req.TOTPSecret,
+			"totp_enabled":         req.TOTPEnabled,
+			"totp_last_step":       req.TOTPLastStep,
+			"recovery_codes":       recoveryCodes(req.RecoveryCodes),
+			"sso_subject":          req.SSOSubject,
+			"created_at":           req.CreatedAt,
+			"updated_at":           req.UpdatedAt,
 		},
 	)
 
@@ -94,7 +113,7 @@ func (r usersRepo) Create(ctx context.Context, req *entity.Users) error {
 	return nil
 }
 
-func (r usersRepo) List(ctx context.Context, filter map[string]string) ([]*entity.Users, error) {
+func (r usersRepo) List(ctx context.Context, filter entity.UsersFilter) ([]*entity.Users, error) {
 	queryBuilder := r.db.Sq.Builder.Select(
 		"guid",
 		"role",
@@ -102,16 +121,16 @@ func (r usersRepo) List(ctx context.Context, filter map[string]string) ([]*entit
 		"lastname",
 		"username",
 		"password",
+		"must_change_password",
+		"password_expires_at",
+		"totp_secret",
+		"totp_enabled",
+		"totp_last_step",
+		"recovery_codes",
+		"sso_subject",
 		"created_at",
 		"updated_at",
-	).From(r.table)
-
-	for k, v := range filter {
-		switch k {
-		case "role":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
-	}
+	).From(r.table).Where(r.where(filter))
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -122,6 +141,7 @@ func (r usersRepo) List(ctx context.Context, filter map[string]string) ([]*entit
 	if err != nil {
 		return nil, r.db.Error(err)
 	}
+	defer rows.Close()
 
 	var users []*entity.Users
 	for rows.Next() {
@@ -133,6 +153,13 @@ func (r usersRepo) List(ctx context.Context, filter map[string]string) ([]*entit
 			&user.Lastname,
 			&user.Username,
 			&user.Password,
+			&user.MustChangePassword,
+			&user.PasswordExpiresAt,
+			&user.TOTPSecret,
+			&user.TOTPEnabled,
+			&user.TOTPLastStep,
+			&user.RecoveryCodes,
+			&user.SSOSubject,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 		); err != nil {
@@ -148,12 +175,18 @@ func (r usersRepo) List(ctx context.Context, filter map[string]string) ([]*entit
 func (r usersRepo) Update(ctx context.Context, req *entity.Users) error {
 	queryBuilder := r.db.Sq.Builder.Update(r.table).SetMap(
 		map[string]interface{}{
-			"role":       req.Role,
-			"firstname":  req.Firstname,
-			"lastname":   req.Lastname,
-			"username":   req.Username,
-			"password":   req.Password,
-			"updated_at": req.UpdatedAt,
+			"role":                 req.Role,
+			"firstname":            req.Firstname,
+			"lastname":             req.Lastname,
+			"username":             req.Username,
+			"password":             req.Password,
+			"must_change_password": req.MustChangePassword,
+			"password_expires_at":  req.PasswordExpiresAt,
+			"totp_secret":          req.TOTPSecret,
+			"totp_enabled":         req.TOTPEnabled,
+			"recovery_codes":       recoveryCodes(req.RecoveryCodes),
+			"sso_subject":          req.SSOSubject,
+			"updated_at":           req.UpdatedAt,
 		},
 	).Where(r.db.Sq.Equal("guid", req.GUID))
 
@@ -168,24 +201,67 @@ func (r usersRepo) Update(ctx context.Context, req *entity.Users) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
 
-func (r usersRepo) Delete(ctx context.Context, filter map[string]string) error {
-	queryBuilder := r.db.Sq.Builder.Delete(r.table)
+// UseTOTPStep moves totp_last_step forward in a single statement, so concurrent requests can't accept the same code twice
+func (r usersRepo) UseTOTPStep(ctx context.Context, guid string, step int64) error {
+	queryBuilder := r.db.Sq.Builder.Update(r.table).
+		Set("totp_last_step", step).
+		Where(r.db.Sq.Equal("guid", guid)).
+		Where(r.db.Sq.Lt("totp_last_step", step))
 
-	for k, v := range filter {
-		switch k {
-		case "guid":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		case "username":
-			queryBuilder = queryBuilder.Where(r.db.Sq.Equal(k, v))
-		}
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" UseTOTPStep")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorConflict
+	}
+
+	return nil
+}
+
+// UseRecoveryCode removes the code in a single statement, so concurrent requests can't accept the same code twice
+func (r usersRepo) UseRecoveryCode(ctx context.Context, guid, hash string) error {
+	queryBuilder := r.db.Sq.Builder.Update(r.table).
+		Set("recovery_codes", sq.Expr("array_remove(recovery_codes, ?)", hash)).
+		Where(r.db.Sq.Equal("guid", guid)).
+		Where(sq.Expr("? = ANY(recovery_codes)", hash))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return r.db.ErrSQLBuild(err, r.table+" UseRecoveryCode")
+	}
+
+	commandTag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return r.db.Error(err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errorspkg.ErrorConflict
 	}
 
+	return nil
+}
+
+func (r usersRepo) Delete(ctx context.Context, filter entity.UsersFilter) error {
+	if filter.IsEmpty() {
+		return errorspkg.ErrorEmptyFilter
+	}
+
+	queryBuilder := r.db.Sq.Builder.Delete(r.table).Where(r.where(filter))
+
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
 		return r.db.ErrSQLBuild(err, r.table+" Delete")
@@ -197,8 +273,52 @@ func (r usersRepo) Delete(ctx context.Context, filter map[string]string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return r.db.Error(fmt.Errorf("no sql rows"))
+		return errorspkg.ErrorNotFound
 	}
 
 	return nil
 }
+
+// where matches every set field of filter
+func (r usersRepo) where(filter entity.UsersFilter) sq.And {
+	var cond sq.And
+	if len(filter.GUIDs) > 0 {
+		cond = append(cond, r.db.Sq.Equal("guid", filter.GUIDs))
+	}
+	if len(filter.Roles) > 0 {
+		cond = append(cond, r.db.Sq.Equal("role", filter.Roles))
+	}
+	if filter.Firstname != "" {
+		cond = append(cond, r.db.Sq.Equal("firstname", filter.Firstname))
+	}
+	if filter.Lastname != "" {
+		cond = append(cond, r.db.Sq.Equal("lastname", filter.Lastname))
+	}
+	if filter.Username != "" {
+		cond = append(cond, r.db.Sq.Equal("username", filter.Username))
+	}
+	if filter.SSOSubject != "" {
+		cond = append(cond, r.db.Sq.Equal("sso_subject", filter.SSOSubject))
+	}
+	if filter.Search != "" {
+		cond = append(cond, r.db.Sq.Or(
+			r.db.Sq.Contains("firstname", filter.Search),
+			r.db.Sq.Contains("lastname", filter.Search),
+			r.db.Sq.Contains("username", filter.Search),
+		))
+	}
+	if !filter.CreatedAt.IsZero() {
+		cond = append(cond, r.db.Sq.Range("created_at", filter.CreatedAt.From, filter.CreatedAt.To))
+	}
+
+	return cond
+}
+
+// recoveryCodes keeps recovery_codes column not null when user has no codes
+func recoveryCodes(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+
+	return codes
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+type PriceTiers interface {
+	Create(ctx context.Context, req *entity.PriceTiers) error
+	// List orders tiers by position, then by code
+	List(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error)
+	// Update changes name and position
+	Update(ctx context.Context, req *entity.PriceTiers) error
+	// Delete fails while prices of the tier exist
+	Delete(ctx context.Context, id string) error
+}
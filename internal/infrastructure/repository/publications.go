@@ -8,7 +8,8 @@ import (
 
 type Publications interface {
 	Create(ctx context.Context, req *entity.Publications) error
-	List(ctx context.Context, filter map[string]string) ([]*entity.Publications, error)
+	List(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error)
 	Update(ctx context.Context, req *entity.Publications) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.PublicationsFilter) error
 }
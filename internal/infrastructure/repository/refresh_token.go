@@ -10,4 +10,5 @@ type RefreshTokenRepo interface {
 	Get(ctx context.Context, refreshToken string) (*entity.RefreshToken, error)
 	Create(ctx context.Context, m *entity.RefreshToken) error
 	Delete(ctx context.Context, refreshToken string) error
+	DeleteByUserID(ctx context.Context, userID string) error
 }
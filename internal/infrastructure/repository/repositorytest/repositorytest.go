@@ -0,0 +1,1224 @@
+// Package repositorytest is the contract of repository interfaces shared by their implementations.
+//
+// Postgres and in-memory repositories run the same tests, so a fake used by usecase tests can't drift
+// from the database. Times are second precision UTC, Postgres doesn't keep more for every column.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/google/uuid"
+)
+
+// Repositories of one database, they see each other's rows
+type Repositories struct {
+	APIKeys       repository.APIKeys
+	Articles      repository.Articles
+	Authors       repository.Authors
+	Categories    repository.Categories
+	Chapters      repository.Chapters
+	Dentists      repository.Denstists
+	ExchangeRates repository.ExchangeRates
+	PriceTiers    repository.PriceTiers
+	Publications  repository.Publications
+	RefreshTokens repository.RefreshTokenRepo
+	RoleSettings  repository.RoleSettings
+	ServiceGroups repository.ServiceGroups
+	Services      repository.Services
+	ServicePrices repository.ServicePrices
+	Users         repository.Users
+}
+
+// Run tests every repository, newRepositories returns repositories of an empty database for each test
+func Run(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repositories)
+	}{
+		{"APIKeys", testAPIKeys},
+		{"Articles", testArticles},
+		{"Authors", testAuthors},
+		{"Categories", testCategories},
+		{"Chapters", testChapters},
+		{"Dentists", testDentists},
+		{"ExchangeRates", testExchangeRates},
+		{"PriceTiers", testPriceTiers},
+		{"Publications", testPublications},
+		{"RefreshTokens", testRefreshTokens},
+		{"RoleSettings", testRoleSettings},
+		{"ServiceGroups", testServiceGroups},
+		{"Services", testServices},
+		{"ServicePrices", testServicePrices},
+		{"Users", testUsers},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepositories(t))
+		})
+	}
+}
+
+// base is creation time of rows, tests add seconds to order them
+var base = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return base.Add(time.Duration(seconds) * time.Second)
+}
+
+func testAPIKeys(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	expires := at(3600)
+	older := &entity.APIKeys{
+		GUID:      uuid.NewString(),
+		Name:      "website",
+		Prefix:    "older",
+		KeyHash:   "hash-older",
+		Role:      entity.RoleWebsite,
+		Scopes:    []string{entity.ScopeRead},
+		CreatedBy: uuid.NewString(),
+		ExpiresAt: &expires,
+		CreatedAt: at(0),
+		UpdatedAt: at(0),
+	}
+	newer := &entity.APIKeys{
+		GUID:      uuid.NewString(),
+		Name:      "integration",
+		Prefix:    "newer",
+		KeyHash:   "hash-newer",
+		Role:      entity.RoleSecretary,
+		Scopes:    []string{entity.ScopeRead, entity.ScopeWrite},
+		CreatedAt: at(1),
+		UpdatedAt: at(1),
+	}
+	for _, v := range []*entity.APIKeys{older, newer} {
+		mustNoError(t, "create", r.APIKeys.Create(ctx, v))
+	}
+
+	duplicate := *newer
+	duplicate.GUID = uuid.NewString()
+	mustBeError(t, "create with taken prefix", r.APIKeys.Create(ctx, &duplicate), errorspkg.ErrorConflict)
+
+	got, err := r.APIKeys.Get(ctx, entity.APIKeysFilter{Prefix: "older"})
+	mustNoError(t, "get by prefix", err)
+	assertEqual(t, "get by prefix", got, older)
+
+	got, err = r.APIKeys.Get(ctx, entity.APIKeysFilter{GUIDs: []string{newer.GUID}})
+	mustNoError(t, "get by guid", err)
+	assertEqual(t, "get by guid", got, newer)
+
+	_, err = r.APIKeys.Get(ctx, entity.APIKeysFilter{Prefix: "missing"})
+	mustBeError(t, "get missing", err, errorspkg.ErrorNotFound)
+
+	_, err = r.APIKeys.Get(ctx, entity.APIKeysFilter{})
+	mustBeError(t, "get without criteria", err, errorspkg.ErrorEmptyFilter)
+
+	list, err := r.APIKeys.List(ctx, entity.APIKeysFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list newest first", apiKeyGUIDs(list), []string{newer.GUID, older.GUID})
+
+	list, err = r.APIKeys.List(ctx, entity.APIKeysFilter{Roles: []string{entity.RoleWebsite}})
+	mustNoError(t, "list by role", err)
+	assertEqual(t, "list by role", apiKeyGUIDs(list), []string{older.GUID})
+
+	list, err = r.APIKeys.List(ctx, entity.APIKeysFilter{Roles: []string{entity.RoleWebsite, entity.RoleSecretary}})
+	mustNoError(t, "list by roles", err)
+	assertEqual(t, "list by roles", apiKeyGUIDs(list), []string{newer.GUID, older.GUID})
+
+	list, err = r.APIKeys.List(ctx, entity.APIKeysFilter{CreatedAt: entity.TimeRange{From: at(1)}})
+	mustNoError(t, "list created since", err)
+	assertEqual(t, "list created since", apiKeyGUIDs(list), []string{newer.GUID})
+
+	revoked, used := at(60), at(30)
+	older.Name = "old website"
+	older.Scopes = []string{}
+	older.LastUsedAt = &used
+	older.RevokedAt = &revoked
+	older.UpdatedAt = at(60)
+	mustNoError(t, "update", r.APIKeys.Update(ctx, older))
+
+	got, err = r.APIKeys.Get(ctx, entity.APIKeysFilter{GUIDs: []string{older.GUID}})
+	mustNoError(t, "get updated", err)
+	assertEqual(t, "get updated", got, older)
+
+	isRevoked, isActive := true, false
+	list, err = r.APIKeys.List(ctx, entity.APIKeysFilter{Revoked: &isRevoked})
+	mustNoError(t, "list revoked", err)
+	assertEqual(t, "list revoked", apiKeyGUIDs(list), []string{older.GUID})
+
+	list, err = r.APIKeys.List(ctx, entity.APIKeysFilter{Revoked: &isActive})
+	mustNoError(t, "list active", err)
+	assertEqual(t, "list active", apiKeyGUIDs(list), []string{newer.GUID})
+
+	missing := *newer
+	missing.GUID = uuid.NewString()
+	missing.Prefix = "missing"
+	mustBeError(t, "update missing", r.APIKeys.Update(ctx, &missing), errorspkg.ErrorNotFound)
+}
+
+func testArticles(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	mustFail(t, "create in missing chapter", r.Articles.Create(ctx, &entity.Articles{
+		GUID:      uuid.NewString(),
+		ChapterID: uuid.NewString(),
+		CreatedAt: at(0),
+	}))
+
+	first := &entity.Chapters{GUID: uuid.NewString(), Title: "Implants", CreatedAt: at(0)}
+	second := &entity.Chapters{GUID: uuid.NewString(), Title: "Whitening", CreatedAt: at(1)}
+	for _, v := range []*entity.Chapters{first, second} {
+		mustNoError(t, "create chapter", r.Chapters.Create(ctx, v))
+	}
+
+	// inserted out of order, list sorts by creation time
+	later := &entity.Articles{GUID: uuid.NewString(), ChapterID: first.GUID, Info: "after", Img: "after.png", Side: "right", CreatedAt: at(2)}
+	earlier := &entity.Articles{GUID: uuid.NewString(), ChapterID: first.GUID, Info: "before", Img: "before.png", Side: "left", CreatedAt: at(1)}
+	other := &entity.Articles{GUID: uuid.NewString(), ChapterID: second.GUID, Info: "other", Side: "left", CreatedAt: at(0)}
+	for _, v := range []*entity.Articles{later, earlier, other} {
+		mustNoError(t, "create", r.Articles.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Articles.Create(ctx, other), errorspkg.ErrorConflict)
+
+	list, err := r.Articles.List(ctx, entity.ArticlesFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Articles{other, earlier, later})
+
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{ChapterIDs: []string{first.GUID}})
+	mustNoError(t, "list by chapter", err)
+	assertEqual(t, "list by chapter", list, []*entity.Articles{earlier, later})
+
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{GUIDs: []string{later.GUID, other.GUID}})
+	mustNoError(t, "list by guids", err)
+	assertEqual(t, "list by guids", list, []*entity.Articles{other, later})
+
+	// range includes its start and excludes its end
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{CreatedAt: entity.TimeRange{From: at(1), To: at(2)}})
+	mustNoError(t, "list created between", err)
+	assertEqual(t, "list created between", list, []*entity.Articles{earlier})
+
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{ChapterIDs: []string{first.GUID}, Side: "right"})
+	mustNoError(t, "list by chapter and side", err)
+	assertEqual(t, "list by chapter and side", list, []*entity.Articles{later})
+
+	update := *earlier
+	update.ChapterID = second.GUID
+	update.Info = "updated"
+	update.Img = "updated.png"
+	update.Side = "right"
+	mustNoError(t, "update", r.Articles.Update(ctx, &update))
+
+	// chapter of an article is fixed
+	update.ChapterID = first.GUID
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{ChapterIDs: []string{first.GUID}})
+	mustNoError(t, "list updated", err)
+	assertEqual(t, "list updated", list, []*entity.Articles{&update, later})
+
+	mustBeError(t, "update missing", r.Articles.Update(ctx, &entity.Articles{GUID: uuid.NewString()}), errorspkg.ErrorNotFound)
+	mustFail(t, "delete referenced chapter", r.Chapters.Delete(ctx, second.GUID))
+
+	mustNoError(t, "delete by guid", r.Articles.Delete(ctx, entity.ArticlesFilter{GUIDs: []string{later.GUID}}))
+	mustBeError(t, "delete missing", r.Articles.Delete(ctx, entity.ArticlesFilter{GUIDs: []string{later.GUID}}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Articles.Delete(ctx, entity.ArticlesFilter{}), errorspkg.ErrorEmptyFilter)
+	mustNoError(t, "delete by chapter", r.Articles.Delete(ctx, entity.ArticlesFilter{ChapterIDs: []string{second.GUID}}))
+
+	list, err = r.Articles.List(ctx, entity.ArticlesFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", articleGUIDs(list), []string{earlier.GUID})
+}
+
+func testAuthors(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	later := &entity.Authors{GUID: uuid.NewString(), Name: "Later", Img: []byte{0x89, 0x50, 0x4e, 0x47}, CreatedAt: at(1)}
+	earlier := &entity.Authors{GUID: uuid.NewString(), Name: "Earlier", Img: []byte{0x01}, CreatedAt: at(0)}
+	for _, v := range []*entity.Authors{later, earlier} {
+		mustNoError(t, "create", r.Authors.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Authors.Create(ctx, later), errorspkg.ErrorConflict)
+
+	got, err := r.Authors.Get(ctx, later.GUID)
+	mustNoError(t, "get", err)
+	assertEqual(t, "get", got, later)
+
+	_, err = r.Authors.Get(ctx, uuid.NewString())
+	mustBeError(t, "get missing", err, errorspkg.ErrorNotFound)
+
+	list, err := r.Authors.List(ctx, entity.AuthorsFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Authors{earlier, later})
+
+	list, err = r.Authors.List(ctx, entity.AuthorsFilter{Search: "LAT"})
+	mustNoError(t, "search", err)
+	assertEqual(t, "search", list, []*entity.Authors{later})
+
+	later.Name = "Renamed"
+	later.Img = []byte{0x02, 0x03}
+	mustNoError(t, "update", r.Authors.Update(ctx, later))
+
+	got, err = r.Authors.Get(ctx, later.GUID)
+	mustNoError(t, "get updated", err)
+	assertEqual(t, "get updated", got, later)
+
+	mustBeError(t, "update missing", r.Authors.Update(ctx, &entity.Authors{GUID: uuid.NewString(), Img: []byte{}}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete", r.Authors.Delete(ctx, entity.AuthorsFilter{GUIDs: []string{earlier.GUID}}))
+	mustBeError(t, "delete missing", r.Authors.Delete(ctx, entity.AuthorsFilter{GUIDs: []string{earlier.GUID}}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Authors.Delete(ctx, entity.AuthorsFilter{}), errorspkg.ErrorEmptyFilter)
+
+	list, err = r.Authors.List(ctx, entity.AuthorsFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.Authors{later})
+}
+
+func testCategories(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	later := &entity.Categories{GUID: uuid.NewString(), Title: "Later", Description: "about later", URL: "/later", CreatedAt: at(1)}
+	earlier := &entity.Categories{GUID: uuid.NewString(), Title: "Earlier", Description: "about earlier", URL: "/earlier", CreatedAt: at(0)}
+	for _, v := range []*entity.Categories{later, earlier} {
+		mustNoError(t, "create", r.Categories.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Categories.Create(ctx, later), errorspkg.ErrorConflict)
+
+	list, err := r.Categories.List(ctx, entity.CategoriesFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Categories{earlier, later})
+
+	// wildcards of search match only themselves
+	discount := &entity.Categories{GUID: uuid.NewString(), Title: "50% off_season", CreatedAt: at(2)}
+	mustNoError(t, "create with wildcards", r.Categories.Create(ctx, discount))
+	for _, search := range []string{"%", "_", "0% OFF_"} {
+		list, err = r.Categories.List(ctx, entity.CategoriesFilter{Search: search})
+		mustNoError(t, "search "+search, err)
+		assertEqual(t, "search "+search, list, []*entity.Categories{discount})
+	}
+	mustNoError(t, "delete with wildcards", r.Categories.Delete(ctx, entity.CategoriesFilter{Search: "%"}))
+
+	later.Title = "Renamed"
+	later.Description = "about renamed"
+	later.URL = "/renamed"
+	mustNoError(t, "update", r.Categories.Update(ctx, later))
+	mustBeError(t, "update missing", r.Categories.Update(ctx, &entity.Categories{GUID: uuid.NewString()}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete", r.Categories.Delete(ctx, entity.CategoriesFilter{GUIDs: []string{earlier.GUID}}))
+	mustBeError(t, "delete missing", r.Categories.Delete(ctx, entity.CategoriesFilter{GUIDs: []string{earlier.GUID}}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Categories.Delete(ctx, entity.CategoriesFilter{}), errorspkg.ErrorEmptyFilter)
+
+	list, err = r.Categories.List(ctx, entity.CategoriesFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.Categories{later})
+}
+
+func testChapters(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	later := &entity.Chapters{GUID: uuid.NewString(), Title: "Later", CreatedAt: at(1)}
+	earlier := &entity.Chapters{GUID: uuid.NewString(), Title: "Earlier", CreatedAt: at(0)}
+	for _, v := range []*entity.Chapters{later, earlier} {
+		mustNoError(t, "create", r.Chapters.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Chapters.Create(ctx, later), errorspkg.ErrorConflict)
+
+	list, err := r.Chapters.List(ctx, entity.ChaptersFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Chapters{earlier, later})
+
+	list, err = r.Chapters.List(ctx, entity.ChaptersFilter{GUIDs: []string{later.GUID}})
+	mustNoError(t, "list by guid", err)
+	assertEqual(t, "list by guid", list, []*entity.Chapters{later})
+
+	later.Title = "Renamed"
+	mustNoError(t, "update", r.Chapters.Update(ctx, later))
+	mustBeError(t, "update missing", r.Chapters.Update(ctx, &entity.Chapters{GUID: uuid.NewString()}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete", r.Chapters.Delete(ctx, earlier.GUID))
+	mustBeError(t, "delete missing", r.Chapters.Delete(ctx, earlier.GUID), errorspkg.ErrorNotFound)
+
+	list, err = r.Chapters.List(ctx, entity.ChaptersFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.Chapters{later})
+}
+
+func testDentists(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	list, err := r.Dentists.List(ctx, entity.DentistsFilter{})
+	mustNoError(t, "list empty", err)
+	if len(list) != 0 {
+		t.Fatalf("list empty: got %d dentists", len(list))
+	}
+
+	left := &entity.Dentists{CloneName: "smith", Name: "Dr. Smith", Info: "implants", URL: "/smith", Side: "left", Priority: 2}
+	right := &entity.Dentists{CloneName: "smith", Name: "Dr. Smith", Info: "implants", URL: "/smith", Side: "right", Priority: 1}
+	for _, v := range []*entity.Dentists{left, right} {
+		mustNoError(t, "create", r.Dentists.Create(ctx, v))
+	}
+	if left.ID <= 0 || right.ID <= left.ID {
+		t.Fatalf("create: ids %d and %d are not increasing", left.ID, right.ID)
+	}
+
+	got, err := r.Dentists.Get(ctx, left.ID)
+	mustNoError(t, "get", err)
+	assertEqual(t, "get", got, left)
+
+	_, err = r.Dentists.Get(ctx, right.ID+1)
+	mustBeError(t, "get missing", err, errorspkg.ErrorNotFound)
+
+	// dentists are not ordered, compare them by id
+	list, err = r.Dentists.List(ctx, entity.DentistsFilter{})
+	mustNoError(t, "list", err)
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	assertEqual(t, "list", list, []*entity.Dentists{left, right})
+
+	list, err = r.Dentists.List(ctx, entity.DentistsFilter{Side: "right"})
+	mustNoError(t, "list by side", err)
+	assertEqual(t, "list by side", list, []*entity.Dentists{right})
+
+	list, err = r.Dentists.List(ctx, entity.DentistsFilter{IDs: []int64{left.ID}, Search: "smith"})
+	mustNoError(t, "list by ids", err)
+	assertEqual(t, "list by ids", list, []*entity.Dentists{left})
+
+	update := *right
+	update.CloneName = "jones"
+	update.Side = "left"
+	update.Priority = 5
+	update.Name = "Dr. Jones"
+	update.Info = "whitening"
+	update.URL = "/jones"
+	mustNoError(t, "update", r.Dentists.Update(ctx, &update))
+
+	// clone name, side and priority are fixed
+	update.CloneName, update.Side, update.Priority = right.CloneName, right.Side, right.Priority
+	got, err = r.Dentists.Get(ctx, right.ID)
+	mustNoError(t, "get updated", err)
+	assertEqual(t, "get updated", got, &update)
+
+	mustBeError(t, "update missing", r.Dentists.Update(ctx, &entity.Dentists{ID: right.ID + 1}), errorspkg.ErrorNotFound)
+}
+
+func testExchangeRates(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	usd := &entity.ExchangeRates{GUID: uuid.NewString(), Base: "USD", Quote: "UZS", Rate: 12650.25, CreatedAt: at(0), UpdatedAt: at(0)}
+	eur := &entity.ExchangeRates{GUID: uuid.NewString(), Base: "EUR", Quote: "UZS", Rate: 13750, CreatedAt: at(1), UpdatedAt: at(1)}
+	eurUSD := &entity.ExchangeRates{GUID: uuid.NewString(), Base: "EUR", Quote: "USD", Rate: 1.0875, CreatedAt: at(2), UpdatedAt: at(2)}
+	for _, v := range []*entity.ExchangeRates{usd, eur, eurUSD} {
+		mustNoError(t, "create", r.ExchangeRates.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.ExchangeRates.Create(ctx, usd), errorspkg.ErrorConflict)
+	// a pair is unique in either direction
+	mustBeError(t, "create inverse pair", r.ExchangeRates.Create(ctx, &entity.ExchangeRates{
+		GUID:      uuid.NewString(),
+		Base:      "UZS",
+		Quote:     "USD",
+		Rate:      0.000079,
+		CreatedAt: at(3),
+		UpdatedAt: at(3),
+	}), errorspkg.ErrorConflict)
+
+	list, err := r.ExchangeRates.List(ctx, entity.ExchangeRatesFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.ExchangeRates{eurUSD, eur, usd})
+
+	list, err = r.ExchangeRates.List(ctx, entity.ExchangeRatesFilter{Currencies: []string{"USD"}})
+	mustNoError(t, "list by currency", err)
+	assertEqual(t, "list by currency", list, []*entity.ExchangeRates{eurUSD, usd})
+
+	list, err = r.ExchangeRates.List(ctx, entity.ExchangeRatesFilter{GUIDs: []string{eur.GUID}, Currencies: []string{"UZS"}})
+	mustNoError(t, "list by guid", err)
+	assertEqual(t, "list by guid", list, []*entity.ExchangeRates{eur})
+
+	update := *usd
+	update.Base = "GBP"
+	update.Rate = 12700.5
+	update.UpdatedAt = at(10)
+	mustNoError(t, "update", r.ExchangeRates.Update(ctx, &update))
+	mustBeError(t, "update missing", r.ExchangeRates.Update(ctx, &entity.ExchangeRates{GUID: uuid.NewString(), Rate: 1, UpdatedAt: at(10)}), errorspkg.ErrorNotFound)
+
+	// currencies of a rate are fixed
+	update.Base = usd.Base
+	list, err = r.ExchangeRates.List(ctx, entity.ExchangeRatesFilter{GUIDs: []string{usd.GUID}})
+	mustNoError(t, "list updated", err)
+	assertEqual(t, "list updated", list, []*entity.ExchangeRates{&update})
+
+	mustNoError(t, "delete", r.ExchangeRates.Delete(ctx, eur.GUID))
+	mustBeError(t, "delete missing", r.ExchangeRates.Delete(ctx, eur.GUID), errorspkg.ErrorNotFound)
+
+	list, err = r.ExchangeRates.List(ctx, entity.ExchangeRatesFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.ExchangeRates{eurUSD, &update})
+}
+
+func testPriceTiers(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	regular := &entity.PriceTiers{GUID: uuid.NewString(), Code: "regular", Name: "Regular", Position: 1, CreatedAt: at(0)}
+	urgent := &entity.PriceTiers{GUID: uuid.NewString(), Code: "urgent", Name: "Urgent", Position: 2, CreatedAt: at(1)}
+	child := &entity.PriceTiers{GUID: uuid.NewString(), Code: "child", Name: "Child", Position: 2, CreatedAt: at(2)}
+	for _, v := range []*entity.PriceTiers{urgent, regular, child} {
+		mustNoError(t, "create", r.PriceTiers.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.PriceTiers.Create(ctx, regular), errorspkg.ErrorConflict)
+	mustBeError(t, "create duplicate code", r.PriceTiers.Create(ctx, &entity.PriceTiers{
+		GUID:      uuid.NewString(),
+		Code:      "regular",
+		Name:      "Other",
+		CreatedAt: at(3),
+	}), errorspkg.ErrorConflict)
+
+	// equal positions are ordered by code
+	list, err := r.PriceTiers.List(ctx, entity.PriceTiersFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.PriceTiers{regular, child, urgent})
+
+	list, err = r.PriceTiers.List(ctx, entity.PriceTiersFilter{Codes: []string{"urgent", "missing"}})
+	mustNoError(t, "list by code", err)
+	assertEqual(t, "list by code", list, []*entity.PriceTiers{urgent})
+
+	list, err = r.PriceTiers.List(ctx, entity.PriceTiersFilter{GUIDs: []string{child.GUID}})
+	mustNoError(t, "list by guid", err)
+	assertEqual(t, "list by guid", list, []*entity.PriceTiers{child})
+
+	update := *child
+	update.Code = "kids"
+	update.Name = "Children"
+	update.Position = 3
+	mustNoError(t, "update", r.PriceTiers.Update(ctx, &update))
+	mustBeError(t, "update missing", r.PriceTiers.Update(ctx, &entity.PriceTiers{GUID: uuid.NewString(), Name: "Missing"}), errorspkg.ErrorNotFound)
+
+	// code of a tier is fixed
+	update.Code = child.Code
+	list, err = r.PriceTiers.List(ctx, entity.PriceTiersFilter{})
+	mustNoError(t, "list updated", err)
+	assertEqual(t, "list updated", list, []*entity.PriceTiers{regular, urgent, &update})
+
+	group := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "Group"}
+	mustNoError(t, "create group", r.ServiceGroups.Create(ctx, group))
+	mustNoError(t, "create service", r.Services.Create(ctx, &entity.Services{
+		GUID:      uuid.NewString(),
+		GroupID:   group.GUID,
+		Prices:    []entity.TierPrice{{Tier: "urgent", Amount: 100}},
+		Currency:  "UZS",
+		CreatedAt: at(0),
+	}))
+
+	mustFail(t, "delete referenced", r.PriceTiers.Delete(ctx, urgent.GUID))
+	mustNoError(t, "delete", r.PriceTiers.Delete(ctx, child.GUID))
+	mustBeError(t, "delete missing", r.PriceTiers.Delete(ctx, child.GUID), errorspkg.ErrorNotFound)
+
+	list, err = r.PriceTiers.List(ctx, entity.PriceTiersFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.PriceTiers{regular, urgent})
+}
+
+func testPublications(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	author := &entity.Authors{GUID: uuid.NewString(), Name: "Author", Img: []byte{}, CreatedAt: at(0)}
+	mustNoError(t, "create author", r.Authors.Create(ctx, author))
+	first := &entity.Categories{GUID: uuid.NewString(), Title: "First", CreatedAt: at(0)}
+	second := &entity.Categories{GUID: uuid.NewString(), Title: "Second", CreatedAt: at(1)}
+	for _, v := range []*entity.Categories{first, second} {
+		mustNoError(t, "create category", r.Categories.Create(ctx, v))
+	}
+
+	mustFail(t, "create in missing category", r.Publications.Create(ctx, &entity.Publications{
+		GUID:       uuid.NewString(),
+		CategoryID: uuid.NewString(),
+		AuthorID:   author.GUID,
+		Content:    []string{},
+		CreatedAt:  at(0),
+	}))
+	mustFail(t, "create by missing author", r.Publications.Create(ctx, &entity.Publications{
+		GUID:       uuid.NewString(),
+		CategoryID: first.GUID,
+		AuthorID:   uuid.NewString(),
+		Content:    []string{},
+		CreatedAt:  at(0),
+	}))
+
+	later := &entity.Publications{
+		GUID:        uuid.NewString(),
+		CategoryID:  first.GUID,
+		AuthorID:    author.GUID,
+		Title:       "Later",
+		Description: "video",
+		Type:        entity.PublicationTypeVideo,
+		Content:     []string{"https://example.com/video.mp4"},
+		CreatedAt:   at(2),
+	}
+	earlier := &entity.Publications{
+		GUID:        uuid.NewString(),
+		CategoryID:  first.GUID,
+		AuthorID:    author.GUID,
+		Title:       "Earlier",
+		Description: "slides",
+		Type:        entity.PublicationTypeSwiper,
+		Content:     []string{"1.png", "2.png"},
+		CreatedAt:   at(1),
+	}
+	other := &entity.Publications{
+		GUID:       uuid.NewString(),
+		CategoryID: second.GUID,
+		AuthorID:   author.GUID,
+		Title:      "Other",
+		Type:       entity.PublicationTypeVideo,
+		Content:    []string{},
+		CreatedAt:  at(0),
+	}
+	for _, v := range []*entity.Publications{later, earlier, other} {
+		mustNoError(t, "create", r.Publications.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Publications.Create(ctx, other), errorspkg.ErrorConflict)
+
+	list, err := r.Publications.List(ctx, entity.PublicationsFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Publications{other, earlier, later})
+
+	list, err = r.Publications.List(ctx, entity.PublicationsFilter{CategoryIDs: []string{first.GUID}})
+	mustNoError(t, "list by category", err)
+	assertEqual(t, "list by category", list, []*entity.Publications{earlier, later})
+
+	list, err = r.Publications.List(ctx, entity.PublicationsFilter{Type: entity.PublicationTypeVideo, Search: "e"})
+	mustNoError(t, "list videos", err)
+	assertEqual(t, "list videos", list, []*entity.Publications{other, later})
+
+	update := *later
+	update.CategoryID = second.GUID
+	update.Type = entity.PublicationTypeSwiper
+	update.Title = "Updated"
+	update.Description = "updated"
+	update.Content = []string{"https://example.com/updated.mp4"}
+	mustNoError(t, "update", r.Publications.Update(ctx, &update))
+
+	// category, author and type of a publication are fixed
+	update.CategoryID, update.Type = later.CategoryID, later.Type
+	list, err = r.Publications.List(ctx, entity.PublicationsFilter{CategoryIDs: []string{first.GUID}})
+	mustNoError(t, "list updated", err)
+	assertEqual(t, "list updated", list, []*entity.Publications{earlier, &update})
+
+	mustBeError(t, "update missing", r.Publications.Update(ctx, &entity.Publications{GUID: uuid.NewString(), Content: []string{}}), errorspkg.ErrorNotFound)
+	mustFail(t, "delete referenced category", r.Categories.Delete(ctx, entity.CategoriesFilter{GUIDs: []string{second.GUID}}))
+	mustFail(t, "delete referenced author", r.Authors.Delete(ctx, entity.AuthorsFilter{GUIDs: []string{author.GUID}}))
+
+	mustNoError(t, "delete by guid", r.Publications.Delete(ctx, entity.PublicationsFilter{GUIDs: []string{later.GUID}}))
+	mustBeError(t, "delete missing", r.Publications.Delete(ctx, entity.PublicationsFilter{GUIDs: []string{later.GUID}}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Publications.Delete(ctx, entity.PublicationsFilter{}), errorspkg.ErrorEmptyFilter)
+	mustNoError(t, "delete by category", r.Publications.Delete(ctx, entity.PublicationsFilter{CategoryIDs: []string{second.GUID}}))
+
+	list, err = r.Publications.List(ctx, entity.PublicationsFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", publicationGUIDs(list), []string{earlier.GUID})
+
+	mustNoError(t, "delete by author", r.Publications.Delete(ctx, entity.PublicationsFilter{AuthorIDs: []string{author.GUID}}))
+	mustNoError(t, "delete unreferenced author", r.Authors.Delete(ctx, entity.AuthorsFilter{GUIDs: []string{author.GUID}}))
+}
+
+func testRefreshTokens(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	userID, otherUserID := uuid.NewString(), uuid.NewString()
+	first := &entity.RefreshToken{GUID: uuid.NewString(), UserID: userID, RefreshToken: "first", ExpiryDate: at(3600), CreatedAt: at(0)}
+	second := &entity.RefreshToken{GUID: uuid.NewString(), UserID: userID, RefreshToken: "second", ExpiryDate: at(3600), CreatedAt: at(1)}
+	other := &entity.RefreshToken{GUID: uuid.NewString(), UserID: otherUserID, RefreshToken: "other", ExpiryDate: at(3600), CreatedAt: at(2)}
+	for _, v := range []*entity.RefreshToken{first, second, other} {
+		mustNoError(t, "create", r.RefreshTokens.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.RefreshTokens.Create(ctx, first), errorspkg.ErrorConflict)
+
+	got, err := r.RefreshTokens.Get(ctx, "first")
+	mustNoError(t, "get", err)
+	assertEqual(t, "get", got, first)
+
+	_, err = r.RefreshTokens.Get(ctx, "missing")
+	mustBeError(t, "get missing", err, errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete", r.RefreshTokens.Delete(ctx, "first"))
+	mustBeError(t, "delete missing", r.RefreshTokens.Delete(ctx, "first"), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete by user", r.RefreshTokens.DeleteByUserID(ctx, userID))
+	mustNoError(t, "delete by user without tokens", r.RefreshTokens.DeleteByUserID(ctx, userID))
+
+	_, err = r.RefreshTokens.Get(ctx, "second")
+	mustBeError(t, "get deleted by user", err, errorspkg.ErrorNotFound)
+
+	_, err = r.RefreshTokens.Get(ctx, "other")
+	mustNoError(t, "get token of other user", err)
+}
+
+func testRoleSettings(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	_, err := r.RoleSettings.Get(ctx, entity.RoleAdmin)
+	mustBeError(t, "get missing", err, errorspkg.ErrorNotFound)
+
+	secretary := &entity.RoleSettings{Role: entity.RoleSecretary, MFARequired: false, UpdatedAt: at(0)}
+	admin := &entity.RoleSettings{Role: entity.RoleAdmin, MFARequired: true, UpdatedAt: at(0)}
+	for _, v := range []*entity.RoleSettings{secretary, admin} {
+		mustNoError(t, "insert", r.RoleSettings.Upsert(ctx, v))
+	}
+
+	secretary.MFARequired = true
+	secretary.UpdatedAt = at(60)
+	mustNoError(t, "update", r.RoleSettings.Upsert(ctx, secretary))
+
+	got, err := r.RoleSettings.Get(ctx, entity.RoleSecretary)
+	mustNoError(t, "get", err)
+	assertEqual(t, "get", got, secretary)
+
+	list, err := r.RoleSettings.List(ctx)
+	mustNoError(t, "list", err)
+	assertEqual(t, "list by role", list, []*entity.RoleSettings{admin, secretary})
+}
+
+func testServiceGroups(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	// creation time is set by repository, so groups are listed in insertion order
+	first := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "First"}
+	mustNoError(t, "create", r.ServiceGroups.Create(ctx, first))
+	time.Sleep(10 * time.Millisecond)
+	second := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "Second"}
+	mustNoError(t, "create", r.ServiceGroups.Create(ctx, second))
+
+	mustBeError(t, "create duplicate", r.ServiceGroups.Create(ctx, first), errorspkg.ErrorConflict)
+
+	list, err := r.ServiceGroups.List(ctx, entity.ServiceGroupsFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", serviceGroupNames(list), []string{"First", "Second"})
+
+	list, err = r.ServiceGroups.List(ctx, entity.ServiceGroupsFilter{GUIDs: []string{second.GUID}})
+	mustNoError(t, "list by guid", err)
+	assertEqual(t, "list by guid", serviceGroupNames(list), []string{"Second"})
+	for _, v := range list {
+		if v.CreatedAt.IsZero() {
+			t.Errorf("list: group %s has no creation time", v.Name)
+		}
+	}
+
+	second.Name = "Renamed"
+	mustNoError(t, "update", r.ServiceGroups.Update(ctx, second))
+	mustBeError(t, "update missing", r.ServiceGroups.Update(ctx, &entity.ServiceGroups{GUID: uuid.NewString()}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "create service", r.Services.Create(ctx, &entity.Services{
+		GUID:      uuid.NewString(),
+		GroupID:   second.GUID,
+		Currency:  "UZS",
+		CreatedAt: at(0),
+	}))
+	mustFail(t, "delete referenced", r.ServiceGroups.Delete(ctx, second.GUID))
+
+	mustNoError(t, "delete", r.ServiceGroups.Delete(ctx, first.GUID))
+	mustBeError(t, "delete missing", r.ServiceGroups.Delete(ctx, first.GUID), errorspkg.ErrorNotFound)
+
+	list, err = r.ServiceGroups.List(ctx, entity.ServiceGroupsFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", serviceGroupNames(list), []string{"Renamed"})
+}
+
+func testServices(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createPriceTiers(t, r)
+
+	mustFail(t, "create in missing group", r.Services.Create(ctx, &entity.Services{
+		GUID:      uuid.NewString(),
+		GroupID:   uuid.NewString(),
+		Currency:  "UZS",
+		CreatedAt: at(0),
+	}))
+
+	first := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "First"}
+	second := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "Second"}
+	for _, v := range []*entity.ServiceGroups{first, second} {
+		mustNoError(t, "create group", r.ServiceGroups.Create(ctx, v))
+	}
+
+	later := &entity.Services{GUID: uuid.NewString(), GroupID: first.GUID, Name: "Later", Prices: tierPrices(10050, 15000), Currency: "UZS", CreatedAt: at(2)}
+	earlier := &entity.Services{GUID: uuid.NewString(), GroupID: first.GUID, Name: "Earlier", Prices: []entity.TierPrice{{Tier: "regular", Amount: 80, From: true}}, Currency: "USD", CreatedAt: at(1)}
+	other := &entity.Services{GUID: uuid.NewString(), GroupID: second.GUID, Name: "Other", Currency: "UZS", CreatedAt: at(0)}
+	for _, v := range []*entity.Services{later, earlier, other} {
+		mustNoError(t, "create", r.Services.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Services.Create(ctx, other), errorspkg.ErrorConflict)
+
+	list, err := r.Services.List(ctx, entity.ServicesFilter{}, time.Time{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.Services{other, earlier, later})
+
+	list, err = r.Services.List(ctx, entity.ServicesFilter{GroupIDs: []string{first.GUID}}, time.Time{})
+	mustNoError(t, "list by group", err)
+	assertEqual(t, "list by group", list, []*entity.Services{earlier, later})
+
+	list, err = r.Services.List(ctx, entity.ServicesFilter{GroupIDs: []string{first.GUID, second.GUID}, Search: "er"}, time.Time{})
+	mustNoError(t, "search in groups", err)
+	assertEqual(t, "search in groups", list, []*entity.Services{other, earlier, later})
+
+	update := *earlier
+	update.GroupID = second.GUID
+	update.Name = "Updated"
+	update.Prices = tierPrices(90, 120)
+	update.Currency = "UZS"
+	mustNoError(t, "update", r.Services.Update(ctx, &update))
+
+	// group of a service is fixed, prices are changed through ServicePrices
+	update.GroupID = first.GUID
+	update.Prices = earlier.Prices
+	update.Currency = earlier.Currency
+	list, err = r.Services.List(ctx, entity.ServicesFilter{GroupIDs: []string{first.GUID}}, time.Time{})
+	mustNoError(t, "list updated", err)
+	assertEqual(t, "list updated", list, []*entity.Services{&update, later})
+
+	mustBeError(t, "update missing", r.Services.Update(ctx, &entity.Services{GUID: uuid.NewString()}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete by guid", r.Services.Delete(ctx, entity.ServicesFilter{GUIDs: []string{later.GUID}}))
+	mustBeError(t, "delete missing", r.Services.Delete(ctx, entity.ServicesFilter{GUIDs: []string{later.GUID}}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Services.Delete(ctx, entity.ServicesFilter{}), errorspkg.ErrorEmptyFilter)
+	mustNoError(t, "delete by group", r.Services.Delete(ctx, entity.ServicesFilter{GroupIDs: []string{second.GUID}}))
+
+	list, err = r.Services.List(ctx, entity.ServicesFilter{}, time.Time{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", serviceGUIDs(list), []string{earlier.GUID})
+}
+
+func testServicePrices(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	createPriceTiers(t, r)
+
+	group := &entity.ServiceGroups{GUID: uuid.NewString(), Name: "Group"}
+	mustNoError(t, "create group", r.ServiceGroups.Create(ctx, group))
+
+	service := &entity.Services{GUID: uuid.NewString(), GroupID: group.GUID, Name: "Service", Prices: tierPrices(100, 150), Currency: "UZS", CreatedAt: at(0)}
+	mustNoError(t, "create service", r.Services.Create(ctx, service))
+
+	mustBeError(t, "create for missing service", r.ServicePrices.Create(ctx, &entity.ServicePrices{
+		GUID:      uuid.NewString(),
+		ServiceID: uuid.NewString(),
+		Currency:  "UZS",
+		ValidFrom: at(10),
+		CreatedAt: at(1),
+	}), errorspkg.ErrorNotFound)
+
+	initial, err := r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}})
+	mustNoError(t, "list initial", err)
+	if len(initial) != 1 {
+		t.Fatalf("list initial: got %d prices, want 1", len(initial))
+	}
+	assertEqual(t, "initial price", initial[0], &entity.ServicePrices{
+		GUID:      initial[0].GUID,
+		ServiceID: service.GUID,
+		Prices:    service.Prices,
+		Currency:  service.Currency,
+		ValidFrom: service.CreatedAt,
+		CreatedAt: service.CreatedAt,
+	})
+
+	// the later price is created first, the earlier one ends where it starts
+	later := &entity.ServicePrices{GUID: uuid.NewString(), ServiceID: service.GUID, Prices: tierPrices(120, 170), Currency: "UZS", ValidFrom: at(20), CreatedAt: at(1)}
+	mustNoError(t, "create later", r.ServicePrices.Create(ctx, later))
+	// amounts are listed in order of positions of their tiers
+	earlier := &entity.ServicePrices{GUID: uuid.NewString(), ServiceID: service.GUID, Prices: []entity.TierPrice{
+		{Tier: "urgent", Amount: 160},
+		{Tier: "regular", Amount: 110, From: true},
+	}, Currency: "USD", ValidFrom: at(10), CreatedAt: at(2)}
+	mustNoError(t, "create earlier", r.ServicePrices.Create(ctx, earlier))
+	earlier.Prices[0], earlier.Prices[1] = earlier.Prices[1], earlier.Prices[0]
+	laterFrom := later.ValidFrom
+	assertEqual(t, "valid to of created", earlier.ValidTo, &laterFrom)
+
+	mustBeError(t, "create duplicate", r.ServicePrices.Create(ctx, later), errorspkg.ErrorConflict)
+	mustFail(t, "create in missing tier", r.ServicePrices.Create(ctx, &entity.ServicePrices{
+		GUID:      uuid.NewString(),
+		ServiceID: service.GUID,
+		Prices:    []entity.TierPrice{{Tier: "missing", Amount: 1}},
+		Currency:  "UZS",
+		ValidFrom: at(30),
+		CreatedAt: at(3),
+	}))
+	mustBeError(t, "create with tier twice", r.ServicePrices.Create(ctx, &entity.ServicePrices{
+		GUID:      uuid.NewString(),
+		ServiceID: service.GUID,
+		Prices:    []entity.TierPrice{{Tier: "regular", Amount: 1}, {Tier: "regular", Amount: 2}},
+		Currency:  "UZS",
+		ValidFrom: at(30),
+		CreatedAt: at(3),
+	}), errorspkg.ErrorConflict)
+
+	first := initial[0]
+	earlierFrom := earlier.ValidFrom
+	first.ValidTo = &earlierFrom
+	list, err := r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", list, []*entity.ServicePrices{first, earlier, later})
+
+	list, err = r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}, At: at(15)})
+	mustNoError(t, "list at", err)
+	assertEqual(t, "list at", list, []*entity.ServicePrices{earlier})
+
+	list, err = r.ServicePrices.List(ctx, entity.ServicePricesFilter{GUIDs: []string{first.GUID, later.GUID}, Tiers: []string{"urgent", "child"}})
+	mustNoError(t, "list by tier", err)
+	assertEqual(t, "list by tier", list, []*entity.ServicePrices{first, later})
+
+	for _, tt := range []struct {
+		at       time.Time
+		prices   []entity.TierPrice
+		currency string
+	}{
+		{at(0), service.Prices, "UZS"},
+		{at(10), earlier.Prices, "USD"},
+		{at(19), earlier.Prices, "USD"},
+		{at(20), later.Prices, "UZS"},
+	} {
+		services, err := r.Services.List(ctx, entity.ServicesFilter{GUIDs: []string{service.GUID}}, tt.at)
+		mustNoError(t, "list services at", err)
+		if len(services) != 1 {
+			t.Fatalf("list services at %s: got %d services, want 1", tt.at, len(services))
+		}
+		assertEqual(t, "prices at "+tt.at.String(), services[0].Prices, tt.prices)
+		assertEqual(t, "currency at "+tt.at.String(), services[0].Currency, tt.currency)
+	}
+
+	services, err := r.Services.List(ctx, entity.ServicesFilter{GUIDs: []string{service.GUID}}, at(-1))
+	mustNoError(t, "list services before created", err)
+	assertEqual(t, "list services before created", serviceGUIDs(services), []string(nil))
+
+	// a price starting at the same time is not replaced
+	replace := &entity.ServicePrices{GUID: uuid.NewString(), ServiceID: service.GUID, Prices: tierPrices(125, 175), Currency: "UZS", ValidFrom: at(20), CreatedAt: at(3)}
+	mustBeError(t, "create at valid from taken", r.ServicePrices.Create(ctx, replace), errorspkg.ErrorConflict)
+	list, err = r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}})
+	mustNoError(t, "list after conflict", err)
+	assertEqual(t, "list after conflict", list, []*entity.ServicePrices{first, earlier, later})
+
+	mustNoError(t, "delete", r.ServicePrices.Delete(ctx, earlier.GUID))
+	mustBeError(t, "delete missing", r.ServicePrices.Delete(ctx, earlier.GUID), errorspkg.ErrorNotFound)
+
+	first.ValidTo = &laterFrom
+	list, err = r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", list, []*entity.ServicePrices{first, later})
+
+	mustNoError(t, "delete service", r.Services.Delete(ctx, entity.ServicesFilter{GUIDs: []string{service.GUID}}))
+
+	list, err = r.ServicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{service.GUID}})
+	mustNoError(t, "list after service delete", err)
+	assertEqual(t, "list after service delete", len(list), 0)
+}
+
+func testUsers(t *testing.T, r Repositories) {
+	ctx := context.Background()
+
+	expires := at(86400)
+	admin := &entity.Users{
+		GUID:              uuid.NewString(),
+		Role:              entity.RoleAdmin,
+		Firstname:         "Ada",
+		Lastname:          "Admin",
+		Username:          "admin",
+		Password:          "hash",
+		PasswordExpiresAt: &expires,
+		RecoveryCodes:     []string{"code-1", "code-2"},
+		CreatedAt:         at(0),
+		UpdatedAt:         at(0),
+	}
+	secretary := &entity.Users{
+		GUID:      uuid.NewString(),
+		Role:      entity.RoleSecretary,
+		Firstname: "Sam",
+		Lastname:  "Secretary",
+		Username:  "secretary",
+		Password:  "hash",
+		CreatedAt: at(1),
+		UpdatedAt: at(1),
+	}
+	sso := &entity.Users{
+		GUID:          uuid.NewString(),
+		Role:          entity.RoleSecretary,
+		Firstname:     "Sid",
+		Lastname:      "Sso",
+		Username:      "sso",
+		SSOSubject:    "https://idp.example.com|42",
+		RecoveryCodes: []string{},
+		CreatedAt:     at(2),
+		UpdatedAt:     at(2),
+	}
+	for _, v := range []*entity.Users{admin, secretary, sso} {
+		mustNoError(t, "create", r.Users.Create(ctx, v))
+	}
+
+	mustBeError(t, "create duplicate", r.Users.Create(ctx, admin), errorspkg.ErrorConflict)
+	mustBeError(t, "create with taken sso subject", r.Users.Create(ctx, &entity.Users{
+		GUID:       uuid.NewString(),
+		Username:   "sso-2",
+		SSOSubject: sso.SSOSubject,
+		CreatedAt:  at(3),
+		UpdatedAt:  at(3),
+	}), errorspkg.ErrorConflict)
+
+	got, err := r.Users.Get(ctx, entity.UsersFilter{Username: "admin"})
+	mustNoError(t, "get by username", err)
+	assertEqual(t, "get by username", got, admin)
+
+	// no recovery codes are read as empty list
+	got, err = r.Users.Get(ctx, entity.UsersFilter{GUIDs: []string{secretary.GUID}})
+	mustNoError(t, "get by guid", err)
+	want := *secretary
+	want.RecoveryCodes = []string{}
+	assertEqual(t, "get by guid", got, &want)
+
+	got, err = r.Users.Get(ctx, entity.UsersFilter{SSOSubject: sso.SSOSubject})
+	mustNoError(t, "get by sso subject", err)
+	assertEqual(t, "get by sso subject", got, sso)
+
+	_, err = r.Users.Get(ctx, entity.UsersFilter{Username: "admin", Firstname: "Sam"})
+	mustBeError(t, "get by not matching filters", err, errorspkg.ErrorNotFound)
+
+	_, err = r.Users.Get(ctx, entity.UsersFilter{})
+	mustBeError(t, "get without criteria", err, errorspkg.ErrorEmptyFilter)
+
+	// users are not ordered, compare them by username
+	list, err := r.Users.List(ctx, entity.UsersFilter{Roles: []string{entity.RoleSecretary}})
+	mustNoError(t, "list by role", err)
+	assertEqual(t, "list by role", usernames(list), []string{"secretary", "sso"})
+
+	list, err = r.Users.List(ctx, entity.UsersFilter{Roles: []string{entity.RoleAdmin, entity.RoleSecretary}, Search: "S"})
+	mustNoError(t, "search", err)
+	assertEqual(t, "search", usernames(list), []string{"secretary", "sso"})
+
+	list, err = r.Users.List(ctx, entity.UsersFilter{CreatedAt: entity.TimeRange{To: at(1)}})
+	mustNoError(t, "list created before", err)
+	assertEqual(t, "list created before", usernames(list), []string{"admin"})
+
+	list, err = r.Users.List(ctx, entity.UsersFilter{})
+	mustNoError(t, "list", err)
+	assertEqual(t, "list", usernames(list), []string{"admin", "secretary", "sso"})
+
+	admin.Firstname = "Ada Renamed"
+	admin.Password = "token"
+	admin.MustChangePassword = true
+	admin.PasswordExpiresAt = nil
+	admin.TOTPSecret = "secret"
+	admin.TOTPEnabled = true
+	admin.RecoveryCodes = []string{"code-2", "code-3"}
+	admin.SSOSubject = "https://idp.example.com|7"
+	admin.UpdatedAt = at(60)
+	mustNoError(t, "update", r.Users.Update(ctx, admin))
+
+	got, err = r.Users.Get(ctx, entity.UsersFilter{GUIDs: []string{admin.GUID}})
+	mustNoError(t, "get updated", err)
+	assertEqual(t, "get updated", got, admin)
+
+	mustNoError(t, "use totp step", r.Users.UseTOTPStep(ctx, admin.GUID, 100))
+	mustBeError(t, "use same totp step", r.Users.UseTOTPStep(ctx, admin.GUID, 100), errorspkg.ErrorConflict)
+	mustBeError(t, "use earlier totp step", r.Users.UseTOTPStep(ctx, admin.GUID, 99), errorspkg.ErrorConflict)
+	mustBeError(t, "use totp step of missing user", r.Users.UseTOTPStep(ctx, uuid.NewString(), 100), errorspkg.ErrorConflict)
+
+	mustNoError(t, "use recovery code", r.Users.UseRecoveryCode(ctx, admin.GUID, "code-2"))
+	mustBeError(t, "use same recovery code", r.Users.UseRecoveryCode(ctx, admin.GUID, "code-2"), errorspkg.ErrorConflict)
+	mustBeError(t, "use recovery code of other user", r.Users.UseRecoveryCode(ctx, secretary.GUID, "code-3"), errorspkg.ErrorConflict)
+	mustBeError(t, "use recovery code of missing user", r.Users.UseRecoveryCode(ctx, uuid.NewString(), "code-3"), errorspkg.ErrorConflict)
+	admin.RecoveryCodes = []string{"code-3"}
+
+	admin.UpdatedAt = at(120)
+	mustNoError(t, "update keeps totp step", r.Users.Update(ctx, admin))
+	admin.TOTPLastStep = 100
+
+	got, err = r.Users.Get(ctx, entity.UsersFilter{GUIDs: []string{admin.GUID}})
+	mustNoError(t, "get totp step", err)
+	assertEqual(t, "get totp step", got, admin)
+
+	secretary.SSOSubject = sso.SSOSubject
+	mustBeError(t, "update to taken sso subject", r.Users.Update(ctx, secretary), errorspkg.ErrorConflict)
+	mustBeError(t, "update missing", r.Users.Update(ctx, &entity.Users{GUID: uuid.NewString(), Username: "missing"}), errorspkg.ErrorNotFound)
+
+	mustNoError(t, "delete by username", r.Users.Delete(ctx, entity.UsersFilter{Username: "sso"}))
+	mustBeError(t, "delete missing", r.Users.Delete(ctx, entity.UsersFilter{Username: "sso"}), errorspkg.ErrorNotFound)
+	mustBeError(t, "delete without criteria", r.Users.Delete(ctx, entity.UsersFilter{}), errorspkg.ErrorEmptyFilter)
+	mustNoError(t, "delete by guid", r.Users.Delete(ctx, entity.UsersFilter{GUIDs: []string{secretary.GUID}}))
+
+	list, err = r.Users.List(ctx, entity.UsersFilter{})
+	mustNoError(t, "list after delete", err)
+	assertEqual(t, "list after delete", usernames(list), []string{"admin"})
+}
+
+func mustNoError(t *testing.T, what string, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+}
+
+// mustFail checks an error of a missing parent or a referenced row, its kind is not a part of the contract
+func mustFail(t *testing.T, what string, err error) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("%s: no error", what)
+	}
+}
+
+func mustBeError(t *testing.T, what string, err, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: got error %v, want %v", what, err, target)
+	}
+}
+
+// assertEqual compares values with times in UTC, Postgres returns timestamptz in local time
+func assertEqual(t *testing.T, what string, got, want interface{}) {
+	t.Helper()
+
+	if !reflect.DeepEqual(inUTC(got), inUTC(want)) {
+		t.Errorf("%s:\ngot  %s\nwant %s", what, format(got), format(want))
+	}
+}
+
+// inUTC returns a copy of v with every time.Time converted to UTC
+func inUTC(v interface{}) interface{} {
+	src := reflect.ValueOf(v)
+	dst := reflect.New(src.Type()).Elem()
+	copyInUTC(dst, src)
+	return dst.Interface()
+}
+
+func copyInUTC(dst, src reflect.Value) {
+	if t, ok := src.Interface().(time.Time); ok {
+		dst.Set(reflect.ValueOf(t.UTC()))
+		return
+	}
+
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		copyInUTC(dst.Elem(), src.Elem())
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			copyInUTC(dst.Field(i), src.Field(i))
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		for i := 0; i < src.Len(); i++ {
+			copyInUTC(dst.Index(i), src.Index(i))
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// format prints rows of a list instead of their addresses
+func format(v interface{}) string {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		return fmt.Sprintf("%+v", v)
+	}
+
+	items := make([]interface{}, value.Len())
+	for i := range items {
+		item := value.Index(i)
+		if item.Kind() == reflect.Ptr && !item.IsNil() {
+			item = item.Elem()
+		}
+		items[i] = item.Interface()
+	}
+	return fmt.Sprintf("%+v", items)
+}
+
+func apiKeyGUIDs(list []*entity.APIKeys) []string {
+	var guids []string
+	for _, v := range list {
+		guids = append(guids, v.GUID)
+	}
+	return guids
+}
+
+func articleGUIDs(list []*entity.Articles) []string {
+	var guids []string
+	for _, v := range list {
+		guids = append(guids, v.GUID)
+	}
+	return guids
+}
+
+func publicationGUIDs(list []*entity.Publications) []string {
+	var guids []string
+	for _, v := range list {
+		guids = append(guids, v.GUID)
+	}
+	return guids
+}
+
+// createPriceTiers creates tiers "regular", "urgent" and "child" in this order of positions
+func createPriceTiers(t *testing.T, r Repositories) {
+	t.Helper()
+
+	for i, code := range []string{"regular", "urgent", "child"} {
+		mustNoError(t, "create price tier", r.PriceTiers.Create(context.Background(), &entity.PriceTiers{
+			GUID:      uuid.NewString(),
+			Code:      code,
+			Name:      code,
+			Position:  i + 1,
+			CreatedAt: at(0),
+		}))
+	}
+}
+
+// tierPrices are amounts in tiers "regular" and "urgent" of createPriceTiers
+func tierPrices(regular, urgent int64) []entity.TierPrice {
+	return []entity.TierPrice{
+		{Tier: "regular", Amount: regular},
+		{Tier: "urgent", Amount: urgent},
+	}
+}
+
+func serviceGUIDs(list []*entity.Services) []string {
+	var guids []string
+	for _, v := range list {
+		guids = append(guids, v.GUID)
+	}
+	return guids
+}
+
+func serviceGroupNames(list []*entity.ServiceGroups) []string {
+	var names []string
+	for _, v := range list {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// usernames are sorted, users are listed in no particular order
+func usernames(list []*entity.Users) []string {
+	var names []string
+	for _, v := range list {
+		names = append(names, v.Username)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+type RoleSettings interface {
+	Get(ctx context.Context, role string) (*entity.RoleSettings, error)
+	List(ctx context.Context) ([]*entity.RoleSettings, error)
+	Upsert(ctx context.Context, req *entity.RoleSettings) error
+}
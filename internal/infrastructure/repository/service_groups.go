@@ -7,7 +7,7 @@ import (
 )
 
 type ServiceGroups interface {
-	List(ctx context.Context, filter map[string]string) ([]*entity.ServiceGroups, error)
+	List(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error)
 	Create(ctx context.Context, req *entity.ServiceGroups) error
 	Update(ctx context.Context, req *entity.ServiceGroups) error
 	Delete(ctx context.Context, id string) error
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+)
+
+type ServicePrices interface {
+	// Create puts the price into history of its service from ValidFrom: the price in effect then ends there and
+	// the new one ends where the next one starts, ValidTo is set. A price starting at the same time is
+	// errors.ErrorConflict, it's deleted first to be replaced.
+	Create(ctx context.Context, req *entity.ServicePrices) error
+	List(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error)
+	// Delete removes the price and extends the previous one over its period
+	Delete(ctx context.Context, id string) error
+}
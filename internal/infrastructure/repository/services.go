@@ -2,13 +2,17 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
 )
 
 type Services interface {
 	Create(ctx context.Context, req *entity.Services) error
-	List(ctx context.Context, filter map[string]string) ([]*entity.Services, error)
+	// List returns services with prices in effect at the time, zero at is now, services without a price then are left out
+	List(ctx context.Context, filter entity.ServicesFilter, at time.Time) ([]*entity.Services, error)
+	// Update changes name, prices are changed through ServicePrices
 	Update(ctx context.Context, req *entity.Services) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.ServicesFilter) error
 }
@@ -7,9 +7,16 @@ import (
 )
 
 type Users interface {
-	Get(ctx context.Context, filter map[string]string) (*entity.Users, error)
+	// Get refuses an empty filter with errors.ErrorEmptyFilter
+	Get(ctx context.Context, filter entity.UsersFilter) (*entity.Users, error)
 	Create(ctx context.Context, req *entity.Users) error
-	List(ctx context.Context, filter map[string]string) ([]*entity.Users, error)
+	List(ctx context.Context, filter entity.UsersFilter) ([]*entity.Users, error)
+	// Update doesn't change TOTPLastStep, it only moves forward by UseTOTPStep
 	Update(ctx context.Context, req *entity.Users) error
-	Delete(ctx context.Context, filter map[string]string) error
+	// UseTOTPStep stores step as the last accepted one, it returns errors.ErrorConflict if the step isn't after the stored one
+	UseTOTPStep(ctx context.Context, guid string, step int64) error
+	// UseRecoveryCode removes hash from recovery codes, it returns errors.ErrorConflict if the user doesn't have it
+	UseRecoveryCode(ctx context.Context, guid, hash string) error
+	// Delete refuses an empty filter with errors.ErrorEmptyFilter
+	Delete(ctx context.Context, filter entity.UsersFilter) error
 }
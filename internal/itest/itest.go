@@ -0,0 +1,430 @@
+// Package itest runs the whole application against a real Postgres for integration tests.
+//
+// A test package starts Postgres once in TestMain with Main, every test then gets a fresh application
+// with empty tables from New. When no Postgres is available New runs the application on in-memory
+// repositories instead, and only tests needing the database itself through DB are skipped, unless
+// TEST_REQUIRE_POSTGRES is set, then the test binary fails.
+package itest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AsaHero/abclinic/api/middleware"
+	"github.com/AsaHero/abclinic/api/models"
+	"github.com/AsaHero/abclinic/internal/app"
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/fixtures"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository/memory"
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/migrate"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"github.com/jackc/pgx/v4"
+)
+
+// Password of users created by Login, it satisfies the default password policy
+const Password = "Integration-Test-1"
+
+var (
+	server     *postgresServer
+	started    bool
+	skipReason = "itest.Main is not called from TestMain"
+)
+
+// Main starts Postgres, applies migrations and runs tests in a working directory with copies of casbin files,
+// so the files of the repository are never changed by tests
+func Main(m *testing.M) int {
+	workDir, err := os.MkdirTemp("", "abclinic-itest-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "itest:", err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := prepareWorkDir(workDir); err != nil {
+		fmt.Fprintln(os.Stderr, "itest:", err)
+		return 1
+	}
+	started = true
+
+	pg, err := startPostgres()
+	if err != nil {
+		if os.Getenv(EnvRequirePostgres) != "" {
+			fmt.Fprintln(os.Stderr, "itest:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "itest: %v; database tests are skipped and applications run on in-memory repositories, "+
+			"set %s to fail instead\n", err, EnvRequirePostgres)
+		skipReason = err.Error()
+		return m.Run()
+	}
+	defer func() {
+		if err := pg.stop(); err != nil {
+			fmt.Fprintln(os.Stderr, "itest: cannot stop postgres:", err)
+		}
+	}()
+
+	migrator, err := migrate.New(newConfig(pg), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "itest:", err)
+		return 1
+	}
+	err = migrator.Up()
+	migrator.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "itest: cannot migrate:", err)
+		return 1
+	}
+
+	server = pg
+	return m.Run()
+}
+
+// Env is a started application with an empty database
+type Env struct {
+	Config *config.Config
+	// Admin gives usecases to arrange data without going through http
+	Admin  *app.Admin
+	URL    string
+	Client *http.Client
+	tokens map[string]string
+}
+
+// New starts the application for the test and stops it on cleanup, database tables are emptied first.
+// Without Postgres the application gets empty in-memory repositories. Configure functions change config
+// of the test before the start, e.g. to point it to a mock service.
+func New(t *testing.T, configure ...func(cfg *config.Config)) *Env {
+	t.Helper()
+
+	if !started {
+		t.Skip(skipReason)
+	}
+
+	var (
+		cfg         *config.Config
+		application *app.App
+		store       *memory.Store
+		err         error
+	)
+	if server != nil {
+		cfg = newConfig(server)
+	} else {
+		cfg = newConfig(&postgresServer{})
+	}
+	for _, f := range configure {
+		f(cfg)
+	}
+
+	if server != nil {
+		if err := truncate(cfg); err != nil {
+			t.Fatalf("cannot empty database: %v", err)
+		}
+		application, err = app.NewApp(cfg)
+	} else {
+		store = memory.NewStore()
+		application, err = app.NewMemoryApp(cfg, store)
+	}
+	if err != nil {
+		t.Fatalf("cannot init app: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := application.Start(ctx); err != nil {
+		t.Fatalf("cannot start app: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+
+		if err := application.Stop(ctx); err != nil {
+			t.Errorf("cannot stop app: %v", err)
+		}
+	})
+
+	var admin *app.Admin
+	if store != nil {
+		admin = app.NewMemoryAdmin(cfg, store)
+	} else {
+		admin, err = app.NewAdmin(cfg)
+		if err != nil {
+			t.Fatalf("cannot init admin: %v", err)
+		}
+	}
+	t.Cleanup(admin.Close)
+
+	return &Env{
+		Config: cfg,
+		Admin:  admin,
+		URL:    "http://" + application.Addr(),
+		Client: &http.Client{Timeout: 30 * time.Second},
+		tokens: make(map[string]string),
+	}
+}
+
+// DB connects to the database for repository tests, tables are emptied first and the pool is closed on cleanup
+func DB(t *testing.T) *postgres.PostgresDB {
+	t.Helper()
+
+	if server == nil {
+		t.Skip(skipReason)
+	}
+
+	cfg := newConfig(server)
+	if err := truncate(cfg); err != nil {
+		t.Fatalf("cannot empty database: %v", err)
+	}
+
+	db, err := postgres.NewPostgresDB(*cfg)
+	if err != nil {
+		t.Fatalf("cannot connect database: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+// Response is a read response of the application
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode unmarshals JSON body into v
+func (r *Response) Decode(t *testing.T, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("cannot decode response %d %s: %v", r.StatusCode, r.Body, err)
+	}
+}
+
+// Request sends body as JSON, token is sent as bearer token unless it's empty
+func (e *Env) Request(t *testing.T, method, path, token string, body interface{}) *Response {
+	t.Helper()
+
+	req := e.newRequest(t, method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return e.do(t, req)
+}
+
+// RequestWithAPIKey sends body as JSON with key in X-API-Key header
+func (e *Env) RequestWithAPIKey(t *testing.T, method, path, key string, body interface{}) *Response {
+	t.Helper()
+
+	req := e.newRequest(t, method, path, body)
+	req.Header.Set(middleware.HeaderAPIKey, key)
+
+	return e.do(t, req)
+}
+
+// Get sends an anonymous GET request with header, e.g. conditional one
+func (e *Env) Get(t *testing.T, path string, header http.Header) *Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, e.URL+path, nil)
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	return e.do(t, req)
+}
+
+func (e *Env) newRequest(t *testing.T, method, path string, body interface{}) *http.Request {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("cannot encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, e.URL+path, reader)
+	if err != nil {
+		t.Fatalf("cannot create request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req
+}
+
+func (e *Env) do(t *testing.T, req *http.Request) *Response {
+	t.Helper()
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: cannot read body: %v", req.Method, req.URL.Path, err)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+	}
+}
+
+// Login returns an access token of a user with the role, the user is created on first call
+func (e *Env) Login(t *testing.T, role string) string {
+	t.Helper()
+
+	if token, ok := e.tokens[role]; ok {
+		return token
+	}
+
+	username := role + "-user"
+	if _, err := e.Admin.RbacUsecase.CreateUser(context.Background(), &entity.Users{
+		Role:      role,
+		Firstname: role,
+		Lastname:  "Test",
+		Username:  username,
+		Password:  Password,
+	}); err != nil {
+		t.Fatalf("cannot create %s user: %v", role, err)
+	}
+
+	resp := e.Request(t, http.MethodPost, "/v1/login", "", models.LoginRequest{
+		Username: username,
+		Password: Password,
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cannot login as %s: %d %s", role, resp.StatusCode, resp.Body)
+	}
+
+	var login models.LoginResponse
+	resp.Decode(t, &login)
+
+	e.tokens[role] = login.AccessToken
+	return login.AccessToken
+}
+
+// LoadFixtures loads a file of fixtures directory of the repository, e.g. demo.yaml
+func (e *Env) LoadFixtures(t *testing.T, name string) {
+	t.Helper()
+
+	data, err := fixtures.Read(filepath.Join(repoRoot(), "fixtures", name))
+	if err != nil {
+		t.Fatalf("cannot read fixtures: %v", err)
+	}
+
+	loader := fixtures.NewLoader(e.Admin.DentistsUsecase, e.Admin.PriceListUsecase, e.Admin.InfoUsecase, e.Admin.BlogsUsecase)
+	if _, err := loader.Load(context.Background(), data); err != nil {
+		t.Fatalf("cannot load fixtures: %v", err)
+	}
+}
+
+func newConfig(pg *postgresServer) *config.Config {
+	cfg, err := config.Load("")
+	if err != nil {
+		// defaults can't be invalid, only environment of the test run
+		panic(fmt.Sprintf("itest: cannot load config: %v", err))
+	}
+
+	cfg.Environment = "test"
+	cfg.LogLevel = "error"
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = ":0"
+	cfg.DB.Host = pg.host
+	cfg.DB.Port = pg.port
+	cfg.DB.User = pg.user
+	cfg.DB.Password = pg.password
+	cfg.DB.Name = pg.database
+	cfg.DB.SSLMode = pg.sslMode
+	cfg.DB.AutoMigrate = false
+	cfg.OIDC.IssuerURL = ""
+	cfg.RateLimit.Enabled = false
+	cfg.Metrics.Enabled = false
+	cfg.Tracing.Exporter = tracing.ExporterNone
+	cfg.Health.DrainDelay = 0
+	// backoff after a failed login is waited out by tests
+	cfg.Login.BaseDelay = 100 * time.Millisecond
+
+	return cfg
+}
+
+// truncate empties every table except migrations version
+func truncate(cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, postgres.GetStrConfig(cfg))
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	rows, err := conn.Query(ctx, "SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename <> 'schema_migrations'")
+	if err != nil {
+		return err
+	}
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, pgx.Identifier{table}.Sanitize())
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(tables) == 0 {
+		return nil
+	}
+
+	_, err = conn.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE")
+	return err
+}
+
+// prepareWorkDir copies casbin files into dir and makes it the working directory
+func prepareWorkDir(dir string) error {
+	for _, name := range []string{"auth_model.conf", "policy.csv"} {
+		data, err := os.ReadFile(filepath.Join(repoRoot(), name))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return os.Chdir(dir)
+}
+
+func repoRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
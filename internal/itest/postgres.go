@@ -0,0 +1,195 @@
+package itest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// EnvDatabaseURL points to a running Postgres, e.g. a container started by CI, a fresh database is created in it
+const EnvDatabaseURL = "TEST_DATABASE_URL"
+
+// EnvPostgresBin is the directory of initdb and pg_ctl, PATH is searched when it's empty
+const EnvPostgresBin = "TEST_POSTGRES_BIN"
+
+// EnvRequirePostgres makes a missing Postgres fail the tests instead of skipping them, CI should set it
+const EnvRequirePostgres = "TEST_REQUIRE_POSTGRES"
+
+var errNoPostgres = errors.New("no Postgres for integration tests: set " + EnvDatabaseURL + " or install initdb and pg_ctl")
+
+// postgresServer is a database reserved for one test binary
+type postgresServer struct {
+	host     string
+	port     string
+	user     string
+	password string
+	database string
+	sslMode  string
+	stop     func() error
+}
+
+func startPostgres() (*postgresServer, error) {
+	if databaseURL := os.Getenv(EnvDatabaseURL); databaseURL != "" {
+		return createDatabase(databaseURL)
+	}
+
+	initdb, pgctl, err := postgresBinaries()
+	if err != nil {
+		return nil, err
+	}
+
+	return startLocalPostgres(initdb, pgctl)
+}
+
+// createDatabase creates a randomly named database in server of databaseURL and drops it on stop
+func createDatabase(databaseURL string) (*postgresServer, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvDatabaseURL, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect %s: %w", EnvDatabaseURL, err)
+	}
+	defer conn.Close(context.Background())
+
+	database := "abclinic_test_" + randomSuffix()
+	if _, err := conn.Exec(ctx, "CREATE DATABASE "+database); err != nil {
+		return nil, err
+	}
+
+	password, _ := u.User.Password()
+	server := &postgresServer{
+		host:     u.Hostname(),
+		port:     u.Port(),
+		user:     u.User.Username(),
+		password: password,
+		database: database,
+		sslMode:  u.Query().Get("sslmode"),
+	}
+	if server.port == "" {
+		server.port = "5432"
+	}
+	if server.sslMode == "" {
+		server.sslMode = "disable"
+	}
+
+	server.stop = func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		conn, err := pgx.Connect(ctx, databaseURL)
+		if err != nil {
+			return err
+		}
+		defer conn.Close(context.Background())
+
+		_, err = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+database)
+		return err
+	}
+
+	return server, nil
+}
+
+func postgresBinaries() (string, string, error) {
+	// initdb refuses to run as root, which is common in containers
+	if os.Geteuid() == 0 {
+		return "", "", fmt.Errorf("%w, initdb can't run as root", errNoPostgres)
+	}
+
+	if dir := os.Getenv(EnvPostgresBin); dir != "" {
+		return filepath.Join(dir, "initdb"), filepath.Join(dir, "pg_ctl"), nil
+	}
+
+	initdb, err := exec.LookPath("initdb")
+	if err != nil {
+		// debian packages don't put server binaries to PATH
+		matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin/initdb")
+		if len(matches) == 0 {
+			return "", "", errNoPostgres
+		}
+		initdb = matches[len(matches)-1]
+	}
+
+	return initdb, filepath.Join(filepath.Dir(initdb), "pg_ctl"), nil
+}
+
+// startLocalPostgres initializes a throwaway cluster in a temporary directory and removes it on stop
+func startLocalPostgres(initdb, pgctl string) (*postgresServer, error) {
+	dir, err := os.MkdirTemp("", "abclinic-postgres-")
+	if err != nil {
+		return nil, err
+	}
+	dataDir := filepath.Join(dir, "data")
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	out, err := exec.Command(initdb, "-D", dataDir, "-U", "postgres", "-A", "trust", "--no-sync").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("initdb: %w: %s", err, out)
+	}
+
+	// fsync is off, the cluster is thrown away anyway
+	options := fmt.Sprintf("-p %d -k %s -c listen_addresses=127.0.0.1 -F", port, dir)
+	out, err = exec.Command(pgctl, "-D", dataDir, "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-w", "start").CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("pg_ctl start: %w: %s", err, out)
+	}
+
+	return &postgresServer{
+		host:     "127.0.0.1",
+		port:     strconv.Itoa(port),
+		user:     "postgres",
+		database: "postgres",
+		sslMode:  "disable",
+		stop: func() error {
+			defer os.RemoveAll(dir)
+
+			out, err := exec.Command(pgctl, "-D", dataDir, "-m", "immediate", "-w", "stop").CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("pg_ctl stop: %w: %s", err, out)
+			}
+			return nil
+		},
+	}, nil
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func randomSuffix() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return strings.ToLower(hex.EncodeToString(buf))
+}
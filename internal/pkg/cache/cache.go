@@ -0,0 +1,130 @@
+// Package cache keeps results of reads in process until they expire or a change of their data invalidates them.
+//
+// Values are stored under tags naming the data they were read from, a change invalidates tags of the data it touched.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value   interface{}
+	tags    []string
+	expires time.Time
+}
+
+type Cache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*entry
+	// generations of tags move on invalidation, a value loaded across one is not stored
+	generations map[string]uint64
+	done        chan struct{}
+}
+
+// New returns a cache keeping values for ttl, expired values are dropped every cleanupInterval
+func New(ttl, cleanupInterval time.Duration) *Cache {
+	c := &Cache{
+		ttl:         ttl,
+		entries:     make(map[string]*entry),
+		generations: make(map[string]uint64),
+		done:        make(chan struct{}),
+	}
+
+	go c.cleanup(cleanupInterval)
+
+	return c
+}
+
+// Load returns the value of key, on miss it's loaded and stored under tags.
+// Values are shared between callers and must not be modified.
+func (c *Cache) Load(ctx context.Context, key string, tags []string, load func() (interface{}, error)) (interface{}, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.value, nil
+	}
+	generation := c.generation(tags)
+	c.mu.Unlock()
+
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.generation(tags) == generation {
+		c.entries[key] = &entry{
+			value:   value,
+			tags:    tags,
+			expires: now.Add(c.ttl),
+		}
+	}
+	c.mu.Unlock()
+
+	return value, nil
+}
+
+// Invalidate drops values stored under any of tags
+func (c *Cache) Invalidate(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		c.generations[tag]++
+	}
+
+	for key, e := range c.entries {
+		if hasAny(e.tags, tags) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cache) Close() {
+	close(c.done)
+}
+
+// generation changes whenever one of tags is invalidated, generations only grow so their sum does too
+func (c *Cache) generation(tags []string) uint64 {
+	var sum uint64
+	for _, tag := range tags {
+		sum += c.generations[tag]
+	}
+	return sum
+}
+
+func (c *Cache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for key, e := range c.entries {
+				if !now.Before(e.expires) {
+					delete(c.entries, key)
+				}
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func hasAny(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,102 +1,161 @@
 package config
 
 import (
-	"os"
 	"time"
 )
 
+// defaultRateLimitRules guard credential endpoints by address and share a generous quota for the rest
+const defaultRateLimitRules = "POST /v1/login 10/1m ip;" +
+	"POST /v1/login/mfa 10/1m ip;" +
+	"POST /v1/login/mfa/enroll 10/1m ip;" +
+	"POST /v1/refresh 30/1m ip;" +
+	"POST /v1/sso/callback 10/1m ip;" +
+	"* /v1/* 600/1m identity"
+
+// DefaultTokenSecret is only accepted in develop environment
+const DefaultTokenSecret = "haCBL1+6BsR6f0ZyF6AOWh0mqouoqkl9KpNK9YhuVlA="
+
+// Config is loaded from defaults, then an optional YAML or TOML file, then environment variables.
+// Fields are described by tags: yaml/toml keys of the file, env variable, default value,
+// sep of list values and secret for values redacted when printed.
 type Config struct {
-	APP         string
-	Environment string
-	LogLevel    string
+	APP         string `yaml:"app" toml:"app" env:"APP" default:"app"`
+	// Environment has no default, a deployment that forgets it must not start with develop's relaxed checks
+	Environment string `yaml:"environment" toml:"environment" env:"ENVIRONMENT"`
+	LogLevel    string `yaml:"log_level" toml:"log_level" env:"LOG_LEVEL" default:"debug"`
 	Server      struct {
-		Host         string
-		Port         string
-		ReadTimeout  string
-		WriteTimeout string
-		IdleTimeout  string
-	}
+		Host         string        `yaml:"host" toml:"host" env:"SERVER_HOST"`
+		Port         string        `yaml:"port" toml:"port" env:"SERVER_PORT" default:":8080"`
+		ReadTimeout  time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"10s"`
+		WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"10s"`
+		IdleTimeout  time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+		// ShutdownTimeout bounds draining requests and stopping every component
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"`
+		// TrustedProxies are CIDRs of proxies whose X-Forwarded-For and X-Real-IP are honoured, other peers' are ignored
+		TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" sep:","`
+	} `yaml:"server" toml:"server"`
 
 	CDN struct {
-		AwsAccessKeyID     string
-		AwsSecretAccessKey string
-		AwsEndpoint        string
-		BucketName         string
-		CdnBaseUrl         string
-	}
+		AwsAccessKeyID     string `yaml:"aws_access_key_id" toml:"aws_access_key_id" env:"AWS_ACCESS_KEY_ID"`
+		AwsSecretAccessKey string `yaml:"aws_secret_access_key" toml:"aws_secret_access_key" env:"AWS_SECRET_ACCESS_KEY" secret:"true"`
+		AwsEndpoint        string `yaml:"aws_endpoint" toml:"aws_endpoint" env:"AWS_END_POINT"`
+		BucketName         string `yaml:"bucket_name" toml:"bucket_name" env:"BUCKET_NAME"`
+		CdnBaseUrl         string `yaml:"cdn_base_url" toml:"cdn_base_url" env:"CDN_BASE_URL"`
+	} `yaml:"cdn" toml:"cdn"`
 	DB struct {
-		Host     string
-		Port     string
-		Name     string
-		User     string
-		Password string
-		SSLMode  string
-	}
+		Host     string `yaml:"host" toml:"host" env:"POSTGRES_HOST" default:"localhost"`
+		Port     string `yaml:"port" toml:"port" env:"POSTGRES_PORT" default:"5432"`
+		Name     string `yaml:"name" toml:"name" env:"POSTGRES_DATABASE" default:"abclinic"`
+		User     string `yaml:"user" toml:"user" env:"POSTGRES_USER" default:"postgres"`
+		Password string `yaml:"password" toml:"password" env:"POSTGRES_PASSWORD" default:"postgres" secret:"true"`
+		SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"POSTGRES_SSLMODE" default:"disable"`
+		// AutoMigrate applies pending embedded migrations on start
+		AutoMigrate bool `yaml:"auto_migrate" toml:"auto_migrate" env:"POSTGRES_AUTO_MIGRATE" default:"false"`
+	} `yaml:"db" toml:"db"`
 	Token struct {
-		Secret     string
-		AccessTTL  time.Duration
-		RefreshTTL time.Duration
-	}
+		Secret       string        `yaml:"secret" toml:"secret" env:"TOKEN_SECRET" secret:"true"`
+		Algorithm    string        `yaml:"algorithm" toml:"algorithm" env:"TOKEN_ALGORITHM" default:"RS256"`
+		KeysDir      string        `yaml:"keys_dir" toml:"keys_dir" env:"TOKEN_KEYS_DIR"`
+		SigningKeyID string        `yaml:"signing_kid" toml:"signing_kid" env:"TOKEN_SIGNING_KID"`
+		KeyRotation  time.Duration `yaml:"key_rotation" toml:"key_rotation" env:"TOKEN_KEY_ROTATION" default:"24h"`
+		Issuer       string        `yaml:"issuer" toml:"issuer" env:"TOKEN_ISSUER" default:"abclinic"`
+		Audience     string        `yaml:"audience" toml:"audience" env:"TOKEN_AUDIENCE" default:"abclinic"`
+		ClockSkew    time.Duration `yaml:"clock_skew" toml:"clock_skew" env:"TOKEN_CLOCK_SKEW" default:"30s"`
+		AccessTTL    time.Duration `yaml:"access_ttl" toml:"access_ttl" env:"TOKEN_ACCESS_TTL" default:"1h"`
+		RefreshTTL   time.Duration `yaml:"refresh_ttl" toml:"refresh_ttl" env:"TOKEN_REFRESH_TTL" default:"24h"`
+	} `yaml:"token" toml:"token"`
 	Context struct {
-		Timeout string
-	}
+		Timeout time.Duration `yaml:"timeout" toml:"timeout" env:"CONTEXT_TIMEOUT" default:"30s"`
+	} `yaml:"context" toml:"context"`
+	Login struct {
+		MaxAttempts     int           `yaml:"max_attempts" toml:"max_attempts" env:"LOGIN_MAX_ATTEMPTS" default:"5"`
+		IPMaxAttempts   int           `yaml:"ip_max_attempts" toml:"ip_max_attempts" env:"LOGIN_IP_MAX_ATTEMPTS" default:"20"`
+		BaseDelay       time.Duration `yaml:"base_delay" toml:"base_delay" env:"LOGIN_BASE_DELAY" default:"1s"`
+		MaxDelay        time.Duration `yaml:"max_delay" toml:"max_delay" env:"LOGIN_MAX_DELAY" default:"1m"`
+		LockoutDuration time.Duration `yaml:"lockout_duration" toml:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION" default:"15m"`
+		Window          time.Duration `yaml:"window" toml:"window" env:"LOGIN_WINDOW" default:"1h"`
+	} `yaml:"login" toml:"login"`
+	Password struct {
+		MinLength      int  `yaml:"min_length" toml:"min_length" env:"PASSWORD_MIN_LENGTH" default:"8"`
+		RequireUpper   bool `yaml:"require_upper" toml:"require_upper" env:"PASSWORD_REQUIRE_UPPER" default:"true"`
+		RequireLower   bool `yaml:"require_lower" toml:"require_lower" env:"PASSWORD_REQUIRE_LOWER" default:"true"`
+		RequireDigit   bool `yaml:"require_digit" toml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT" default:"true"`
+		RequireSpecial bool `yaml:"require_special" toml:"require_special" env:"PASSWORD_REQUIRE_SPECIAL" default:"false"`
+		// Denylist is rejected in addition to the built-in list
+		Denylist []string      `yaml:"denylist" toml:"denylist" env:"PASSWORD_DENYLIST" sep:","`
+		ResetTTL time.Duration `yaml:"reset_ttl" toml:"reset_ttl" env:"PASSWORD_RESET_TTL" default:"24h"`
+	} `yaml:"password" toml:"password"`
+	MFA struct {
+		Issuer       string        `yaml:"issuer" toml:"issuer" env:"MFA_ISSUER" default:"ABClinic"`
+		ChallengeTTL time.Duration `yaml:"challenge_ttl" toml:"challenge_ttl" env:"MFA_CHALLENGE_TTL" default:"5m"`
+	} `yaml:"mfa" toml:"mfa"`
+	// OIDC single sign-on is disabled without issuer
+	OIDC struct {
+		IssuerURL    string   `yaml:"issuer_url" toml:"issuer_url" env:"OIDC_ISSUER_URL"`
+		ClientID     string   `yaml:"client_id" toml:"client_id" env:"OIDC_CLIENT_ID"`
+		ClientSecret string   `yaml:"client_secret" toml:"client_secret" env:"OIDC_CLIENT_SECRET" secret:"true"`
+		RedirectURL  string   `yaml:"redirect_url" toml:"redirect_url" env:"OIDC_REDIRECT_URL"`
+		Scopes       []string `yaml:"scopes" toml:"scopes" env:"OIDC_SCOPES" default:"openid profile email" sep:" "`
+		GroupsClaim  string   `yaml:"groups_claim" toml:"groups_claim" env:"OIDC_GROUPS_CLAIM" default:"groups"`
+		// RoleMapping holds group=role pairs in precedence order
+		RoleMapping []string      `yaml:"role_mapping" toml:"role_mapping" env:"OIDC_ROLE_MAPPING" sep:","`
+		StateTTL    time.Duration `yaml:"state_ttl" toml:"state_ttl" env:"OIDC_STATE_TTL" default:"10m"`
+	} `yaml:"oidc" toml:"oidc"`
+	RateLimit struct {
+		Enabled bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED" default:"true"`
+		// Rules are "METHOD PATTERN REQUESTS/PERIOD [ip|identity]", the first matching rule applies
+		Rules []string `yaml:"rules" toml:"rules" env:"RATE_LIMIT_RULES" sep:";"`
+		// APIKeyFailures is "REQUESTS/PERIOD" of failed api key lookups per address, an address without failures
+		// left gets 429 for any api key. It applies with rate limiting disabled too.
+		APIKeyFailures string `yaml:"api_key_failures" toml:"api_key_failures" env:"RATE_LIMIT_API_KEY_FAILURES" default:"10/1m"`
+	} `yaml:"rate_limit" toml:"rate_limit"`
+	PriceList struct {
+		// Currency is ISO 4217 code of prices given without one. Migration 000021 marked prices existing before
+		// it as UZS, the default, deployments quoting in another currency update those rows after migrating
+		Currency string `yaml:"currency" toml:"currency" env:"PRICE_LIST_CURRENCY" default:"UZS"`
+	} `yaml:"price_list" toml:"price_list"`
+	Cache struct {
+		// Enabled keeps public content read from the database in process, changes made through the instance invalidate it
+		Enabled bool `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED" default:"true"`
+		// TTL bounds how long changes made by other instances or admin commands, and scheduled prices taking effect, stay unseen
+		TTL time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL" default:"1m"`
+		// MaxAge of Cache-Control lets clients reuse public responses without revalidation, 0 makes them revalidate
+		DentistsMaxAge  time.Duration `yaml:"dentists_max_age" toml:"dentists_max_age" env:"CACHE_DENTISTS_MAX_AGE" default:"5m"`
+		PriceListMaxAge time.Duration `yaml:"price_list_max_age" toml:"price_list_max_age" env:"CACHE_PRICE_LIST_MAX_AGE" default:"1m"`
+		InfoMaxAge      time.Duration `yaml:"info_max_age" toml:"info_max_age" env:"CACHE_INFO_MAX_AGE" default:"5m"`
+		BlogsMaxAge     time.Duration `yaml:"blogs_max_age" toml:"blogs_max_age" env:"CACHE_BLOGS_MAX_AGE" default:"1m"`
+	} `yaml:"cache" toml:"cache"`
+	Metrics struct {
+		// Enabled exposes Prometheus metrics on /metrics of Addr
+		Enabled bool `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED" default:"true"`
+		// Addr is a listener of its own, apart from the public server, it should be reachable only by the scraper
+		Addr string `yaml:"addr" toml:"addr" env:"METRICS_ADDR" default:":9090"`
+	} `yaml:"metrics" toml:"metrics"`
+	Health struct {
+		CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+		// DrainDelay keeps serving after readiness starts failing so load balancers can react
+		DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay" env:"HEALTH_DRAIN_DELAY" default:"5s"`
+	} `yaml:"health" toml:"health"`
+	Tracing struct {
+		// Exporter is otlp, stdout or none
+		Exporter string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" default:"none"`
+		// OTLPEndpoint is host:port of OTLP/HTTP collector
+		OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" default:"localhost:4318"`
+		OTLPInsecure bool    `yaml:"otlp_insecure" toml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" default:"true"`
+		SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1"`
+	} `yaml:"tracing" toml:"tracing"`
 }
 
+// NewConfig loads configuration from CONFIG_FILE, if set, and environment, and validates it
 func NewConfig() (*Config, error) {
-
-	config := Config{}
-	// general initialization
-	config.APP = getEnv("APP", "app")
-	config.Environment = getEnv("ENVIRONMENT", "develop")
-	config.LogLevel = getEnv("LOG_LEVEL", "debug")
-	config.Context.Timeout = getEnv("CONTEXT_TIMEOUT", "30s")
-
-	// server initialization
-	config.Server.Host = getEnv("SERVER_HOST", "")
-	config.Server.Port = getEnv("SERVER_PORT", ":8080")
-	config.Server.ReadTimeout = getEnv("SERVER_READ_TIMEOUT", "10s")
-	config.Server.WriteTimeout = getEnv("SERVER_WRITE_TIMEOUT", "10s")
-	config.Server.IdleTimeout = getEnv("SERVER_IDLE_TIMEOUT", "120s")
-
-	// cdn init
-	config.CDN.AwsAccessKeyID = getEnv("AWS_ACCESS_KEY_ID", "")
-	config.CDN.AwsSecretAccessKey = getEnv("AWS_SECRET_ACCESS_KEY", "")
-	config.CDN.AwsEndpoint = getEnv("AWS_END_POINT", "")
-	config.CDN.BucketName = getEnv("BUCKET_NAME", "")
-	config.CDN.CdnBaseUrl = getEnv("CDN_BASE_URL", "")
-
-	// db initialization
-	config.DB.Host = getEnv("POSTGRES_HOST", "localhost")
-	config.DB.Port = getEnv("POSTGRES_PORT", "5432")
-	config.DB.Name = getEnv("POSTGRES_DATABASE", "abclinic")
-	config.DB.User = getEnv("POSTGRES_USER", "postgres")
-	config.DB.Password = getEnv("POSTGRES_PASSWORD", "postgres")
-	config.DB.SSLMode = getEnv("POSTGRES_SSLMODE", "disable")
-
-	// token init
-	// token initialization
-	config.Token.Secret = getEnv("TOKEN_SECRET", "haCBL1+6BsR6f0ZyF6AOWh0mqouoqkl9KpNK9YhuVlA=")
-
-	// access ttl parse
-	accessTTl, err := time.ParseDuration(getEnv("TOKEN_ACCESS_TTL", "1h"))
+	config, err := Load(getEnv("CONFIG_FILE", ""))
 	if err != nil {
 		return nil, err
 	}
-	// refresh ttl parse
-	refreshTTL, err := time.ParseDuration(getEnv("TOKEN_REFRESH_TTL", "24h"))
-	if err != nil {
-		return nil, err
-	}
-	config.Token.AccessTTL = accessTTl
-	config.Token.RefreshTTL = refreshTTL
-
-	return &config, nil
-}
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	return defaultValue
+	return config, nil
 }
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load builds configuration from defaults, the file at path if it's not empty and environment variables,
+// every layer overrides only the values it sets
+func Load(path string) (*Config, error) {
+	config := Config{}
+	if err := setFields(&config, "default", func(value string) (string, bool) {
+		return value, value != ""
+	}); err != nil {
+		return nil, err
+	}
+	// too long or sensitive for a struct tag
+	config.Token.Secret = DefaultTokenSecret
+	config.RateLimit.Rules = splitList(defaultRateLimitRules, ";")
+
+	if path != "" {
+		if err := loadFile(&config, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := setFields(&config, "env", os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func loadFile(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(config)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(data), config)
+		if err == nil && len(meta.Undecoded()) > 0 {
+			err = fmt.Errorf("unknown keys %v", meta.Undecoded())
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("cannot parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// setFields walks config and sets every field whose tag resolves to a value
+func setFields(config *Config, tag string, lookup func(string) (string, bool)) error {
+	return walk(reflect.ValueOf(config).Elem(), func(field reflect.StructField, value reflect.Value) error {
+		key := field.Tag.Get(tag)
+		if key == "" {
+			return nil
+		}
+
+		raw, ok := lookup(key)
+		if !ok {
+			return nil
+		}
+
+		if err := setValue(value, raw, field.Tag.Get("sep")); err != nil {
+			if tag == "env" {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			return fmt.Errorf("invalid default of %s: %w", field.Name, err)
+		}
+
+		return nil
+	})
+}
+
+// walk calls fn for every non-struct field, nested structs are descended into
+func walk(v reflect.Value, fn func(reflect.StructField, reflect.Value) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+
+		if value.Kind() == reflect.Struct {
+			if err := walk(value, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(field, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setValue(value reflect.Value, raw, sep string) error {
+	switch {
+	case value.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+	case value.Kind() == reflect.String:
+		value.SetString(raw)
+	case value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String:
+		value.Set(reflect.ValueOf(splitList(raw, sep)))
+	default:
+		return fmt.Errorf("unsupported config field type %s", value.Type())
+	}
+
+	return nil
+}
+
+// splitList splits by sep, comma by default, dropping blanks, a space separator splits by any whitespace
+func splitList(raw, sep string) []string {
+	if sep == "" {
+		sep = ","
+	}
+
+	var parts []string
+	if sep == " " {
+		parts = strings.Fields(raw)
+	} else {
+		parts = strings.Split(raw, sep)
+	}
+
+	list := []string{}
+	for _, v := range parts {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+
+	return defaultValue
+}
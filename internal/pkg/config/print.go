@@ -0,0 +1,37 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Redacted returns a copy with secret values replaced, unset secrets stay empty to show they're missing
+func (c *Config) Redacted() *Config {
+	copied := *c
+
+	// walk only fails on callback errors
+	_ = walk(reflect.ValueOf(&copied).Elem(), func(field reflect.StructField, value reflect.Value) error {
+		if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String && value.String() != "" {
+			value.SetString(redacted)
+		}
+		return nil
+	})
+
+	return &copied
+}
+
+// Print writes effective configuration as YAML with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
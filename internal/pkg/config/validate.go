@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/pkg/app"
+	"github.com/AsaHero/abclinic/internal/pkg/money"
+	"github.com/AsaHero/abclinic/internal/pkg/ratelimit"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate reports every problem at once so a deployment can be fixed in one pass
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Environment != "", "environment is required, set ENVIRONMENT")
+	check(oneOf(c.LogLevel, "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		"log_level %q is unknown", c.LogLevel)
+
+	check(c.Server.Port != "", "server.port is required")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	for _, v := range c.Server.TrustedProxies {
+		_, _, err := net.ParseCIDR(v)
+		check(err == nil, "server.trusted_proxies has %q which is not a CIDR", v)
+	}
+	check(c.Context.Timeout > 0, "context.timeout must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(c.DB.Name != "", "db.name is required")
+
+	check(oneOf(c.Token.Algorithm, "HS256", "RS256", "EdDSA"),
+		"token.algorithm must be HS256, RS256 or EdDSA, got %q", c.Token.Algorithm)
+	if c.Token.Algorithm == "HS256" {
+		check(len(c.Token.Secret) >= 32, "token.secret must be at least 32 characters for HS256")
+		// the default secret is public, tokens signed with it can be forged by anyone
+		check(c.Token.Secret != DefaultTokenSecret || c.Environment == app.EnvironmentDevelop,
+			"token.secret must be changed from the default outside %s, set TOKEN_SECRET", app.EnvironmentDevelop)
+	} else {
+		// generated keys live in one process, tokens would break on restart and across instances
+		check(c.Token.KeysDir != "" || c.Environment == app.EnvironmentDevelop,
+			"token.keys_dir is required for %s outside %s, set TOKEN_KEYS_DIR", c.Token.Algorithm, app.EnvironmentDevelop)
+	}
+	check(c.Token.AccessTTL > 0, "token.access_ttl must be positive")
+	check(c.Token.RefreshTTL > c.Token.AccessTTL, "token.refresh_ttl must be longer than token.access_ttl")
+	check(c.Token.ClockSkew >= 0, "token.clock_skew must not be negative")
+	check(c.Token.KeyRotation >= 0, "token.key_rotation must not be negative")
+
+	check(c.Login.MaxAttempts > 0, "login.max_attempts must be positive")
+	check(c.Login.IPMaxAttempts > 0, "login.ip_max_attempts must be positive")
+	check(c.Login.BaseDelay <= c.Login.MaxDelay, "login.base_delay must not exceed login.max_delay")
+	check(c.Login.Window > 0, "login.window must be positive")
+
+	check(c.Password.MinLength > 0, "password.min_length must be positive")
+	check(c.Password.ResetTTL > 0, "password.reset_ttl must be positive")
+	check(c.MFA.ChallengeTTL > 0, "mfa.challenge_ttl must be positive")
+
+	if c.OIDC.IssuerURL != "" {
+		check(c.OIDC.ClientID != "", "oidc.client_id is required with oidc.issuer_url")
+		check(c.OIDC.RedirectURL != "", "oidc.redirect_url is required with oidc.issuer_url")
+		check(c.OIDC.StateTTL > 0, "oidc.state_ttl must be positive")
+	}
+
+	check(money.Valid(c.PriceList.Currency), "price_list.currency %q is not an ISO 4217 code", c.PriceList.Currency)
+
+	if c.Cache.Enabled {
+		check(c.Cache.TTL > 0, "cache.ttl must be positive")
+	}
+	check(c.Cache.DentistsMaxAge >= 0, "cache.dentists_max_age must not be negative")
+	check(c.Cache.PriceListMaxAge >= 0, "cache.price_list_max_age must not be negative")
+	check(c.Cache.InfoMaxAge >= 0, "cache.info_max_age must not be negative")
+	check(c.Cache.BlogsMaxAge >= 0, "cache.blogs_max_age must not be negative")
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+	check(c.Health.DrainDelay >= 0, "health.drain_delay must not be negative")
+	check(c.Health.DrainDelay < c.Server.ShutdownTimeout, "health.drain_delay must be shorter than server.shutdown_timeout")
+
+	_, err := ratelimit.ParseLimit(c.RateLimit.APIKeyFailures)
+	check(err == nil, "rate_limit.api_key_failures: %v", err)
+
+	check(oneOf(c.Tracing.Exporter, "otlp", "stdout", "none"),
+		"tracing.exporter must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, v := range allowed {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AsaHero/abclinic/internal/pkg/app"
+)
+
+func TestValidateTokenKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		algorithm   string
+		keysDir     string
+		wantErr     bool
+	}{
+		{"generated RS256 keys in develop", app.EnvironmentDevelop, "RS256", "", false},
+		{"generated EdDSA keys in develop", app.EnvironmentDevelop, "EdDSA", "", false},
+		{"generated RS256 keys in production", app.EnvironmentProduction, "RS256", "", true},
+		{"generated EdDSA keys in production", app.EnvironmentProduction, "EdDSA", "", true},
+		{"RS256 keys dir in production", app.EnvironmentProduction, "RS256", "/etc/abclinic/keys", false},
+		{"EdDSA keys dir in production", app.EnvironmentProduction, "EdDSA", "/etc/abclinic/keys", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Environment = tt.environment
+			cfg.Token.Algorithm = tt.algorithm
+			cfg.Token.KeysDir = tt.keysDir
+
+			err = cfg.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"cidrs", []string{"10.0.0.0/8", "fd00::/8"}, false},
+		{"address without prefix length", []string{"10.0.0.1"}, true},
+		{"hostname", []string{"proxy.local"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Environment = app.EnvironmentDevelop
+			cfg.Server.TrustedProxies = tt.proxies
+
+			err = cfg.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		keysDir     string
+		wantErr     bool
+	}{
+		{"unset", "", "", true},
+		{"generated keys in develop", app.EnvironmentDevelop, "", false},
+		{"generated keys in production", app.EnvironmentProduction, "", true},
+		{"keys dir in production", app.EnvironmentProduction, "/etc/abclinic/keys", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			t.Setenv("TOKEN_KEYS_DIR", tt.keysDir)
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = cfg.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency string
+		wantErr  bool
+	}{
+		{"default", "", false},
+		{"iso 4217", "USD", false},
+		{"lowercase", "usd", true},
+		{"unknown", "ABC", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg.Environment = app.EnvironmentDevelop
+			if tt.currency != "" {
+				cfg.PriceList.Currency = tt.currency
+			}
+
+			err = cfg.Validate()
+			if tt.wantErr != errors.Is(err, ErrInvalidConfig) {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
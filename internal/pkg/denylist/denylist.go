@@ -0,0 +1,70 @@
+// Package denylist rejects access tokens before they expire.
+//
+// Entries live in the Store of the process, MemoryStore is the only implementation, so a revocation is seen only
+// by the instance it was made on and is lost on restart. Running several instances needs a Store shared by them,
+// until then refresh tokens, which are deleted from the database, are the only revocation every instance sees.
+package denylist
+
+import (
+	"time"
+)
+
+const (
+	prefixToken = "jti:"
+	prefixUser  = "user:"
+)
+
+// Store keeps denylist entries until their ttl passes
+type Store interface {
+	Set(key string, value time.Time, ttl time.Duration)
+	Get(key string) (time.Time, bool)
+}
+
+// Denylist tracks revoked access tokens and users whose tokens must be re-issued
+type Denylist struct {
+	store Store
+}
+
+func New(store Store) *Denylist {
+	return &Denylist{
+		store: store,
+	}
+}
+
+// RevokeToken denies a single token identified by its jti claim
+func (d *Denylist) RevokeToken(jti string, ttl time.Duration) {
+	if jti == "" {
+		return
+	}
+
+	d.store.Set(prefixToken+jti, time.Now(), ttl)
+}
+
+func (d *Denylist) IsTokenRevoked(jti string) bool {
+	if jti == "" {
+		return false
+	}
+
+	_, ok := d.store.Get(prefixToken + jti)
+	return ok
+}
+
+// RevokeUser denies every token of the user issued before now
+func (d *Denylist) RevokeUser(userID string, ttl time.Duration) {
+	if userID == "" {
+		return
+	}
+
+	d.store.Set(prefixUser+userID, time.Now(), ttl)
+}
+
+// IsUserRevoked tells whether a token of the user issued at issuedAt was issued before the user was revoked,
+// issuedAt must be precise to nanoseconds for tokens issued right after the revocation to be accepted
+func (d *Denylist) IsUserRevoked(userID string, issuedAt time.Time) bool {
+	revokedAt, ok := d.store.Get(prefixUser + userID)
+	if !ok {
+		return false
+	}
+
+	return issuedAt.Before(revokedAt)
+}
@@ -0,0 +1,98 @@
+package denylist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsTokenRevoked(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	d := New(store)
+
+	d.RevokeToken("revoked", time.Minute)
+	d.RevokeToken("expired", -time.Second)
+	d.RevokeToken("", time.Minute)
+
+	tests := []struct {
+		name string
+		jti  string
+		want bool
+	}{
+		{"revoked", "revoked", true},
+		{"not revoked", "other", false},
+		{"expired entry", "expired", false},
+		{"no jti", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.IsTokenRevoked(tt.jti); got != tt.want {
+				t.Errorf("IsTokenRevoked(%q) = %v, want %v", tt.jti, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsUserRevoked(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	d := New(store)
+
+	d.RevokeUser("user", time.Minute)
+	d.RevokeUser("expired", -time.Second)
+	d.RevokeUser("", time.Minute)
+
+	revokedAt, ok := store.Get(prefixUser + "user")
+	if !ok {
+		t.Fatal("revocation of the user is not stored")
+	}
+
+	tests := []struct {
+		name     string
+		userID   string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"issued before", "user", revokedAt.Add(-time.Nanosecond), true},
+		{"issued at revocation", "user", revokedAt, false},
+		{"issued right after", "user", revokedAt.Add(time.Nanosecond), false},
+		{"issued without iat_ns", "user", time.Time{}, true},
+		{"other user", "other", revokedAt.Add(-time.Second), false},
+		{"expired entry", "expired", revokedAt.Add(-time.Second), false},
+		{"no user", "", revokedAt.Add(-time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.IsUserRevoked(tt.userID, tt.issuedAt); got != tt.want {
+				t.Errorf("IsUserRevoked(%q, %v) = %v, want %v", tt.userID, tt.issuedAt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	store := NewMemoryStore(10 * time.Millisecond)
+	defer store.Close()
+
+	store.Set("expiring", time.Now(), time.Millisecond)
+	store.Set("kept", time.Now(), time.Minute)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.RLock()
+		_, expiring := store.entries["expiring"]
+		_, kept := store.entries["kept"]
+		store.mu.RUnlock()
+
+		if !kept {
+			t.Fatal("entry within its ttl is dropped")
+		}
+		if !expiring {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired entry is not dropped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,75 @@
+package denylist
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     time.Time
+	expiresAt time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	done    chan struct{}
+}
+
+// NewMemoryStore returns in-memory store which drops expired entries every cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		done:    make(chan struct{}),
+	}
+
+	go s.cleanup(cleanupInterval)
+
+	return s
+}
+
+func (s *MemoryStore) Set(key string, value time.Time, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+func (s *MemoryStore) Get(key string) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return time.Time{}, false
+	}
+
+	return entry.value, true
+}
+
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, entry := range s.entries {
+				if now.After(entry.expiresAt) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/casbin/casbin/v2"
+)
+
+var ErrNoPolicy = errors.New("casbin policy is empty")
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Postgres pings a connection from the pool
+func Postgres(db pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		return db.Ping(ctx)
+	}
+}
+
+// Casbin fails until the policy is loaded, every request is denied without it
+func Casbin(enforcer *casbin.Enforcer) CheckFunc {
+	return func(ctx context.Context) error {
+		if len(enforcer.GetPolicy()) == 0 {
+			return ErrNoPolicy
+		}
+		return nil
+	}
+}
+
+// ObjectStorage checks that the bucket exists and credentials are accepted
+func ObjectStorage(client *s3.S3, bucket string) CheckFunc {
+	return func(ctx context.Context) error {
+		_, err := client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+			Bucket: aws.String(bucket),
+		})
+		return err
+	}
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFailing      = "failing"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc returns nil when the dependency is usable
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	Error   string
+}
+
+type Report struct {
+	Status string
+	Checks []CheckResult
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker runs registered readiness checks concurrently, each bounded by timeout
+type Checker struct {
+	timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a check, checks are reported in registration order
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Shutdown makes readiness fail so load balancers stop routing before the server closes
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) ShuttingDown() bool {
+	return c.shuttingDown.Load()
+}
+
+// Ready runs every check, report is failing if any check fails or shutdown has begun
+func (c *Checker) Ready(ctx context.Context) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make([]CheckResult, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, v := range c.checks {
+		wg.Add(1)
+		go func(i int, v check) {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, v)
+		}(i, v)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	if c.ShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, v check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := v.fn(ctx)
+
+	result := CheckResult{
+		Name:    v.name,
+		Status:  StatusOK,
+		Latency: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,127 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Hook is a named pair of callbacks, either may be nil
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in the order they were appended and stops started ones in reverse,
+// so a dependency appended first outlives everything built on top of it
+type Lifecycle struct {
+	logger  *zap.Logger
+	hooks   []Hook
+	started int
+}
+
+func New(logger *zap.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger}
+}
+
+// Append adds a hook, a hook appended by a running OnStart is started after the ones appended already. A hook
+// without OnStart is taken as started once appended, like a closer of a worker the appending OnStart has run.
+func (l *Lifecycle) Append(hook Hook) {
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start runs OnStart hooks, when one fails the hooks started before it are stopped with the same ctx, along with
+// closers the failed one appended before failing
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for l.started < len(l.hooks) {
+		hook := l.hooks[l.started]
+
+		if hook.OnStart != nil {
+			start := time.Now()
+			appended := len(l.hooks)
+			if err := hook.OnStart(ctx); err != nil {
+				startErr := fmt.Errorf("start %s: %w", hook.Name, err)
+				if stopErr := l.rollback(ctx, appended); stopErr != nil {
+					return fmt.Errorf("%w; rollback: %v", startErr, stopErr)
+				}
+				return startErr
+			}
+			l.logger.Debug("lifecycle hook started", zap.String("hook", hook.Name), zap.Duration("duration", time.Since(start)))
+		}
+
+		l.started++
+	}
+
+	return nil
+}
+
+// Stop runs OnStop hooks of started hooks in reverse order, a failing hook doesn't prevent the rest from stopping
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	errs := l.stop(ctx, l.hooks[:l.started])
+	l.started = 0
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// rollback drops hooks the failed one appended from index appended on and stops closers among them,
+// then the hooks started before it
+func (l *Lifecycle) rollback(ctx context.Context, appended int) error {
+	var closers []Hook
+	for _, hook := range l.hooks[appended:] {
+		if hook.OnStart == nil {
+			closers = append(closers, hook)
+		}
+	}
+	l.hooks = l.hooks[:appended]
+
+	errs := l.stop(ctx, closers)
+	errs = append(errs, l.stop(ctx, l.hooks[:l.started])...)
+	l.started = 0
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// stop runs OnStop of hooks in reverse order
+func (l *Lifecycle) stop(ctx context.Context, hooks []Hook) stopErrors {
+	var errs stopErrors
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := hook.OnStop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", hook.Name, err))
+			continue
+		}
+		l.logger.Debug("lifecycle hook stopped", zap.String("hook", hook.Name), zap.Duration("duration", time.Since(start)))
+	}
+
+	return errs
+}
+
+type stopErrors []error
+
+func (e stopErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e stopErrors) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,127 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+var errTest = errors.New("test error")
+
+// recorder builds hooks which record their start and stop
+type recorder struct {
+	started []string
+	stopped []string
+}
+
+func (r *recorder) hook(name string, onStart func() error) Hook {
+	hook := Hook{
+		Name: name,
+		OnStop: func(ctx context.Context) error {
+			r.stopped = append(r.stopped, name)
+			return nil
+		},
+	}
+	if onStart != nil {
+		hook.OnStart = func(ctx context.Context) error {
+			if err := onStart(); err != nil {
+				return err
+			}
+			r.started = append(r.started, name)
+			return nil
+		}
+	}
+	return hook
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name string
+		// appErr fails the hook after it appends its closer and server, they come after hooks appended already
+		appErr      error
+		wantStarted []string
+		wantStopped []string
+	}{
+		{
+			name:        "all started",
+			wantStarted: []string{"db", "app", "other", "server"},
+			wantStopped: []string{"server", "workers", "other", "app", "cache", "db"},
+		},
+		{
+			name:        "hook fails after appending hooks",
+			appErr:      errTest,
+			wantStarted: []string{"db"},
+			wantStopped: []string{"workers", "cache", "db"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			l := New(zap.NewNop())
+
+			ok := func() error { return nil }
+			l.Append(r.hook("db", ok))
+			l.Append(r.hook("cache", nil))
+			l.Append(r.hook("app", func() error {
+				// workers run once appended, the server waits for its OnStart
+				l.Append(r.hook("workers", nil))
+				l.Append(r.hook("server", ok))
+				return tt.appErr
+			}))
+			l.Append(r.hook("other", ok))
+
+			err := l.Start(context.Background())
+			if !errors.Is(err, tt.appErr) || (tt.appErr == nil) != (err == nil) {
+				t.Fatalf("Start() = %v, want %v", err, tt.appErr)
+			}
+			if err == nil {
+				if err := l.Stop(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !reflect.DeepEqual(r.started, tt.wantStarted) {
+				t.Errorf("started %v, want %v", r.started, tt.wantStarted)
+			}
+			if !reflect.DeepEqual(r.stopped, tt.wantStopped) {
+				t.Errorf("stopped %v, want %v", r.stopped, tt.wantStopped)
+			}
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	r := &recorder{}
+	l := New(zap.NewNop())
+
+	l.Append(r.hook("first", nil))
+	l.Append(Hook{
+		Name: "failing",
+		OnStop: func(ctx context.Context) error {
+			r.stopped = append(r.stopped, "failing")
+			return errTest
+		},
+	})
+	l.Append(r.hook("last", nil))
+
+	if err := l.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := l.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "stop failing: "+errTest.Error()) {
+		t.Errorf("Stop() = %v, want error of failing hook", err)
+	}
+	if want := []string{"last", "failing", "first"}; !reflect.DeepEqual(r.stopped, want) {
+		t.Errorf("stopped %v, want %v", r.stopped, want)
+	}
+
+	r.stopped = nil
+	if err := l.Stop(context.Background()); err != nil || r.stopped != nil {
+		t.Errorf("stopping again: got %v, stopped %v", err, r.stopped)
+	}
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// scope is shared by the request context and its descendants so fields added deeper in the chain,
+// like the authenticated user, also reach the access log written by the outermost middleware
+type scope struct {
+	logger *zap.Logger
+	// lazy fields are resolved on every FromContext call, zap encodes With fields eagerly
+	lazy []func() zap.Field
+}
+
+// NewContext stores a request-scoped logger in ctx
+func NewContext(ctx context.Context, l *zap.Logger, lazy ...func() zap.Field) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &scope{logger: l, lazy: lazy})
+}
+
+// With adds fields to the request-scoped logger in ctx, it's a no-op outside of a request
+func With(ctx context.Context, fields ...zap.Field) {
+	if s, ok := ctx.Value(ctxKey{}).(*scope); ok {
+		s.logger = s.logger.With(fields...)
+	}
+}
+
+// FromContext returns the request-scoped logger, outside of a request it falls back
+// to the global logger annotated with the trace of ctx
+func FromContext(ctx context.Context) *zap.Logger {
+	if s, ok := ctx.Value(ctxKey{}).(*scope); ok {
+		if len(s.lazy) == 0 {
+			return s.logger
+		}
+
+		fields := make([]zap.Field, 0, len(s.lazy))
+		for _, field := range s.lazy {
+			fields = append(fields, field())
+		}
+		return s.logger.With(fields...)
+	}
+
+	l := zap.L()
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		l = l.With(zap.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return l
+}
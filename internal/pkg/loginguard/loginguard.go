@@ -0,0 +1,173 @@
+package loginguard
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	prefixUsername = "username:"
+	prefixIP       = "ip:"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+// Attempts is a failed login attempts counter of a single username or ip
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store keeps counters until their ttl passes
+type Store interface {
+	// Update replaces attempts of key with the result of update atomically, a missing key has zero attempts.
+	// Attempts are kept for the returned ttl, zero ttl deletes them.
+	Update(key string, update func(attempts Attempts) (Attempts, time.Duration))
+	Delete(key string)
+}
+
+type Options struct {
+	// MaxAttempts is the number of failures per username before lockout
+	MaxAttempts int
+	// IPMaxAttempts is the number of failures per ip before lockout
+	IPMaxAttempts int
+	// BaseDelay is the backoff after the first failure, it doubles on every next one
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutDuration is how long a username or ip stays locked
+	LockoutDuration time.Duration
+	// Window is how long failures are remembered
+	Window time.Duration
+}
+
+// Guard limits failed login attempts per username and per ip
+type Guard struct {
+	store   Store
+	options Options
+}
+
+func New(store Store, options Options) *Guard {
+	return &Guard{
+		store:   store,
+		options: options,
+	}
+}
+
+// Reserve counts a login attempt of the username from the ip before credentials are checked, so concurrent
+// attempts wait for each other's backoff. It returns ErrTooManyAttempts with the time left to wait if login is
+// not allowed yet, nothing is counted then. A reserved attempt ends with Fail, Succeed or Release.
+func (g *Guard) Reserve(username, ip string) (time.Duration, error) {
+	if wait := g.reserve(prefixUsername + username); wait > 0 {
+		return wait, ErrTooManyAttempts
+	}
+
+	if wait := g.reserve(prefixIP + ip); wait > 0 {
+		g.release(prefixUsername + username)
+		return wait, ErrTooManyAttempts
+	}
+
+	return 0, nil
+}
+
+// Fail keeps the reserved attempt as a failure and locks the username or ip out once they reach their limit
+func (g *Guard) Fail(username, ip string) {
+	g.fail(prefixUsername+username, g.options.MaxAttempts)
+	g.fail(prefixIP+ip, g.options.IPMaxAttempts)
+}
+
+// Succeed forgets failures of the username and the reserved attempt of the ip, other ip failures expire on their own
+func (g *Guard) Succeed(username, ip string) {
+	g.store.Delete(prefixUsername + username)
+	g.release(prefixIP + ip)
+}
+
+// Release forgets the reserved attempt when credentials weren't checked, e.g. on an internal error
+func (g *Guard) Release(username, ip string) {
+	g.release(prefixUsername + username)
+	g.release(prefixIP + ip)
+}
+
+// Unlock lifts username lockout
+func (g *Guard) Unlock(username string) {
+	g.store.Delete(prefixUsername + username)
+}
+
+// reserve counts an attempt unless the key has to wait, it returns the time left to wait
+func (g *Guard) reserve(key string) time.Duration {
+	var wait time.Duration
+	g.store.Update(key, func(attempts Attempts) (Attempts, time.Duration) {
+		now := time.Now()
+
+		if left := g.nextAttemptAt(attempts).Sub(now); left > 0 {
+			wait = left
+			return attempts, g.ttl(attempts, now)
+		}
+
+		attempts.Failures++
+		attempts.LastFailure = now
+
+		return attempts, g.ttl(attempts, now)
+	})
+
+	return wait
+}
+
+func (g *Guard) release(key string) {
+	g.store.Update(key, func(attempts Attempts) (Attempts, time.Duration) {
+		now := time.Now()
+
+		if attempts.Failures > 0 {
+			attempts.Failures--
+		}
+		if attempts.Failures == 0 && !attempts.LockedUntil.After(now) {
+			return Attempts{}, 0
+		}
+
+		return attempts, g.ttl(attempts, now)
+	})
+}
+
+func (g *Guard) fail(key string, maxAttempts int) {
+	g.store.Update(key, func(attempts Attempts) (Attempts, time.Duration) {
+		now := time.Now()
+		// backoff starts once the failure is known, not when the attempt was reserved
+		attempts.LastFailure = now
+
+		if maxAttempts > 0 && attempts.Failures >= maxAttempts {
+			attempts.LockedUntil = now.Add(g.options.LockoutDuration)
+			// counting starts over once lockout is over
+			attempts.Failures = 0
+		}
+
+		return attempts, g.ttl(attempts, now)
+	})
+}
+
+// ttl keeps failures for the window and lockout until it's over
+func (g *Guard) ttl(attempts Attempts, now time.Time) time.Duration {
+	ttl := g.options.Window
+	if lockout := attempts.LockedUntil.Sub(now); lockout > ttl {
+		ttl = lockout
+	}
+
+	return ttl
+}
+
+func (g *Guard) nextAttemptAt(attempts Attempts) time.Time {
+	if attempts.Failures == 0 {
+		return attempts.LockedUntil
+	}
+
+	delay := g.options.BaseDelay << (attempts.Failures - 1)
+	if delay > g.options.MaxDelay || delay <= 0 {
+		delay = g.options.MaxDelay
+	}
+
+	next := attempts.LastFailure.Add(delay)
+	if attempts.LockedUntil.After(next) {
+		return attempts.LockedUntil
+	}
+
+	return next
+}
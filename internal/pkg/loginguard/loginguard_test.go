@@ -0,0 +1,220 @@
+package loginguard
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestGuard(t *testing.T, options Options) (*Guard, *MemoryStore) {
+	t.Helper()
+
+	store := NewMemoryStore(time.Minute)
+	t.Cleanup(store.Close)
+
+	return New(store, options), store
+}
+
+func setAttempts(store *MemoryStore, key string, attempts Attempts) {
+	store.Update(key, func(Attempts) (Attempts, time.Duration) {
+		return attempts, time.Hour
+	})
+}
+
+func getAttempts(store *MemoryStore, key string) Attempts {
+	var got Attempts
+	store.Update(key, func(attempts Attempts) (Attempts, time.Duration) {
+		got = attempts
+		if attempts == (Attempts{}) {
+			return attempts, 0
+		}
+		return attempts, time.Hour
+	})
+	return got
+}
+
+var testOptions = Options{
+	MaxAttempts:     3,
+	IPMaxAttempts:   10,
+	BaseDelay:       time.Minute,
+	MaxDelay:        4 * time.Minute,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+func TestReserve(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		key      string
+		attempts Attempts
+		// wait is the expected time left, the check allows a second of the test run
+		wait time.Duration
+	}{
+		{name: "no failures"},
+		{name: "within backoff", key: prefixUsername + "user", attempts: Attempts{Failures: 1, LastFailure: now.Add(-30 * time.Second)}, wait: 30 * time.Second},
+		{name: "after backoff", key: prefixUsername + "user", attempts: Attempts{Failures: 1, LastFailure: now.Add(-61 * time.Second)}},
+		{name: "backoff doubles", key: prefixUsername + "user", attempts: Attempts{Failures: 2, LastFailure: now.Add(-90 * time.Second)}, wait: 30 * time.Second},
+		{name: "backoff is capped", key: prefixUsername + "user", attempts: Attempts{Failures: 40, LastFailure: now.Add(-3 * time.Minute)}, wait: time.Minute},
+		{name: "locked out", key: prefixUsername + "user", attempts: Attempts{LockedUntil: now.Add(10 * time.Minute)}, wait: 10 * time.Minute},
+		{name: "lockout is over", key: prefixUsername + "user", attempts: Attempts{LockedUntil: now.Add(-time.Second)}},
+		{name: "ip locked out", key: prefixIP + "10.0.0.1", attempts: Attempts{LockedUntil: now.Add(10 * time.Minute)}, wait: 10 * time.Minute},
+		{name: "other ip locked out", key: prefixIP + "10.0.0.2", attempts: Attempts{LockedUntil: now.Add(10 * time.Minute)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, store := newTestGuard(t, testOptions)
+			if tt.key != "" {
+				setAttempts(store, tt.key, tt.attempts)
+			}
+			before := getAttempts(store, prefixUsername+"user")
+
+			wait, err := guard.Reserve("user", "10.0.0.1")
+
+			if tt.wait == 0 {
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				if got := getAttempts(store, prefixUsername+"user").Failures; got != before.Failures+1 {
+					t.Errorf("attempt is not counted: %d failures", got)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrTooManyAttempts) {
+				t.Fatalf("got error %v, want %v", err, ErrTooManyAttempts)
+			}
+			if wait > tt.wait || wait < tt.wait-time.Second {
+				t.Errorf("got wait %v, want %v", wait, tt.wait)
+			}
+			if got := getAttempts(store, prefixUsername+"user"); got != before {
+				t.Errorf("rejected attempt is counted: %+v, was %+v", got, before)
+			}
+		})
+	}
+}
+
+func TestLockout(t *testing.T) {
+	options := testOptions
+	options.BaseDelay = time.Nanosecond
+	options.MaxDelay = time.Nanosecond
+	guard, _ := newTestGuard(t, options)
+
+	for i := 0; i < options.MaxAttempts; i++ {
+		time.Sleep(time.Millisecond)
+		if _, err := guard.Reserve("user", "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: got error %v", i+1, err)
+		}
+		guard.Fail("user", "10.0.0.1")
+	}
+
+	time.Sleep(time.Millisecond)
+	wait, err := guard.Reserve("user", "10.0.0.2")
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("after %d failures: got error %v, want %v", options.MaxAttempts, err, ErrTooManyAttempts)
+	}
+	if wait < options.LockoutDuration-time.Second {
+		t.Errorf("got wait %v, want lockout %v", wait, options.LockoutDuration)
+	}
+
+	if _, err := guard.Reserve("other", "10.0.0.1"); err != nil {
+		t.Errorf("other username from the same ip: got error %v", err)
+	}
+
+	guard.Unlock("user")
+	if _, err := guard.Reserve("user", "10.0.0.3"); err != nil {
+		t.Errorf("after unlock: got error %v", err)
+	}
+}
+
+func TestSucceedAndRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(g *Guard, username, ip string)
+	}{
+		{"succeed", (*Guard).Succeed},
+		{"release", (*Guard).Release},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard, store := newTestGuard(t, testOptions)
+
+			if _, err := guard.Reserve("user", "10.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+			tt.finish(guard, "user", "10.0.0.1")
+
+			for _, key := range []string{prefixUsername + "user", prefixIP + "10.0.0.1"} {
+				if got := getAttempts(store, key); got != (Attempts{}) {
+					t.Errorf("%s: attempt is kept: %+v", key, got)
+				}
+			}
+		})
+	}
+}
+
+func TestConcurrentReserve(t *testing.T) {
+	guard, store := newTestGuard(t, testOptions)
+
+	const attempts = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := guard.Reserve("user", "10.0.0.1"); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 1 {
+		t.Errorf("%d concurrent attempts are allowed, want 1", allowed)
+	}
+	if got := getAttempts(store, prefixUsername+"user").Failures; got != 1 {
+		t.Errorf("%d attempts are counted, want 1", got)
+	}
+}
+
+func TestMemoryStoreUpdate(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+
+	const updates = 100
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			store.Update("key", func(attempts Attempts) (Attempts, time.Duration) {
+				attempts.Failures++
+				return attempts, time.Minute
+			})
+		}()
+	}
+	wg.Wait()
+
+	if got := getAttempts(store, "key").Failures; got != updates {
+		t.Errorf("got %d failures, want %d", got, updates)
+	}
+
+	store.Update("expired", func(attempts Attempts) (Attempts, time.Duration) {
+		attempts.Failures++
+		return attempts, time.Nanosecond
+	})
+	time.Sleep(time.Millisecond)
+	if got := getAttempts(store, "expired"); got != (Attempts{}) {
+		t.Errorf("expired attempts are kept: %+v", got)
+	}
+}
@@ -0,0 +1,83 @@
+package loginguard
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	attempts  Attempts
+	expiresAt time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	done    chan struct{}
+}
+
+// NewMemoryStore returns in-memory store which drops expired counters every cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		done:    make(chan struct{}),
+	}
+
+	go s.cleanup(cleanupInterval)
+
+	return s
+}
+
+func (s *MemoryStore) Update(key string, update func(attempts Attempts) (Attempts, time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	var attempts Attempts
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expiresAt) {
+		attempts = entry.attempts
+	}
+
+	attempts, ttl := update(attempts)
+	if ttl <= 0 {
+		delete(s.entries, key)
+		return
+	}
+
+	s.entries[key] = memoryEntry{
+		attempts:  attempts,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, entry := range s.entries {
+				if now.After(entry.expiresAt) {
+					delete(s.entries, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "abclinic"
+
+// Metrics holds application collectors registered on a private registry
+type Metrics struct {
+	registry *prometheus.Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	AuthzDecisions      *prometheus.CounterVec
+	UploadSize          prometheus.Histogram
+	UploadDuration      *prometheus.HistogramVec
+	TokensIssued        *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by chi route pattern and status.",
+		}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by chi route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		AuthzDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "authz_decisions_total",
+			Help:      "Casbin enforcement decisions by role.",
+		}, []string{"role", "decision"}),
+		UploadSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upload_size_bytes",
+			Help:      "Size of files uploaded to object storage.",
+			Buckets:   prometheus.ExponentialBuckets(16*1024, 4, 8),
+		}),
+		UploadDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upload_duration_seconds",
+			Help:      "Duration of uploads to object storage by result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		TokensIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tokens_issued_total",
+			Help:      "Access and refresh token pairs issued by flow.",
+		}, []string{"flow"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.HTTPRequests,
+		m.HTTPRequestDuration,
+		m.AuthzDecisions,
+		m.UploadSize,
+		m.UploadDuration,
+		m.TokensIssued,
+	)
+
+	return m
+}
+
+// RegisterPool exposes connection pool statistics
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
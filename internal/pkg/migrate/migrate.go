@@ -0,0 +1,161 @@
+// Package migrate applies embedded schema migrations, it keeps the schema_migrations table of the migrate CLI
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/pkg/config"
+	"github.com/AsaHero/abclinic/internal/pkg/postgres"
+	"github.com/AsaHero/abclinic/migrations"
+	gomigrate "github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/pgx"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	pgxdriver "github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/stdlib"
+	"go.uber.org/zap"
+)
+
+var ErrNoMigrations = errors.New("no migrations found")
+
+// Migration is a single embedded migration and whether it's applied
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Status describes the schema version, Version is zero when nothing is applied yet
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Migrator runs migrations holding a postgres advisory lock, so concurrent instances apply them one at a time
+type Migrator struct {
+	migrate *gomigrate.Migrate
+	source  source.Driver
+}
+
+func New(cfg *config.Config, logger *zap.Logger) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read embedded migrations: %w", err)
+	}
+
+	connConfig, err := pgxdriver.ParseConfig(postgres.GetStrConfig(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database config: %w", err)
+	}
+
+	db := stdlib.OpenDB(*connConfig)
+	driver, err := pgx.WithInstance(db, &pgx.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to connect database: %w", err)
+	}
+
+	m, err := gomigrate.NewWithInstance("iofs", src, "pgx", driver)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	if logger != nil {
+		m.Log = &migrateLogger{logger: logger}
+	}
+
+	return &Migrator{migrate: m, source: src}, nil
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up() error {
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, gomigrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down rolls back steps migrations, every applied migration when steps is zero
+func (m *Migrator) Down(steps int) error {
+	var err error
+	if steps > 0 {
+		err = m.migrate.Steps(-steps)
+	} else {
+		err = m.migrate.Down()
+	}
+
+	if err != nil && !errors.Is(err, gomigrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Force sets version and clears dirty flag without running migrations, it's used to recover a failed migration
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+func (m *Migrator) Status() (*Status, error) {
+	status := &Status{}
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, gomigrate.ErrNilVersion) {
+		return nil, err
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	v, err := m.source.First()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNoMigrations
+		}
+		return nil, err
+	}
+
+	for {
+		r, name, err := m.source.ReadUp(v)
+		if err != nil {
+			return nil, err
+		}
+		r.Close()
+
+		// a dirty version failed half way, it's not counted as applied
+		applied := v < version || (v == version && !dirty)
+		status.Migrations = append(status.Migrations, Migration{Version: v, Name: name, Applied: applied})
+
+		v, err = m.source.Next(v)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.migrate.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+	return dbErr
+}
+
+type migrateLogger struct {
+	logger *zap.Logger
+}
+
+func (l *migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+func (l *migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,100 @@
+package money
+
+import (
+	"math/big"
+	"strconv"
+)
+
+// exponents are ISO 4217 minor units of active currencies, funds and precious metals without minor units are left out
+var exponents = map[string]int{
+	"AED": 2, "AFN": 2, "ALL": 2, "AMD": 2, "ANG": 2, "AOA": 2, "ARS": 2, "AUD": 2,
+	"AWG": 2, "AZN": 2, "BAM": 2, "BBD": 2, "BDT": 2, "BGN": 2, "BMD": 2, "BND": 2,
+	"BOB": 2, "BOV": 2, "BRL": 2, "BSD": 2, "BTN": 2, "BWP": 2, "BYN": 2, "BZD": 2,
+	"CAD": 2, "CDF": 2, "CHE": 2, "CHF": 2, "CHW": 2, "CNY": 2, "COP": 2, "COU": 2,
+	"CRC": 2, "CUP": 2, "CVE": 2, "CZK": 2, "DKK": 2, "DOP": 2, "DZD": 2, "EGP": 2,
+	"ERN": 2, "ETB": 2, "EUR": 2, "FJD": 2, "FKP": 2, "GBP": 2, "GEL": 2, "GHS": 2,
+	"GIP": 2, "GMD": 2, "GTQ": 2, "GYD": 2, "HKD": 2, "HNL": 2, "HTG": 2, "HUF": 2,
+	"IDR": 2, "ILS": 2, "INR": 2, "IRR": 2, "JMD": 2, "KES": 2, "KGS": 2, "KHR": 2,
+	"KPW": 2, "KYD": 2, "KZT": 2, "LAK": 2, "LBP": 2, "LKR": 2, "LRD": 2, "LSL": 2,
+	"MAD": 2, "MDL": 2, "MGA": 2, "MKD": 2, "MMK": 2, "MNT": 2, "MOP": 2, "MRU": 2,
+	"MUR": 2, "MVR": 2, "MWK": 2, "MXN": 2, "MXV": 2, "MYR": 2, "MZN": 2, "NAD": 2,
+	"NGN": 2, "NIO": 2, "NOK": 2, "NPR": 2, "NZD": 2, "PAB": 2, "PEN": 2, "PGK": 2,
+	"PHP": 2, "PKR": 2, "PLN": 2, "QAR": 2, "RON": 2, "RSD": 2, "RUB": 2, "SAR": 2,
+	"SBD": 2, "SCR": 2, "SDG": 2, "SEK": 2, "SGD": 2, "SHP": 2, "SLE": 2, "SOS": 2,
+	"SRD": 2, "SSP": 2, "STN": 2, "SVC": 2, "SYP": 2, "SZL": 2, "THB": 2, "TJS": 2,
+	"TMT": 2, "TOP": 2, "TRY": 2, "TTD": 2, "TWD": 2, "TZS": 2, "UAH": 2, "USD": 2,
+	"USN": 2, "UYU": 2, "UZS": 2, "VED": 2, "VES": 2, "WST": 2, "XCD": 2, "XCG": 2,
+	"YER": 2, "ZAR": 2, "ZMW": 2, "ZWG": 2,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0,
+	"XPF": 0,
+	"CLF": 4, "UYW": 4,
+}
+
+// Valid reports whether currency is an active ISO 4217 alphabetic code
+func Valid(currency string) bool {
+	_, ok := exponents[currency]
+	return ok
+}
+
+// Exponent is the number of digits of minor units of the currency, e.g. 2 for cents of USD.
+// Codes rejected by Valid get 2
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Convert converts amount in minor units of from to minor units of to, one unit of from is rate units of to.
+// The result is rounded half away from zero to minor units of to.
+func Convert(amount int64, from, to string, rate float64) int64 {
+	return convert(amount, from, to, decimal(rate))
+}
+
+// ConvertInverse converts with the rate of the opposite pair, one unit of to is rate units of from
+func ConvertInverse(amount int64, from, to string, rate float64) int64 {
+	return convert(amount, from, to, new(big.Rat).Inv(decimal(rate)))
+}
+
+func convert(amount int64, from, to string, rate *big.Rat) int64 {
+	value := new(big.Rat).SetInt64(amount)
+	value.Mul(value, rate)
+
+	// minor units of from to minor units of to
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(Exponent(to)-Exponent(from)))), nil))
+	if Exponent(to) >= Exponent(from) {
+		value.Mul(value, scale)
+	} else {
+		value.Quo(value, scale)
+	}
+
+	return round(value).Int64()
+}
+
+// decimal reads the rate as the shortest decimal of the float, so rates entered as 0.1 are exact
+func decimal(rate float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	return r
+}
+
+// round rounds half away from zero
+func round(value *big.Rat) *big.Int {
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+
+	// rem has the sign of value, compare |2*rem| with the denominator
+	rem.Mul(rem, big.NewInt(2))
+	if rem.CmpAbs(value.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(int64(value.Sign())))
+	}
+
+	return quo
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
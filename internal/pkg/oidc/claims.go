@@ -0,0 +1,46 @@
+package oidc
+
+import (
+	"encoding/json"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are id token claims, claims which are not listed are kept for Strings
+type Claims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	PreferredUsername string `json:"preferred_username"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	jwt.RegisteredClaims
+
+	raw map[string]interface{}
+}
+
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type claims Claims
+	if err := json.Unmarshal(data, (*claims)(c)); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &c.raw)
+}
+
+// Strings returns claim as a list, a single string value is a list of one
+func (c *Claims) Strings(name string) []string {
+	switch v := c.raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNonceMismatch  = errors.New("id token nonce mismatch")
+	ErrNoIDToken      = errors.New("token response has no id_token")
+	ErrUnknownKeyID   = errors.New("id token signed by unknown key")
+	ErrIssuerMismatch = errors.New("discovered issuer does not match configured one")
+)
+
+type Options struct {
+	// IssuerURL is used to discover endpoints at <issuer>/.well-known/openid-configuration
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	Leeway       time.Duration
+	HTTPClient   *http.Client
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider runs authorization code flow with PKCE against an OpenID Connect identity provider
+type Provider struct {
+	options Options
+
+	mu        sync.RWMutex
+	discovery *discovery
+	keys      map[string]interface{}
+}
+
+func NewProvider(options Options) *Provider {
+	if options.HTTPClient == nil {
+		options.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(options.Scopes) == 0 {
+		options.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	return &Provider{
+		options: options,
+		keys:    make(map[string]interface{}),
+	}
+}
+
+// AuthCodeURL returns identity provider URL the user is sent to
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.options.ClientID)
+	query.Set("redirect_uri", p.options.RedirectURL)
+	query.Set("scope", strings.Join(p.options.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems authorization code and returns verified id token claims
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.options.RedirectURL)
+	form.Set("client_id", p.options.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.options.ClientSecret != "" {
+		form.Set("client_secret", p.options.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var response struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.do(req, &response); err != nil {
+		if response.ErrorDescription != "" {
+			return nil, fmt.Errorf("token exchange: %s: %s", response.Error, response.ErrorDescription)
+		}
+		if response.Error != "" {
+			return nil, fmt.Errorf("token exchange: %s", response.Error)
+		}
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+
+	if response.IDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	return p.verify(ctx, response.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.options.ClientID),
+		jwt.WithLeeway(p.options.Leeway),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims := &Claims{}
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.RLock()
+	d := p.discovery
+	p.mu.RUnlock()
+
+	if d != nil {
+		return d, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.options.IssuerURL, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d = &discovery{}
+	if err := p.do(req, d); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(p.options.IssuerURL, "/") {
+		return nil, fmt.Errorf("%w: %s", ErrIssuerMismatch, d.Issuer)
+	}
+
+	p.mu.Lock()
+	p.discovery = d
+	p.mu.Unlock()
+
+	return d, nil
+}
+
+// key returns verification key by kid, JWKS is refetched once for unknown kid to follow key rotation
+func (p *Provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	p.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jsonWebKeySet
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys, err := set.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok = keys[kid]; !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.options.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	// error responses of token endpoint are json as well
+	jsonErr := json.Unmarshal(body, v)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return jsonErr
+}
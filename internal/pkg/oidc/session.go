@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"sync"
+	"time"
+)
+
+// Session is kept between redirect to identity provider and callback, keyed by state
+type Session struct {
+	Nonce        string
+	CodeVerifier string
+}
+
+type SessionStore interface {
+	Save(state string, session Session, ttl time.Duration)
+	// Take returns session and removes it, so state can be used once
+	Take(state string) (Session, bool)
+}
+
+type memoryEntry struct {
+	session   Session
+	expiresAt time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	done    chan struct{}
+}
+
+// NewMemoryStore returns in-memory store which drops expired sessions every cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		done:    make(chan struct{}),
+	}
+
+	go s.cleanup(cleanupInterval)
+
+	return s
+}
+
+func (s *MemoryStore) Save(state string, session Session, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[state] = memoryEntry{
+		session:   session,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+func (s *MemoryStore) Take(state string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[state]
+	if !ok {
+		return Session{}, false
+	}
+
+	delete(s.entries, state)
+
+	if time.Now().After(entry.expiresAt) {
+		return Session{}, false
+	}
+
+	return entry.session, true
+}
+
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for state, entry := range s.entries {
+				if now.After(entry.expiresAt) {
+					delete(s.entries, state)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
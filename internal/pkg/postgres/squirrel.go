@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 )
@@ -29,6 +30,11 @@ func (s *Squirrel) ILike(key string, value interface{}) sq.ILike {
 	return sq.ILike{key: value}
 }
 
+// Contains matches value as a substring of key case-insensitively, wildcards in value match themselves
+func (s *Squirrel) Contains(key string, value string) sq.ILike {
+	return sq.ILike{key: "%" + likeEscaper.Replace(value) + "%"}
+}
+
 func (s *Squirrel) NotEqual(key string, value interface{}) sq.NotEq {
 	return sq.NotEq{key: value}
 }
@@ -65,6 +71,30 @@ func (s *Squirrel) Lt(key string, value interface{}) sq.Lt {
 	return sq.Lt{key: value}
 }
 
+func (s *Squirrel) GtOrEq(key string, value interface{}) sq.GtOrEq {
+	return sq.GtOrEq{key: value}
+}
+
+// Range matches key from inclusive to exclusive, zero bound is not compared
+func (s *Squirrel) Range(key string, from, to time.Time) sq.And {
+	var cond sq.And
+	if !from.IsZero() {
+		cond = append(cond, s.GtOrEq(key, from))
+	}
+	if !to.IsZero() {
+		cond = append(cond, s.Lt(key, to))
+	}
+	return cond
+}
+
+// Effective matches periods from fromKey inclusive to toKey exclusive containing at, NULL toKey leaves a period open
+func (s *Squirrel) Effective(fromKey, toKey string, at time.Time) sq.And {
+	return sq.And{
+		sq.LtOrEq{fromKey: at},
+		sq.Or{s.Equal(toKey, nil), s.Gt(toKey, at)},
+	}
+}
+
 func (s *Squirrel) Expr(sql string, args ...interface{}) sq.Sqlizer {
 	return sq.Expr(sql, args)
 }
@@ -80,6 +110,9 @@ func (s *Squirrel) JSONPathWhere(fieldName, jsonbOp, searchField, value string)
 	return b.String(), nil
 }
 
+// likeEscaper escapes with backslash, the default escape character of LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 type EqualStr string
 
 func (e EqualStr) ToSql() (sql string, args []interface{}, err error) {
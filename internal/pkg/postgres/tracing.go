@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const attributeRows = attribute.Key("db.rows")
+
+// Query, QueryRow, Exec and BeginFunc shadow pool methods to wrap every repository query in a span
+
+func (p *PostgresDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return query(ctx, p.Pool, sql, args...)
+}
+
+func (p *PostgresDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return queryRow(ctx, p.Pool, sql, args...)
+}
+
+func (p *PostgresDB) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return exec(ctx, p.Pool, sql, arguments...)
+}
+
+// BeginFunc runs f in a transaction span, queries of tx are its children
+func (p *PostgresDB) BeginFunc(ctx context.Context, f func(pgx.Tx) error) error {
+	return beginFunc(ctx, p.Pool.BeginFunc, f)
+}
+
+// querier is what the pool and transactions have in common
+type querier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+}
+
+func query(ctx context.Context, q querier, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func queryRow(ctx context.Context, q querier, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	return &tracedRow{Row: q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func exec(ctx context.Context, q querier, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startQuerySpan(ctx, sql)
+
+	tag, err := q.Exec(ctx, sql, arguments...)
+	if err == nil {
+		span.SetAttributes(attributeRows.Int64(tag.RowsAffected()))
+	}
+	tracing.End(span, err)
+
+	return tag, err
+}
+
+// beginFunc wraps the transaction begun by begin in a span ending after commit or rollback
+func beginFunc(ctx context.Context, begin func(context.Context, func(pgx.Tx) error) error, f func(pgx.Tx) error) error {
+	ctx, span := tracing.Start(ctx, "transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+
+	err := begin(ctx, func(tx pgx.Tx) error {
+		return f(&tracedTx{Tx: tx, span: span})
+	})
+	tracing.End(span, err)
+
+	return err
+}
+
+// tracedTx wraps queries in spans under the transaction span. Callers pass the context they began
+// the transaction with, so the parent is taken from the transaction rather than from ctx
+type tracedTx struct {
+	pgx.Tx
+	span trace.Span
+}
+
+func (t *tracedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return query(trace.ContextWithSpan(ctx, t.span), t.Tx, sql, args...)
+}
+
+func (t *tracedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return queryRow(trace.ContextWithSpan(ctx, t.span), t.Tx, sql, args...)
+}
+
+func (t *tracedTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return exec(trace.ContextWithSpan(ctx, t.span), t.Tx, sql, arguments...)
+}
+
+// BeginFunc runs f in a savepoint traced like a transaction
+func (t *tracedTx) BeginFunc(ctx context.Context, f func(pgx.Tx) error) error {
+	return beginFunc(trace.ContextWithSpan(ctx, t.span), t.Tx.BeginFunc, f)
+}
+
+func startQuerySpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	operation, table := statement(sql)
+
+	name := strings.TrimSpace(operation + " " + table)
+	if name == "" {
+		name = "query"
+	}
+
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(sql),
+			semconv.DBOperation(operation),
+			semconv.DBSQLTable(table),
+		),
+	)
+}
+
+// statement derives operation and table from sql built by squirrel, e.g. SELECT and dentists
+func statement(sql string) (operation, table string) {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	operation = strings.ToUpper(fields[0])
+
+	var keyword string
+	switch operation {
+	case "SELECT", "DELETE":
+		keyword = "FROM"
+	case "INSERT":
+		keyword = "INTO"
+	case "UPDATE":
+		return operation, tableName(fields, 1)
+	default:
+		return operation, ""
+	}
+
+	for i, field := range fields {
+		if strings.EqualFold(field, keyword) {
+			return operation, tableName(fields, i+1)
+		}
+	}
+
+	return operation, ""
+}
+
+func tableName(fields []string, i int) string {
+	if i >= len(fields) {
+		return ""
+	}
+	return strings.Trim(fields[i], `"(),;`)
+}
+
+// tracedRows ends span once rows are exhausted or closed
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	count int
+	ended bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+
+	r.span.SetAttributes(attributeRows.Int(r.count))
+	tracing.End(r.span, r.Rows.Err())
+}
+
+type tracedRow struct {
+	pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		// not found is an expected outcome of lookups
+		r.span.SetAttributes(attributeRows.Int(0))
+		tracing.End(r.span, nil)
+		return err
+	}
+	if err == nil {
+		r.span.SetAttributes(attributeRows.Int(1))
+	}
+	tracing.End(r.span, err)
+
+	return err
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeTx answers Exec, the rest of pgx.Tx isn't called
+type fakeTx struct {
+	pgx.Tx
+}
+
+func (fakeTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag("INSERT 0 1"), nil
+}
+
+func TestBeginFunc(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	begin := func(ctx context.Context, f func(pgx.Tx) error) error {
+		return f(fakeTx{})
+	}
+	failed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "commit"},
+		{name: "rollback", err: failed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+			ctx := context.Background()
+			err := beginFunc(ctx, begin, func(tx pgx.Tx) error {
+				// repositories query with the context the transaction was begun with
+				if _, err := tx.Exec(ctx, "INSERT INTO service_prices (guid) VALUES ($1)", "guid"); err != nil {
+					return err
+				}
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want 2", len(spans))
+			}
+			query, transaction := spans[0], spans[1]
+			if query.Name() != "INSERT service_prices" || transaction.Name() != "transaction" {
+				t.Fatalf("got spans %q and %q", query.Name(), transaction.Name())
+			}
+			if query.Parent().SpanID() != transaction.SpanContext().SpanID() {
+				t.Errorf("query span is not a child of the transaction span")
+			}
+
+			wantStatus := codes.Unset
+			if tt.err != nil {
+				wantStatus = codes.Error
+			}
+			if got := transaction.Status().Code; got != wantStatus {
+				t.Errorf("got transaction status %v, want %v", got, wantStatus)
+			}
+		})
+	}
+}
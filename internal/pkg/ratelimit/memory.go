@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket refills completely and can be dropped
+	full time.Time
+}
+
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	done    chan struct{}
+}
+
+// NewMemoryStore returns in-memory token buckets, full buckets are dropped every cleanupInterval
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+		done:    make(chan struct{}),
+	}
+
+	go s.cleanup(cleanupInterval)
+
+	return s
+}
+
+func (s *MemoryStore) Take(key string, limit Limit) Result {
+	now := time.Now()
+	capacity := float64(limit.Requests)
+	// tokens refilled per second
+	rate := capacity / limit.Period.Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = refill(b, now, capacity, rate)
+	b.updated = now
+
+	result := Result{
+		Limit: limit.Requests,
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((capacity - b.tokens) / rate)
+	b.full = now.Add(result.Reset)
+
+	return result
+}
+
+func (s *MemoryStore) Peek(key string, limit Limit) Result {
+	now := time.Now()
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Period.Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := capacity
+	if b, ok := s.buckets[key]; ok {
+		tokens = refill(b, now, capacity, rate)
+	}
+
+	result := Result{
+		Allowed:   tokens >= 1,
+		Limit:     limit.Requests,
+		Remaining: int(tokens),
+		Reset:     seconds((capacity - tokens) / rate),
+	}
+	if !result.Allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for key, b := range s.buckets {
+				if now.After(b.full) {
+					delete(s.buckets, key)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// refill returns tokens of the bucket at now
+func refill(b *bucket, now time.Time, capacity, rate float64) float64 {
+	return math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
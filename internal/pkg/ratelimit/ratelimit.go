@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// KeyIP shares the bucket between all requests from an address
+	KeyIP = "ip"
+	// KeyIdentity uses api key or user of the request and falls back to the address for anonymous ones
+	KeyIdentity = "identity"
+)
+
+// Limit allows Requests per Period with bursts up to Requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Result is the state of a bucket after taking a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token when request is not allowed
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	Take(key string, limit Limit) Result
+	// Peek returns the state of the bucket without taking a token, Allowed tells whether Take would allow
+	Peek(key string, limit Limit) Result
+}
+
+// Rule applies Limit to requests with Method and path matching Pattern,
+// "{name}" matches one path segment, trailing "*" matches the rest, "*" method matches any.
+type Rule struct {
+	Method  string
+	Pattern string
+	Limit   Limit
+	Key     string
+}
+
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+func New(store Store, rules []Rule) *Limiter {
+	return &Limiter{
+		store: store,
+		rules: rules,
+	}
+}
+
+// Match returns the first rule matching the request
+func (l *Limiter) Match(method, path string) (Rule, bool) {
+	for _, rule := range l.rules {
+		if (rule.Method == "*" || rule.Method == method) && matchPattern(rule.Pattern, path) {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Take takes a token from the bucket of identity under rule
+func (l *Limiter) Take(rule Rule, identity string) Result {
+	return l.store.Take(rule.Method+" "+rule.Pattern+"|"+identity, rule.Limit)
+}
+
+// FailureLimiter limits failures of an identity, e.g. failed api key lookups of an address. Attempts are checked
+// before they are made and only failed ones take a token, so successful ones are never refused on their own.
+type FailureLimiter struct {
+	store Store
+	name  string
+	limit Limit
+}
+
+func NewFailureLimiter(store Store, name string, limit Limit) *FailureLimiter {
+	return &FailureLimiter{
+		store: store,
+		name:  name,
+		limit: limit,
+	}
+}
+
+// Check tells whether identity has failures left, RetryAfter of a refused one is the time until it has one
+func (f *FailureLimiter) Check(identity string) Result {
+	return f.store.Peek(f.name+"|"+identity, f.limit)
+}
+
+// Fail counts a failure of identity
+func (f *FailureLimiter) Fail(identity string) {
+	f.store.Take(f.name+"|"+identity, f.limit)
+}
+
+// ParseLimit parses a limit like "10/1m"
+func ParseLimit(s string) (Limit, error) {
+	requestsStr, periodStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate %q must be REQUESTS/PERIOD", s)
+	}
+
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid requests %q", requestsStr)
+	}
+
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("invalid period %q", periodStr)
+	}
+
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// ParseRules parses rules like "POST /v1/login 10/1m ip", key defaults to identity
+func ParseRules(lines []string) ([]Rule, error) {
+	var rules []Rule
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("rate limit rule %q: expected \"METHOD PATTERN REQUESTS/PERIOD [ip|identity]\"", line)
+		}
+
+		limit, err := ParseLimit(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("rate limit rule %q: %w", line, err)
+		}
+
+		key := KeyIdentity
+		if len(fields) == 4 {
+			key = fields[3]
+		}
+		if key != KeyIP && key != KeyIdentity {
+			return nil, fmt.Errorf("rate limit rule %q: unknown key %q", line, key)
+		}
+
+		rules = append(rules, Rule{
+			Method:  strings.ToUpper(fields[0]),
+			Pattern: fields[1],
+			Limit:   limit,
+			Key:     key,
+		})
+	}
+
+	return rules, nil
+}
+
+func matchPattern(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if part == "*" && i == len(patternParts)-1 {
+			return true
+		}
+
+		if i >= len(pathParts) {
+			return false
+		}
+
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			continue
+		}
+
+		if part != pathParts[i] {
+			return false
+		}
+	}
+
+	return len(patternParts) == len(pathParts)
+}
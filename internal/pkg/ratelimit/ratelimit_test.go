@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	// a token is refilled every 6 seconds
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	tests := []struct {
+		name string
+		// bucket is the state before the request, nil is a new one
+		bucket        *bucket
+		elapsed       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{name: "new bucket", wantAllowed: true, wantRemaining: 9, wantReset: 6 * time.Second},
+		{name: "empty bucket", bucket: &bucket{}, wantRetry: 6 * time.Second, wantReset: time.Minute},
+		{name: "half a token refilled", bucket: &bucket{}, elapsed: 3 * time.Second, wantRetry: 3 * time.Second, wantReset: 57 * time.Second},
+		{name: "a token refilled", bucket: &bucket{}, elapsed: 6 * time.Second, wantAllowed: true, wantReset: time.Minute},
+		{name: "tokens refilled", bucket: &bucket{}, elapsed: 30 * time.Second, wantAllowed: true, wantRemaining: 4, wantReset: 36 * time.Second},
+		{name: "refill is capped", bucket: &bucket{}, elapsed: time.Hour, wantAllowed: true, wantRemaining: 9, wantReset: 6 * time.Second},
+		{name: "refill adds to left tokens", bucket: &bucket{tokens: 2}, elapsed: 12 * time.Second, wantAllowed: true, wantRemaining: 3, wantReset: 42 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore(time.Minute)
+			defer store.Close()
+
+			if tt.bucket != nil {
+				tt.bucket.updated = time.Now().Add(-tt.elapsed)
+				store.buckets["key"] = tt.bucket
+			}
+
+			got := store.Take("key", limit)
+
+			if got.Allowed != tt.wantAllowed || got.Remaining != tt.wantRemaining || got.Limit != limit.Requests {
+				t.Errorf("got %+v, want allowed %v with %d remaining", got, tt.wantAllowed, tt.wantRemaining)
+			}
+			// time passes between the bucket setup and the request
+			if !about(got.RetryAfter, tt.wantRetry) {
+				t.Errorf("got retry after %v, want %v", got.RetryAfter, tt.wantRetry)
+			}
+			if !about(got.Reset, tt.wantReset) {
+				t.Errorf("got reset %v, want %v", got.Reset, tt.wantReset)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeys(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+
+	limit := Limit{Requests: 2, Period: time.Minute}
+	for i := 0; i < limit.Requests; i++ {
+		if !store.Take("key", limit).Allowed {
+			t.Fatalf("request %d within the limit is rejected", i+1)
+		}
+	}
+
+	if store.Take("key", limit).Allowed {
+		t.Error("request over the limit is allowed")
+	}
+	if !store.Take("other", limit).Allowed {
+		t.Error("request of another key is rejected")
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	store := NewMemoryStore(10 * time.Millisecond)
+	defer store.Close()
+
+	store.Take("refilled", Limit{Requests: 1, Period: time.Millisecond})
+	store.Take("kept", Limit{Requests: 1, Period: time.Hour})
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mu.Lock()
+		_, refilled := store.buckets["refilled"]
+		_, kept := store.buckets["kept"]
+		store.mu.Unlock()
+
+		if !kept {
+			t.Fatal("bucket which is not full is dropped")
+		}
+		if !refilled {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("full bucket is not dropped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFailureLimiter(t *testing.T) {
+	store := NewMemoryStore(time.Minute)
+	defer store.Close()
+	failures := NewFailureLimiter(store, "failures", Limit{Requests: 2, Period: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if !failures.Check("ip").Allowed {
+			t.Fatalf("check %d without failures is refused", i+1)
+		}
+	}
+
+	failures.Fail("ip")
+	if got := failures.Check("ip"); !got.Allowed || got.Remaining != 1 {
+		t.Errorf("after a failure got %+v, want allowed with 1 remaining", got)
+	}
+
+	failures.Fail("ip")
+	got := failures.Check("ip")
+	if got.Allowed || !about(got.RetryAfter, 30*time.Second) {
+		t.Errorf("out of failures got %+v, want refused for 30s", got)
+	}
+	if !failures.Check("other").Allowed {
+		t.Error("other identity is refused")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	limiter := New(nil, []Rule{
+		{Method: "POST", Pattern: "/v1/login", Key: KeyIP},
+		{Method: "GET", Pattern: "/v1/services/{id}", Key: KeyIdentity},
+		{Method: "*", Pattern: "/v1/*", Key: KeyIdentity},
+	})
+
+	tests := []struct {
+		method  string
+		path    string
+		want    string
+		wantHit bool
+	}{
+		{"POST", "/v1/login", "/v1/login", true},
+		{"POST", "/v1/login/", "/v1/login", true},
+		{"GET", "/v1/login", "/v1/*", true},
+		{"GET", "/v1/services/42", "/v1/services/{id}", true},
+		{"GET", "/v1/services/42/history", "/v1/*", true},
+		{"DELETE", "/v1/services/42", "/v1/*", true},
+		{"GET", "/health", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rule, ok := limiter.Match(tt.method, tt.path)
+			if ok != tt.wantHit || rule.Pattern != tt.want {
+				t.Errorf("got rule %q, %v, want %q, %v", rule.Pattern, ok, tt.want, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    Rule
+		wantErr bool
+	}{
+		{line: "POST /v1/login 10/1m ip", want: Rule{Method: "POST", Pattern: "/v1/login", Limit: Limit{10, time.Minute}, Key: KeyIP}},
+		{line: "get /v1/* 600/1h", want: Rule{Method: "GET", Pattern: "/v1/*", Limit: Limit{600, time.Hour}, Key: KeyIdentity}},
+		{line: "POST /v1/login", wantErr: true},
+		{line: "POST /v1/login 10 ip", wantErr: true},
+		{line: "POST /v1/login 0/1m", wantErr: true},
+		{line: "POST /v1/login 10/0s", wantErr: true},
+		{line: "POST /v1/login 10/minute", wantErr: true},
+		{line: "POST /v1/login 10/1m user", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			rules, err := ParseRules([]string{tt.line})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got rules %+v, want error", rules)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != 1 || rules[0] != tt.want {
+				t.Errorf("got %+v, want %+v", rules, tt.want)
+			}
+		})
+	}
+}
+
+func about(got, want time.Duration) bool {
+	d := got - want
+	return d > -100*time.Millisecond && d < 100*time.Millisecond
+}
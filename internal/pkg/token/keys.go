@@ -0,0 +1,347 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	rsaKeyBits = 2048
+)
+
+var (
+	ErrUnknownKeyID      = errors.New("token signed by unknown key")
+	ErrUnexpectedAlg     = errors.New("unexpected signing method")
+	ErrUnsupportedKey    = errors.New("unsupported private key type")
+	ErrUnknownAlgorithm  = errors.New("unknown signing algorithm")
+	ErrNoSigningKeyFound = errors.New("no signing key found")
+)
+
+// Key is a single signing key identified by kid header
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey interface{}
+	PublicKey  interface{}
+	retiredAt  time.Time
+}
+
+type KeySetOptions struct {
+	// Algorithm of generated keys, HS256 signs with Secret instead
+	Algorithm string
+	Secret    string
+	// Dir holds <kid>.pem private keys, keys are generated in memory if it's empty, which suits a single
+	// development instance only since every process signs with its own keys
+	Dir string
+	// SigningKeyID picks the signing key from Dir, the last kid in lexical order is used by default
+	SigningKeyID string
+	// Rotation is the interval of generating a new key or reloading Dir
+	Rotation time.Duration
+	// Retention is how long a retired generated key still verifies tokens
+	Retention time.Duration
+	// Issuer and Audience are set on issued tokens and required on parsed ones
+	Issuer   string
+	Audience string
+	// Leeway tolerates clock skew between token issuer and verifier
+	Leeway time.Duration
+	Logger *zap.Logger
+}
+
+// KeySet signs tokens with the active key and verifies them with every known key
+type KeySet struct {
+	mu         sync.RWMutex
+	options    KeySetOptions
+	keys       map[string]*Key
+	signingKey *Key
+	done       chan struct{}
+}
+
+func NewKeySet(options KeySetOptions) (*KeySet, error) {
+	keySet := &KeySet{
+		options: options,
+		keys:    make(map[string]*Key),
+		done:    make(chan struct{}),
+	}
+
+	switch {
+	case options.Algorithm == AlgorithmHS256:
+		key := &Key{
+			Method:     jwt.SigningMethodHS256,
+			PrivateKey: []byte(options.Secret),
+			PublicKey:  []byte(options.Secret),
+		}
+		keySet.keys[key.ID] = key
+		keySet.signingKey = key
+
+		// shared secret is never rotated
+		return keySet, nil
+	case options.Dir != "":
+		if err := keySet.load(); err != nil {
+			return nil, err
+		}
+	default:
+		if err := keySet.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Rotation > 0 {
+		go keySet.run()
+	}
+
+	return keySet, nil
+}
+
+// Sign signs claims with the active key
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	key := k.signingKey
+	k.mu.RUnlock()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc resolves verification key by kid and pins its algorithm
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	k.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("%w: %v", ErrUnexpectedAlg, token.Header["alg"])
+	}
+
+	return key.PublicKey, nil
+}
+
+// methods lists signing algorithms of known keys
+func (k *KeySet) methods() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	var methods []string
+	seen := make(map[string]bool)
+	for _, key := range k.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+
+	return methods
+}
+
+// JWKS returns public part of every asymmetric key
+func (k *KeySet) JWKS() JWKS {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		if jwk, ok := newJWK(key); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
+
+func (k *KeySet) Close() {
+	close(k.done)
+}
+
+func (k *KeySet) run() {
+	ticker := time.NewTicker(k.options.Rotation)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.done:
+			return
+		case <-ticker.C:
+			var err error
+			if k.options.Dir != "" {
+				err = k.load()
+			} else {
+				err = k.rotate()
+			}
+
+			if err != nil && k.options.Logger != nil {
+				k.options.Logger.Error("token key set rotation", zap.Error(err))
+			}
+		}
+	}
+}
+
+// rotate generates a new signing key and forgets keys retired longer than retention ago
+func (k *KeySet) rotate() error {
+	key, err := generateKey(k.options.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if k.signingKey != nil {
+		k.signingKey.retiredAt = now
+	}
+
+	for kid, v := range k.keys {
+		if !v.retiredAt.IsZero() && now.Sub(v.retiredAt) > k.options.Retention {
+			delete(k.keys, kid)
+		}
+	}
+
+	k.keys[key.ID] = key
+	k.signingKey = key
+
+	return nil
+}
+
+// load replaces keys with the content of keys directory
+func (k *KeySet) load() error {
+	files, err := filepath.Glob(filepath.Join(k.options.Dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*Key, len(files))
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("cannot read token key %s: %w", file, err)
+		}
+
+		key, err := parseKey(kid, data)
+		if err != nil {
+			return fmt.Errorf("cannot parse token key %s: %w", file, err)
+		}
+
+		keys[kid] = key
+	}
+
+	signingKID := k.options.SigningKeyID
+	if signingKID == "" {
+		for kid := range keys {
+			if kid > signingKID {
+				signingKID = kid
+			}
+		}
+	}
+
+	signingKey, ok := keys[signingKID]
+	if !ok {
+		return fmt.Errorf("%w in %s: %q", ErrNoSigningKeyFound, k.options.Dir, signingKID)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.signingKey = signingKey
+	k.mu.Unlock()
+
+	return nil
+}
+
+func generateKey(algorithm string) (*Key, error) {
+	key := &Key{
+		ID: uuid.New().String(),
+	}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.PrivateKey = privateKey
+		key.PublicKey = &privateKey.PublicKey
+	case AlgorithmEdDSA:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key.Method = jwt.SigningMethodEdDSA
+		key.PrivateKey = privateKey
+		key.PublicKey = publicKey
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, algorithm)
+	}
+
+	return key, nil
+}
+
+func parseKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:         kid,
+		PrivateKey: privateKey,
+	}
+
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.PublicKey = &privateKey.PublicKey
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.PublicKey = privateKey.Public()
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return key, nil
+}
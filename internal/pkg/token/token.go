@@ -5,74 +5,117 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	// TypeMFA is a challenge token which proves the password step of two-step login
+	TypeMFA = "mfa"
 )
 
 var (
 	ErrValidationErrorMalformed  = errors.New("token is malformed")
 	ErrTokenExpiredOrNotValidYet = errors.New("token is either expired or not active yet")
+	ErrInvalidTokenType          = errors.New("token type is not accepted")
 )
 
-func GenerateJwtToken(jwtsecret string, claims *jwt.MapClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// Sign and get the complete encoded token as a string using the secret
-	return token.SignedString([]byte(jwtsecret))
+// Claims are claims of access and refresh tokens, subject holds the user role
+type Claims struct {
+	UserID string `json:"user_id"`
+	Type   string `json:"token_type"`
+	// IssuedAtNano is iat in nanoseconds, whole seconds of iat can't tell tokens issued right after
+	// a revocation of the user, e.g. on login with a forced password change, from revoked ones
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
+	jwt.RegisteredClaims
 }
 
-func GenerateToken(sub, userID, jwtsecret string, access_ttl, refresh_ttl time.Duration, optionalFields ...map[string]interface{}) (string, string, error) {
-	accessClaims := jwt.MapClaims{
-		"sub":     sub,
-		"user_id": userID,
-		"exp":     time.Now().Add(access_ttl).Unix(),
-	}
+func (c Claims) Role() string {
+	return c.Subject
+}
 
-	for _, fields := range optionalFields {
-		for key, value := range fields {
-			accessClaims[key] = value
-		}
+// Issued is the time the token was issued at, zero for a token without iat_ns
+func (c Claims) Issued() time.Time {
+	if c.IssuedAtNano == 0 {
+		return time.Time{}
 	}
+	return time.Unix(0, c.IssuedAtNano)
+}
+
+func GenerateJwtToken(keys *KeySet, claims *Claims) (string, error) {
+	// Sign and get the complete encoded token as a string using the active key
+	return keys.Sign(claims)
+}
+
+func GenerateToken(sub, userID string, keys *KeySet, access_ttl, refresh_ttl time.Duration) (string, string, error) {
+	now := time.Now()
 
 	// generate access token
-	access_token, err := GenerateJwtToken(jwtsecret, &accessClaims)
+	access_token, err := GenerateJwtToken(keys, keys.newClaims(TypeAccess, sub, userID, now, access_ttl))
 	if err != nil {
 		return "", "", err
 	}
 
 	// generate refresh token
-	refresh_token, err := GenerateJwtToken(jwtsecret, &jwt.MapClaims{
-		"exp":     time.Now().Add(refresh_ttl).Unix(),
-		"sub":     sub,
-		"user_id": userID,
-	})
+	refresh_token, err := GenerateJwtToken(keys, keys.newClaims(TypeRefresh, sub, userID, now, refresh_ttl))
 	if err != nil {
 		return "", "", err
 	}
 	return access_token, refresh_token, err
 }
 
-func ParseJwtToken(tokenStr, jwtsecret string) (map[string]interface{}, error) {
-	var claims map[string]interface{}
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtsecret), nil
-	})
+// GenerateMFAToken issues a short-lived challenge token which can only be exchanged for tokens with a second factor
+func GenerateMFAToken(sub, userID string, keys *KeySet, ttl time.Duration) (string, error) {
+	return GenerateJwtToken(keys, keys.newClaims(TypeMFA, sub, userID, time.Now(), ttl))
+}
 
+// ParseJwtToken validates token and accepts it only if it has the expected token type
+func ParseJwtToken(tokenStr string, keys *KeySet, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := keys.parser().ParseWithClaims(tokenStr, claims, keys.Keyfunc)
 	if err != nil {
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				return claims, ErrValidationErrorMalformed
-			}
-			if ve.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorNotValidYet) != 0 {
-				return claims, ErrTokenExpiredOrNotValidYet
-			}
+		if errors.Is(err, jwt.ErrTokenMalformed) {
+			return nil, ErrValidationErrorMalformed
+		}
+		if errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrTokenNotValidYet) {
+			return nil, ErrTokenExpiredOrNotValidYet
 		}
-		return claims, fmt.Errorf("Couldn't handle this token: %w", err)
+		return nil, fmt.Errorf("Couldn't handle this token: %w", err)
 	}
-	// get claims
-	if mapClaims, ok := token.Claims.(jwt.MapClaims); ok {
-		claims = mapClaims
+
+	if claims.Type != tokenType {
+		return nil, ErrInvalidTokenType
 	}
+
 	return claims, nil
 }
+
+func (k *KeySet) newClaims(tokenType, sub, userID string, now time.Time, ttl time.Duration) *Claims {
+	return &Claims{
+		UserID:       userID,
+		Type:         tokenType,
+		IssuedAtNano: now.UnixNano(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   sub,
+			Issuer:    k.options.Issuer,
+			Audience:  jwt.ClaimStrings{k.options.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+}
+
+func (k *KeySet) parser() *jwt.Parser {
+	return jwt.NewParser(
+		jwt.WithValidMethods(k.methods()),
+		jwt.WithIssuer(k.options.Issuer),
+		jwt.WithAudience(k.options.Audience),
+		jwt.WithLeeway(k.options.Leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+}
@@ -0,0 +1,228 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestKeySet(t *testing.T, options KeySetOptions) *KeySet {
+	t.Helper()
+
+	if options.Algorithm == "" {
+		options.Algorithm = AlgorithmEdDSA
+	}
+	if options.Issuer == "" {
+		options.Issuer = "abclinic"
+	}
+	if options.Audience == "" {
+		options.Audience = "abclinic"
+	}
+
+	keys, err := NewKeySet(options)
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	t.Cleanup(keys.Close)
+
+	return keys
+}
+
+func TestParseJwtToken(t *testing.T) {
+	keys := newTestKeySet(t, KeySetOptions{Leeway: 30 * time.Second})
+	otherIssuer := newTestKeySet(t, KeySetOptions{Issuer: "other"})
+	otherKeys := newTestKeySet(t, KeySetOptions{})
+	hmacKeys := newTestKeySet(t, KeySetOptions{Algorithm: AlgorithmHS256, Secret: "0123456789abcdef0123456789abcdef"})
+
+	now := time.Now()
+	sign := func(keys *KeySet, tokenType string, issuedAt time.Time, ttl time.Duration, change func(*Claims)) string {
+		claims := keys.newClaims(tokenType, "admin", "user", issuedAt, ttl)
+		if change != nil {
+			change(claims)
+		}
+
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    error
+		wantErr bool
+	}{
+		{name: "valid", token: sign(keys, TypeAccess, now, time.Hour, nil)},
+		{name: "expired within leeway", token: sign(keys, TypeAccess, now.Add(-time.Hour), time.Hour-10*time.Second, nil)},
+		{name: "expired", token: sign(keys, TypeAccess, now.Add(-time.Hour), time.Hour-time.Minute, nil), want: ErrTokenExpiredOrNotValidYet},
+		{name: "not valid yet", token: sign(keys, TypeAccess, now.Add(time.Minute), time.Hour, nil), want: ErrTokenExpiredOrNotValidYet},
+		{name: "no expiry", token: sign(keys, TypeAccess, now, time.Hour, func(c *Claims) { c.ExpiresAt = nil }), wantErr: true},
+		{name: "other issuer", token: sign(otherIssuer, TypeAccess, now, time.Hour, nil), wantErr: true},
+		{name: "other audience", token: sign(keys, TypeAccess, now, time.Hour, func(c *Claims) { c.Audience = jwt.ClaimStrings{"other"} }), wantErr: true},
+		{name: "other token type", token: sign(keys, TypeRefresh, now, time.Hour, nil), want: ErrInvalidTokenType},
+		{name: "unknown kid", token: sign(otherKeys, TypeAccess, now, time.Hour, nil), want: ErrUnknownKeyID},
+		{name: "other algorithm", token: sign(hmacKeys, TypeAccess, now, time.Hour, nil), wantErr: true},
+		{name: "malformed", token: "not-a-token", want: ErrValidationErrorMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseJwtToken(tt.token, keys, TypeAccess)
+
+			switch {
+			case tt.want == nil && !tt.wantErr:
+				if err != nil {
+					t.Fatalf("got error %v", err)
+				}
+				if claims.Role() != "admin" || claims.UserID != "user" {
+					t.Errorf("got claims %+v", claims)
+				}
+			case tt.want != nil:
+				if !errors.Is(err, tt.want) {
+					t.Errorf("got error %v, want %v", err, tt.want)
+				}
+			default:
+				if err == nil {
+					t.Error("token is accepted")
+				}
+			}
+		})
+	}
+}
+
+func TestIssued(t *testing.T) {
+	keys := newTestKeySet(t, KeySetOptions{})
+
+	now := time.Now()
+	token, err := keys.Sign(keys.newClaims(TypeAccess, "admin", "user", now, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := ParseJwtToken(token, keys, TypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !claims.Issued().Equal(now) {
+		t.Errorf("got issued %v, want %v", claims.Issued(), now)
+	}
+	// iat keeps the whole seconds of the library default
+	if !claims.IssuedAt.Time.Equal(now.Truncate(time.Second)) {
+		t.Errorf("got iat %v, want %v", claims.IssuedAt.Time, now.Truncate(time.Second))
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	keys := newTestKeySet(t, KeySetOptions{Retention: time.Hour})
+
+	before, _, err := GenerateToken("admin", "user", keys, time.Hour, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retiredKID := keys.signingKey.ID
+
+	if err := keys.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	after, _, err := GenerateToken("admin", "user", keys, time.Hour, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		kid   string
+	}{
+		{"signed by retired key", before, retiredKID},
+		{"signed by new key", after, keys.signingKey.ID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := keys.parser().ParseWithClaims(tt.token, &Claims{}, keys.Keyfunc)
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if kid := parsed.Header["kid"]; kid != tt.kid {
+				t.Errorf("got kid %v, want %v", kid, tt.kid)
+			}
+		})
+	}
+
+	if len(keys.JWKS().Keys) != 2 {
+		t.Errorf("JWKS has %d keys, want retired and new one", len(keys.JWKS().Keys))
+	}
+
+	// the retired key is forgotten on the rotation after its retention
+	keys.keys[retiredKID].retiredAt = time.Now().Add(-2 * time.Hour)
+	if err := keys.rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseJwtToken(before, keys, TypeAccess); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("token of a forgotten key: got error %v, want %v", err, ErrUnknownKeyID)
+	}
+	if _, err := ParseJwtToken(after, keys, TypeAccess); err != nil {
+		t.Errorf("token of a retired key within retention: got error %v", err)
+	}
+}
+
+func TestKeySetDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, kid := range []string{"2024-01", "2024-02"} {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		signingKeyID string
+		wantKID      string
+		want         error
+	}{
+		{name: "last kid by default", wantKID: "2024-02"},
+		{name: "configured kid", signingKeyID: "2024-01", wantKID: "2024-01"},
+		{name: "missing kid", signingKeyID: "2023-12", want: ErrNoSigningKeyFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := NewKeySet(KeySetOptions{Algorithm: AlgorithmEdDSA, Dir: dir, SigningKeyID: tt.signingKeyID})
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got error %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer keys.Close()
+
+			if keys.signingKey.ID != tt.wantKID {
+				t.Errorf("signing kid %q, want %q", keys.signingKey.ID, tt.wantKID)
+			}
+			if len(keys.JWKS().Keys) != 2 {
+				t.Errorf("JWKS has %d keys, want 2", len(keys.JWKS().Keys))
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// secret is the RFC 6238 test key "12345678901234567890"
+const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// codes are the last digits of RFC 6238 SHA1 test vectors
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+
+	codeAt := func(offset int64) string {
+		code, err := Code(secret, now.Add(time.Duration(offset)*Period))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		passcode string
+		secret   string
+		skew     int
+		lastStep int64
+		want     int64
+		wantOK   bool
+	}{
+		{name: "current step", passcode: codeAt(0), skew: 1, want: step, wantOK: true},
+		{name: "previous step within skew", passcode: codeAt(-1), skew: 1, want: step - 1, wantOK: true},
+		{name: "next step within skew", passcode: codeAt(1), skew: 1, want: step + 1, wantOK: true},
+		{name: "previous step out of skew", passcode: codeAt(-2), skew: 1},
+		{name: "next step out of skew", passcode: codeAt(2), skew: 1},
+		{name: "no skew", passcode: codeAt(-1)},
+		{name: "wrong code", passcode: "000000", skew: 1},
+		{name: "short code", passcode: codeAt(0)[:5], skew: 1},
+		{name: "malformed secret", passcode: codeAt(0), secret: "not base32!", skew: 1},
+		{name: "lowercase secret", passcode: codeAt(0), secret: " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", skew: 1, want: step, wantOK: true},
+		{name: "replayed step", passcode: codeAt(0), skew: 1, lastStep: step},
+		{name: "step before the accepted one", passcode: codeAt(-1), skew: 1, lastStep: step},
+		{name: "step after the accepted one", passcode: codeAt(1), skew: 1, lastStep: step, want: step + 1, wantOK: true},
+		{name: "step after the accepted previous one", passcode: codeAt(0), skew: 1, lastStep: step - 1, want: step, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := secret
+			if tt.secret != "" {
+				key = tt.secret
+			}
+
+			got, ok := Validate(tt.passcode, key, now, tt.skew, tt.lastStep)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Validate() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package validation
 
 import "golang.org/x/crypto/bcrypt"
 
+// DummyPasswordHash is compared against when user does not exist
+const DummyPasswordHash = "$2a$14$HiYbV4zpRfEg7HKx4pN0EuPYYkgyPUpChe/1pkmYNGMTM6F0j580S"
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
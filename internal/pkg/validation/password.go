@@ -0,0 +1,78 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var ErrPasswordPolicy = errors.New("password does not meet policy")
+
+// commonPasswords are rejected regardless of configured denylist
+var commonPasswords = []string{
+	"123456", "12345678", "123456789", "1234567890", "qwerty", "qwerty123",
+	"password", "password1", "password123", "admin", "admin123", "abclinic",
+	"111111", "000000", "iloveyou", "welcome", "letmein", "dentist",
+}
+
+type PasswordPolicy struct {
+	MinLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	Denylist       []string
+}
+
+// Validate returns ErrPasswordPolicy describing every violated rule
+func (p PasswordPolicy) Validate(password, username string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "an upper case letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "a lower case letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		violations = append(violations, "a special character")
+	}
+
+	if len(violations) != 0 {
+		return fmt.Errorf("%w: must contain %s", ErrPasswordPolicy, strings.Join(violations, ", "))
+	}
+
+	if username != "" && strings.EqualFold(password, username) {
+		return fmt.Errorf("%w: must differ from username", ErrPasswordPolicy)
+	}
+
+	for _, denied := range append(commonPasswords, p.Denylist...) {
+		if strings.EqualFold(password, denied) {
+			return fmt.Errorf("%w: password is too common", ErrPasswordPolicy)
+		}
+	}
+
+	return nil
+}
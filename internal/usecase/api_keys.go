@@ -0,0 +1,279 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidAPIKey    = errors.New("invalid api key")
+	ErrAPIKeyRevoked    = errors.New("api key is revoked")
+	ErrInvalidAPIKeyReq = errors.New("api key needs a name, a known role and read or write scopes")
+)
+
+const (
+	// keys look like abk_<prefix>_<secret>, prefix is stored in clear for lookup
+	apiKeyTag         = "abk"
+	apiKeyPrefixBytes = 6
+	apiKeySecretBytes = 32
+	// apiKeyLastUsedResolution limits writes of last_used_at for busy keys
+	apiKeyLastUsedResolution = time.Minute
+)
+
+type APIKeys interface {
+	Create(ctx context.Context, req *entity.APIKeys) (string, error)
+	Get(ctx context.Context, id string) (*entity.APIKeys, error)
+	List(ctx context.Context, filter entity.APIKeysFilter) ([]*entity.APIKeys, error)
+	Rotate(ctx context.Context, id string) (string, error)
+	Revoke(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, key string) (*entity.APIKeys, error)
+}
+
+type apiKeysUsecase struct {
+	BaseUsecase
+	apiKeysRepo repository.APIKeys
+	ctxTimeout  time.Duration
+}
+
+func NewAPIKeysUsecase(ctxTimeout time.Duration, apiKeysRepo repository.APIKeys) APIKeys {
+	return &apiKeysUsecase{
+		apiKeysRepo: apiKeysRepo,
+		ctxTimeout:  ctxTimeout,
+	}
+}
+
+// Create stores a new key and returns it in clear, it can't be shown again
+func (u apiKeysUsecase) Create(ctx context.Context, req *entity.APIKeys) (string, error) {
+	ctx, span := tracing.Start(ctx, "APIKeys.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	if !validAPIKey(req) {
+		return "", ErrInvalidAPIKeyReq
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	req.Prefix = prefix
+	req.KeyHash = hashAPIKey(key)
+
+	u.BaseUsecase.beforeCreate(&req.GUID, &req.CreatedAt, &req.UpdatedAt)
+
+	if err := u.apiKeysRepo.Create(ctx, req); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (u apiKeysUsecase) Get(ctx context.Context, id string) (*entity.APIKeys, error) {
+	ctx, span := tracing.Start(ctx, "APIKeys.Get")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	return u.apiKeysRepo.Get(ctx, entity.APIKeysFilter{GUIDs: []string{id}})
+}
+
+func (u apiKeysUsecase) List(ctx context.Context, filter entity.APIKeysFilter) ([]*entity.APIKeys, error) {
+	ctx, span := tracing.Start(ctx, "APIKeys.List")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	return u.apiKeysRepo.List(ctx, filter)
+}
+
+// Rotate replaces secret of the key keeping its role and scopes, the old secret stops working at once
+func (u apiKeysUsecase) Rotate(ctx context.Context, id string) (string, error) {
+	ctx, span := tracing.Start(ctx, "APIKeys.Rotate")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	apiKey, err := u.apiKeysRepo.Get(ctx, entity.APIKeysFilter{GUIDs: []string{id}})
+	if err != nil {
+		return "", err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return "", ErrAPIKeyRevoked
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		return "", err
+	}
+
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = hashAPIKey(key)
+
+	u.BaseUsecase.beforeCreate(nil, nil, &apiKey.UpdatedAt)
+
+	if err := u.apiKeysRepo.Update(ctx, apiKey); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Revoke disables the key, revoked keys are kept for audit
+func (u apiKeysUsecase) Revoke(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "APIKeys.Revoke")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	apiKey, err := u.apiKeysRepo.Get(ctx, entity.APIKeysFilter{GUIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+
+	u.BaseUsecase.beforeCreate(nil, nil, &apiKey.UpdatedAt)
+
+	if err := u.apiKeysRepo.Update(ctx, apiKey); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("api key revoked", zap.String("target_api_key_id", apiKey.GUID))
+
+	return nil
+}
+
+// Authenticate resolves key from request header into a stored, active key
+func (u apiKeysUsecase) Authenticate(ctx context.Context, key string) (*entity.APIKeys, error) {
+	ctx, span := tracing.Start(ctx, "APIKeys.Authenticate")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	// malformed keys don't reach the database
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := u.apiKeysRepo.Get(ctx, entity.APIKeysFilter{Prefix: prefix})
+	if err != nil {
+		if errors.Is(err, errorspkg.ErrorNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)) {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedResolution {
+		apiKey.LastUsedAt = &now
+		// usage tracking must not fail authentication
+		if err := u.apiKeysRepo.Update(ctx, apiKey); err != nil {
+			logger.FromContext(ctx).Warn("api key last used update", zap.String("api_key_id", apiKey.GUID), zap.Error(err))
+		}
+	}
+
+	return apiKey, nil
+}
+
+func validAPIKey(req *entity.APIKeys) bool {
+	if strings.TrimSpace(req.Name) == "" || len(req.Scopes) == 0 {
+		return false
+	}
+
+	switch req.Role {
+	case entity.RoleAdmin, entity.RoleWebsite, entity.RoleDentist, entity.RoleSecretary:
+	default:
+		return false
+	}
+
+	for _, scope := range req.Scopes {
+		if scope != entity.ScopeRead && scope != entity.ScopeWrite {
+			return false
+		}
+	}
+
+	return true
+}
+
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	// underscore separates key parts, so it is replaced in the base64 secret
+	prefixStr := hex.EncodeToString(prefix)
+	secretStr := strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(secret), "_", "-")
+
+	return apiKeyTag + "_" + prefixStr + "_" + secretStr, prefixStr, nil
+}
+
+// parseAPIKey returns prefix of a key shaped like the ones generateAPIKey makes
+func parseAPIKey(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag {
+		return "", false
+	}
+
+	prefix, secret := parts[1], parts[2]
+	if len(prefix) != hex.EncodedLen(apiKeyPrefixBytes) || len(secret) != base64.RawURLEncoding.EncodedLen(apiKeySecretBytes) {
+		return "", false
+	}
+	if _, err := hex.DecodeString(prefix); err != nil {
+		return "", false
+	}
+	// underscores of the secret were replaced with dashes, which base64 url encoding has too
+	if _, err := base64.RawURLEncoding.DecodeString(secret); err != nil {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+// hashAPIKey uses plain sha256 since keys are long random strings
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,23 +2,26 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 )
 
 type Blogs interface {
 	CreatePublications(ctx context.Context, req *entity.Publications) (string, error)
-	ListPublications(ctx context.Context, filter map[string]string) ([]*entity.Publications, error)
+	ListPublications(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error)
 	UpdatePublications(ctx context.Context, req *entity.Publications) error
 	DeletePublications(ctx context.Context, id string) error
 	CreatePublicationsCategories(ctx context.Context, req *entity.Categories) (string, error)
-	ListPublicationsCategories(ctx context.Context, filter map[string]string) ([]*entity.Categories, error)
+	ListPublicationsCategories(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error)
 	UpdatePublicationsCategories(ctx context.Context, req *entity.Categories) error
 	DeletePublicationsCategories(ctx context.Context, id string) error
 	CreateAuthors(ctx context.Context, req *entity.Authors) (string, error)
-	ListAuthors(ctx context.Context, filter map[string]string) ([]*entity.Authors, error)
+	ListAuthors(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error)
 	UpdateAuthors(ctx context.Context, req *entity.Authors) error
 	DeleteAuthors(ctx context.Context, id string) error
 	GetAuthor(ctx context.Context, id string) (*entity.Authors, error)
@@ -42,6 +45,9 @@ func NewBlogsUsecase(ctxTimeout time.Duration, publicationsRepo repository.Publi
 }
 
 func (u blogsUsecase) CreatePublications(ctx context.Context, req *entity.Publications) (string, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.CreatePublications")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -49,25 +55,37 @@ func (u blogsUsecase) CreatePublications(ctx context.Context, req *entity.Public
 
 	return req.GUID, u.publicationsRepo.Create(ctx, req)
 }
-func (u blogsUsecase) ListPublications(ctx context.Context, filter map[string]string) ([]*entity.Publications, error) {
+func (u blogsUsecase) ListPublications(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.ListPublications")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.publicationsRepo.List(ctx, filter)
 }
 func (u blogsUsecase) UpdatePublications(ctx context.Context, req *entity.Publications) error {
+	ctx, span := tracing.Start(ctx, "Blogs.UpdatePublications")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.publicationsRepo.Update(ctx, req)
 }
 func (u blogsUsecase) DeletePublications(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Blogs.DeletePublications")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	return u.publicationsRepo.Delete(ctx, map[string]string{"guid": id})
+	return u.publicationsRepo.Delete(ctx, entity.PublicationsFilter{GUIDs: []string{id}})
 }
 func (u blogsUsecase) CreatePublicationsCategories(ctx context.Context, req *entity.Categories) (string, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.CreatePublicationsCategories")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -75,30 +93,39 @@ func (u blogsUsecase) CreatePublicationsCategories(ctx context.Context, req *ent
 
 	return req.GUID, u.categoriesRepo.Create(ctx, req)
 }
-func (u blogsUsecase) ListPublicationsCategories(ctx context.Context, filter map[string]string) ([]*entity.Categories, error) {
+func (u blogsUsecase) ListPublicationsCategories(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.ListPublicationsCategories")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.categoriesRepo.List(ctx, filter)
 }
 func (u blogsUsecase) UpdatePublicationsCategories(ctx context.Context, req *entity.Categories) error {
+	ctx, span := tracing.Start(ctx, "Blogs.UpdatePublicationsCategories")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.categoriesRepo.Update(ctx, req)
 }
 func (u blogsUsecase) DeletePublicationsCategories(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Blogs.DeletePublicationsCategories")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	err := u.publicationsRepo.Delete(ctx, map[string]string{"category_id": id})
+	err := u.publicationsRepo.Delete(ctx, entity.PublicationsFilter{CategoryIDs: []string{id}})
 	if err != nil {
-		if err.Error() != "no sql rows" {
+		if !errors.Is(err, errorspkg.ErrorNotFound) {
 			return err
 		}
 	}
 
-	err = u.categoriesRepo.Delete(ctx, map[string]string{"guid": id})
+	err = u.categoriesRepo.Delete(ctx, entity.CategoriesFilter{GUIDs: []string{id}})
 	if err != nil {
 		return err
 	}
@@ -106,6 +133,9 @@ func (u blogsUsecase) DeletePublicationsCategories(ctx context.Context, id strin
 	return nil
 }
 func (u blogsUsecase) CreateAuthors(ctx context.Context, req *entity.Authors) (string, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.CreateAuthors")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	if req.GUID != "" {
@@ -118,36 +148,48 @@ func (u blogsUsecase) CreateAuthors(ctx context.Context, req *entity.Authors) (s
 }
 
 func (u blogsUsecase) GetAuthor(ctx context.Context, id string) (*entity.Authors, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.GetAuthor")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.authorsRepo.Get(ctx, id)
 }
 
-func (u blogsUsecase) ListAuthors(ctx context.Context, filter map[string]string) ([]*entity.Authors, error) {
+func (u blogsUsecase) ListAuthors(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error) {
+	ctx, span := tracing.Start(ctx, "Blogs.ListAuthors")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.authorsRepo.List(ctx, filter)
 }
 func (u blogsUsecase) UpdateAuthors(ctx context.Context, req *entity.Authors) error {
+	ctx, span := tracing.Start(ctx, "Blogs.UpdateAuthors")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.authorsRepo.Update(ctx, req)
 }
 func (u blogsUsecase) DeleteAuthors(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Blogs.DeleteAuthors")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	err := u.publicationsRepo.Delete(ctx, map[string]string{"author_id": id})
+	err := u.publicationsRepo.Delete(ctx, entity.PublicationsFilter{AuthorIDs: []string{id}})
 	if err != nil {
-		if err.Error() != "no sql rows" {
+		if !errors.Is(err, errorspkg.ErrorNotFound) {
 			return err
 		}
 	}
 
-	err = u.authorsRepo.Delete(ctx, map[string]string{"guid": id})
+	err = u.authorsRepo.Delete(ctx, entity.AuthorsFilter{GUIDs: []string{id}})
 	if err != nil {
 		return err
 	}
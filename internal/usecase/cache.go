@@ -0,0 +1,431 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	"github.com/AsaHero/abclinic/internal/pkg/cache"
+)
+
+// Cached usecases serve reads of public content from the cache. Lists are stored under the tag of their table,
+// single rows under the tag of the row too, and every change invalidates the tags of rows it touched,
+// including rows removed along with it. Changes are invalidated even when they fail, they may be applied partly.
+
+const (
+	tagDentists      = "dentists"
+	tagServices      = "services"
+	tagServiceGroups = "service_groups"
+	tagServicePrices = "service_prices"
+	tagPriceTiers    = "price_tiers"
+	tagExchangeRates = "exchange_rates"
+	tagArticles      = "articles"
+	tagChapters      = "chapters"
+	tagPublications  = "publications"
+	tagCategories    = "categories"
+	tagAuthors       = "authors"
+)
+
+type cachedDentists struct {
+	Denstists
+	cache *cache.Cache
+}
+
+func NewCachedDentists(dentists Denstists, c *cache.Cache) Denstists {
+	return &cachedDentists{
+		Denstists: dentists,
+		cache:     c,
+	}
+}
+
+func (u *cachedDentists) Get(ctx context.Context, id int64) (*entity.Dentists, error) {
+	tag := rowTag(tagDentists, strconv.FormatInt(id, 10))
+
+	value, err := u.cache.Load(ctx, cacheKey("Dentists.Get", id), []string{tag}, func() (interface{}, error) {
+		return u.Denstists.Get(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*entity.Dentists), nil
+}
+
+func (u *cachedDentists) List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Dentists.List", filter), []string{tagDentists}, func() (interface{}, error) {
+		return u.Denstists.List(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Dentists), nil
+}
+
+func (u *cachedDentists) Create(ctx context.Context, req *entity.Dentists) (int64, error) {
+	defer u.cache.Invalidate(tagDentists)
+
+	return u.Denstists.Create(ctx, req)
+}
+
+func (u *cachedDentists) Update(ctx context.Context, req *entity.Dentists) error {
+	defer u.cache.Invalidate(tagDentists, rowTag(tagDentists, strconv.FormatInt(req.ID, 10)))
+
+	return u.Denstists.Update(ctx, req)
+}
+
+type cachedPriceList struct {
+	PriceList
+	cache *cache.Cache
+}
+
+func NewCachedPriceList(priceList PriceList, c *cache.Cache) PriceList {
+	return &cachedPriceList{
+		PriceList: priceList,
+		cache:     c,
+	}
+}
+
+func (u *cachedPriceList) ListServices(ctx context.Context, filter entity.ServicesFilter, options entity.ServicesListOptions) ([]*entity.Services, error) {
+	tags := []string{tagServices}
+	// converted prices depend on exchange rates too
+	if options.Currency != "" {
+		tags = append(tags, tagExchangeRates)
+	}
+
+	value, err := u.cache.Load(ctx, cacheKey("PriceList.ListServices", []interface{}{filter, options}), tags, func() (interface{}, error) {
+		return u.PriceList.ListServices(ctx, filter, options)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Services), nil
+}
+
+func (u *cachedPriceList) ListServiceGroups(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error) {
+	value, err := u.cache.Load(ctx, cacheKey("PriceList.ListServiceGroups", filter), []string{tagServiceGroups}, func() (interface{}, error) {
+		return u.PriceList.ListServiceGroups(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.ServiceGroups), nil
+}
+
+func (u *cachedPriceList) ListPrices(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error) {
+	value, err := u.cache.Load(ctx, cacheKey("PriceList.ListPrices", filter), []string{tagServicePrices}, func() (interface{}, error) {
+		return u.PriceList.ListPrices(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.ServicePrices), nil
+}
+
+func (u *cachedPriceList) ListPriceTiers(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error) {
+	value, err := u.cache.Load(ctx, cacheKey("PriceList.ListPriceTiers", filter), []string{tagPriceTiers}, func() (interface{}, error) {
+		return u.PriceList.ListPriceTiers(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.PriceTiers), nil
+}
+
+func (u *cachedPriceList) ListExchangeRates(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error) {
+	value, err := u.cache.Load(ctx, cacheKey("PriceList.ListExchangeRates", filter), []string{tagExchangeRates}, func() (interface{}, error) {
+		return u.PriceList.ListExchangeRates(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.ExchangeRates), nil
+}
+
+// Services are listed with their prices, changes of prices invalidate both
+
+func (u *cachedPriceList) CreateService(ctx context.Context, req *entity.Services) (string, error) {
+	defer u.cache.Invalidate(tagServices, tagServicePrices)
+
+	return u.PriceList.CreateService(ctx, req)
+}
+
+func (u *cachedPriceList) UpdateService(ctx context.Context, req *entity.Services) error {
+	defer u.cache.Invalidate(tagServices, tagServicePrices)
+
+	return u.PriceList.UpdateService(ctx, req)
+}
+
+func (u *cachedPriceList) DeleteService(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagServices, tagServicePrices)
+
+	return u.PriceList.DeleteService(ctx, id)
+}
+
+func (u *cachedPriceList) SchedulePrice(ctx context.Context, req *entity.ServicePrices) (string, error) {
+	defer u.cache.Invalidate(tagServices, tagServicePrices)
+
+	return u.PriceList.SchedulePrice(ctx, req)
+}
+
+func (u *cachedPriceList) CancelPrice(ctx context.Context, serviceID, id string) error {
+	defer u.cache.Invalidate(tagServices, tagServicePrices)
+
+	return u.PriceList.CancelPrice(ctx, serviceID, id)
+}
+
+func (u *cachedPriceList) CreatePriceTier(ctx context.Context, req *entity.PriceTiers) (string, error) {
+	defer u.cache.Invalidate(tagPriceTiers)
+
+	return u.PriceList.CreatePriceTier(ctx, req)
+}
+
+// UpdatePriceTier reorders amounts of prices by the position
+func (u *cachedPriceList) UpdatePriceTier(ctx context.Context, req *entity.PriceTiers) error {
+	defer u.cache.Invalidate(tagPriceTiers, tagServices, tagServicePrices)
+
+	return u.PriceList.UpdatePriceTier(ctx, req)
+}
+
+// DeletePriceTier is refused while the tier has prices
+func (u *cachedPriceList) DeletePriceTier(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagPriceTiers)
+
+	return u.PriceList.DeletePriceTier(ctx, id)
+}
+
+func (u *cachedPriceList) CreateExchangeRate(ctx context.Context, req *entity.ExchangeRates) (string, error) {
+	defer u.cache.Invalidate(tagExchangeRates)
+
+	return u.PriceList.CreateExchangeRate(ctx, req)
+}
+
+func (u *cachedPriceList) UpdateExchangeRate(ctx context.Context, req *entity.ExchangeRates) error {
+	defer u.cache.Invalidate(tagExchangeRates)
+
+	return u.PriceList.UpdateExchangeRate(ctx, req)
+}
+
+func (u *cachedPriceList) DeleteExchangeRate(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagExchangeRates)
+
+	return u.PriceList.DeleteExchangeRate(ctx, id)
+}
+
+func (u *cachedPriceList) CreateServiceGroup(ctx context.Context, req *entity.ServiceGroups) (string, error) {
+	defer u.cache.Invalidate(tagServiceGroups)
+
+	return u.PriceList.CreateServiceGroup(ctx, req)
+}
+
+func (u *cachedPriceList) UpdateServiceGroup(ctx context.Context, req *entity.ServiceGroups) error {
+	defer u.cache.Invalidate(tagServiceGroups)
+
+	return u.PriceList.UpdateServiceGroup(ctx, req)
+}
+
+// DeleteServiceGroup deletes services of the group too
+func (u *cachedPriceList) DeleteServiceGroup(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagServiceGroups, tagServices, tagServicePrices)
+
+	return u.PriceList.DeleteServiceGroup(ctx, id)
+}
+
+type cachedInfo struct {
+	InfoUsecase
+	cache *cache.Cache
+}
+
+func NewCachedInfo(info InfoUsecase, c *cache.Cache) InfoUsecase {
+	return &cachedInfo{
+		InfoUsecase: info,
+		cache:       c,
+	}
+}
+
+func (u *cachedInfo) ListArticles(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Info.ListArticles", filter), []string{tagArticles}, func() (interface{}, error) {
+		return u.InfoUsecase.ListArticles(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Articles), nil
+}
+
+func (u *cachedInfo) ListArticlesChapters(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Info.ListArticlesChapters", filter), []string{tagChapters}, func() (interface{}, error) {
+		return u.InfoUsecase.ListArticlesChapters(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Chapters), nil
+}
+
+func (u *cachedInfo) CreateArticle(ctx context.Context, req *entity.Articles) (string, error) {
+	defer u.cache.Invalidate(tagArticles)
+
+	return u.InfoUsecase.CreateArticle(ctx, req)
+}
+
+func (u *cachedInfo) UpdateArticles(ctx context.Context, req *entity.Articles) error {
+	defer u.cache.Invalidate(tagArticles)
+
+	return u.InfoUsecase.UpdateArticles(ctx, req)
+}
+
+func (u *cachedInfo) DeleteArticles(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagArticles)
+
+	return u.InfoUsecase.DeleteArticles(ctx, id)
+}
+
+func (u *cachedInfo) CreateArticlesChapter(ctx context.Context, req *entity.Chapters) (string, error) {
+	defer u.cache.Invalidate(tagChapters)
+
+	return u.InfoUsecase.CreateArticlesChapter(ctx, req)
+}
+
+func (u *cachedInfo) UpdateArticlesChapter(ctx context.Context, req *entity.Chapters) error {
+	defer u.cache.Invalidate(tagChapters)
+
+	return u.InfoUsecase.UpdateArticlesChapter(ctx, req)
+}
+
+// DeleteArticlesChapter deletes articles of the chapter too
+func (u *cachedInfo) DeleteArticlesChapter(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagChapters, tagArticles)
+
+	return u.InfoUsecase.DeleteArticlesChapter(ctx, id)
+}
+
+type cachedBlogs struct {
+	Blogs
+	cache *cache.Cache
+}
+
+func NewCachedBlogs(blogs Blogs, c *cache.Cache) Blogs {
+	return &cachedBlogs{
+		Blogs: blogs,
+		cache: c,
+	}
+}
+
+func (u *cachedBlogs) ListPublications(ctx context.Context, filter entity.PublicationsFilter) ([]*entity.Publications, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Blogs.ListPublications", filter), []string{tagPublications}, func() (interface{}, error) {
+		return u.Blogs.ListPublications(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Publications), nil
+}
+
+func (u *cachedBlogs) ListPublicationsCategories(ctx context.Context, filter entity.CategoriesFilter) ([]*entity.Categories, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Blogs.ListPublicationsCategories", filter), []string{tagCategories}, func() (interface{}, error) {
+		return u.Blogs.ListPublicationsCategories(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Categories), nil
+}
+
+func (u *cachedBlogs) ListAuthors(ctx context.Context, filter entity.AuthorsFilter) ([]*entity.Authors, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Blogs.ListAuthors", filter), []string{tagAuthors}, func() (interface{}, error) {
+		return u.Blogs.ListAuthors(ctx, filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.([]*entity.Authors), nil
+}
+
+func (u *cachedBlogs) GetAuthor(ctx context.Context, id string) (*entity.Authors, error) {
+	value, err := u.cache.Load(ctx, cacheKey("Blogs.GetAuthor", id), []string{rowTag(tagAuthors, id)}, func() (interface{}, error) {
+		return u.Blogs.GetAuthor(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*entity.Authors), nil
+}
+
+func (u *cachedBlogs) CreatePublications(ctx context.Context, req *entity.Publications) (string, error) {
+	defer u.cache.Invalidate(tagPublications)
+
+	return u.Blogs.CreatePublications(ctx, req)
+}
+
+func (u *cachedBlogs) UpdatePublications(ctx context.Context, req *entity.Publications) error {
+	defer u.cache.Invalidate(tagPublications)
+
+	return u.Blogs.UpdatePublications(ctx, req)
+}
+
+func (u *cachedBlogs) DeletePublications(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagPublications)
+
+	return u.Blogs.DeletePublications(ctx, id)
+}
+
+func (u *cachedBlogs) CreatePublicationsCategories(ctx context.Context, req *entity.Categories) (string, error) {
+	defer u.cache.Invalidate(tagCategories)
+
+	return u.Blogs.CreatePublicationsCategories(ctx, req)
+}
+
+func (u *cachedBlogs) UpdatePublicationsCategories(ctx context.Context, req *entity.Categories) error {
+	defer u.cache.Invalidate(tagCategories)
+
+	return u.Blogs.UpdatePublicationsCategories(ctx, req)
+}
+
+// DeletePublicationsCategories deletes publications of the category too
+func (u *cachedBlogs) DeletePublicationsCategories(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagCategories, tagPublications)
+
+	return u.Blogs.DeletePublicationsCategories(ctx, id)
+}
+
+func (u *cachedBlogs) CreateAuthors(ctx context.Context, req *entity.Authors) (string, error) {
+	defer u.cache.Invalidate(tagAuthors)
+
+	return u.Blogs.CreateAuthors(ctx, req)
+}
+
+func (u *cachedBlogs) UpdateAuthors(ctx context.Context, req *entity.Authors) error {
+	defer u.cache.Invalidate(tagAuthors, rowTag(tagAuthors, req.GUID))
+
+	return u.Blogs.UpdateAuthors(ctx, req)
+}
+
+// DeleteAuthors deletes publications of the author too
+func (u *cachedBlogs) DeleteAuthors(ctx context.Context, id string) error {
+	defer u.cache.Invalidate(tagAuthors, rowTag(tagAuthors, id), tagPublications)
+
+	return u.Blogs.DeleteAuthors(ctx, id)
+}
+
+func rowTag(table, id string) string {
+	return table + "/" + id
+}
+
+// cacheKey identifies a read by method and arguments, arguments are plain data so encoding them can't fail
+func cacheKey(method string, args interface{}) string {
+	data, _ := json.Marshal(args)
+	return method + ":" + string(data)
+}
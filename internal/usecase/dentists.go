@@ -6,11 +6,13 @@ import (
 
 	"github.com/AsaHero/abclinic/internal/entity"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 )
 
 type Denstists interface {
 	Get(ctx context.Context, id int64) (*entity.Dentists, error)
-	List(ctx context.Context, filter map[string]string) ([]*entity.Dentists, error)
+	List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error)
+	Create(ctx context.Context, req *entity.Dentists) (int64, error)
 	Update(ctx context.Context, req *entity.Dentists) error
 }
 
@@ -27,20 +29,39 @@ func NewDentistsUsecase(ctxTimeout time.Duration, dentistsRepo repository.Densti
 }
 
 func (u *dentistsUsecase) Get(ctx context.Context, id int64) (*entity.Dentists, error) {
+	ctx, span := tracing.Start(ctx, "Dentists.Get")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	return u.dentistsRepo.Get(ctx, id)
 }
 
-func (u *dentistsUsecase) List(ctx context.Context, filter map[string]string) ([]*entity.Dentists, error) {
+func (u *dentistsUsecase) List(ctx context.Context, filter entity.DentistsFilter) ([]*entity.Dentists, error) {
+	ctx, span := tracing.Start(ctx, "Dentists.List")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	return u.dentistsRepo.List(ctx, filter)
 }
 
+func (u *dentistsUsecase) Create(ctx context.Context, req *entity.Dentists) (int64, error) {
+	ctx, span := tracing.Start(ctx, "Dentists.Create")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	return req.ID, u.dentistsRepo.Create(ctx, req)
+}
+
 func (u *dentistsUsecase) Update(ctx context.Context, req *entity.Dentists) error {
+	ctx, span := tracing.Start(ctx, "Dentists.Update")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
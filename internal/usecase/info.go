@@ -2,19 +2,22 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 )
 
 type InfoUsecase interface {
 	CreateArticle(ctx context.Context, req *entity.Articles) (string, error)
-	ListArticles(ctx context.Context, filter map[string]string) ([]*entity.Articles, error)
+	ListArticles(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error)
 	UpdateArticles(ctx context.Context, req *entity.Articles) error
 	DeleteArticles(ctx context.Context, id string) error
 	CreateArticlesChapter(ctx context.Context, req *entity.Chapters) (string, error)
-	ListArticlesChapters(ctx context.Context, filter map[string]string) ([]*entity.Chapters, error)
+	ListArticlesChapters(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error)
 	UpdateArticlesChapter(ctx context.Context, req *entity.Chapters) error
 	DeleteArticlesChapter(ctx context.Context, id string) error
 }
@@ -35,6 +38,9 @@ func NewinfoUsecase(ctxTimeout time.Duration, articlesRepo repository.Articles,
 }
 
 func (u infoUsecase) CreateArticle(ctx context.Context, req *entity.Articles) (string, error) {
+	ctx, span := tracing.Start(ctx, "Info.CreateArticle")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -42,25 +48,37 @@ func (u infoUsecase) CreateArticle(ctx context.Context, req *entity.Articles) (s
 
 	return req.GUID, u.articlesRepo.Create(ctx, req)
 }
-func (u infoUsecase) ListArticles(ctx context.Context, filter map[string]string) ([]*entity.Articles, error) {
+func (u infoUsecase) ListArticles(ctx context.Context, filter entity.ArticlesFilter) ([]*entity.Articles, error) {
+	ctx, span := tracing.Start(ctx, "Info.ListArticles")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.articlesRepo.List(ctx, filter)
 }
 func (u infoUsecase) UpdateArticles(ctx context.Context, req *entity.Articles) error {
+	ctx, span := tracing.Start(ctx, "Info.UpdateArticles")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.articlesRepo.Update(ctx, req)
 }
 func (u infoUsecase) DeleteArticles(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Info.DeleteArticles")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	return u.articlesRepo.Delete(ctx, map[string]string{"guid": id})
+	return u.articlesRepo.Delete(ctx, entity.ArticlesFilter{GUIDs: []string{id}})
 }
 func (u infoUsecase) CreateArticlesChapter(ctx context.Context, req *entity.Chapters) (string, error) {
+	ctx, span := tracing.Start(ctx, "Info.CreateArticlesChapter")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -68,25 +86,34 @@ func (u infoUsecase) CreateArticlesChapter(ctx context.Context, req *entity.Chap
 
 	return req.GUID, u.chaptersRepo.Create(ctx, req)
 }
-func (u infoUsecase) ListArticlesChapters(ctx context.Context, filter map[string]string) ([]*entity.Chapters, error) {
+func (u infoUsecase) ListArticlesChapters(ctx context.Context, filter entity.ChaptersFilter) ([]*entity.Chapters, error) {
+	ctx, span := tracing.Start(ctx, "Info.ListArticlesChapters")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.chaptersRepo.List(ctx, filter)
 }
 func (u infoUsecase) UpdateArticlesChapter(ctx context.Context, req *entity.Chapters) error {
+	ctx, span := tracing.Start(ctx, "Info.UpdateArticlesChapter")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.chaptersRepo.Update(ctx, req)
 }
 func (u infoUsecase) DeleteArticlesChapter(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Info.DeleteArticlesChapter")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	err := u.articlesRepo.Delete(ctx, map[string]string{"chapter_id": id})
+	err := u.articlesRepo.Delete(ctx, entity.ArticlesFilter{ChapterIDs: []string{id}})
 	if err != nil {
-		if err.Error() != "no sql rows" {
+		if !errors.Is(err, errorspkg.ErrorNotFound) {
 			return err
 		}
 	}
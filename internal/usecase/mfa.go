@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/totp"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrMFARequired       = errors.New("two-factor authentication is required for the role")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+)
+
+const (
+	// totpSkew accepts codes of one step before and after the current one
+	totpSkew           = 1
+	recoveryCodeBytes  = 5
+	recoveryCodesCount = 10
+)
+
+type MFA interface {
+	IsRequired(ctx context.Context, role string) (bool, error)
+	Enroll(ctx context.Context, userID string) (string, string, error)
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Verify(ctx context.Context, userID, code string) error
+	Disable(ctx context.Context, userID string) error
+	ListRoleSettings(ctx context.Context) ([]*entity.RoleSettings, error)
+	SetRoleRequired(ctx context.Context, role string, required bool) error
+}
+
+type mfaUsecase struct {
+	BaseUsecase
+	usersRepo        repository.Users
+	roleSettingsRepo repository.RoleSettings
+	issuer           string
+	ctxTimeout       time.Duration
+}
+
+func NewMFAUsecase(ctxTimeout time.Duration, usersRepo repository.Users, roleSettingsRepo repository.RoleSettings, issuer string) MFA {
+	return &mfaUsecase{
+		usersRepo:        usersRepo,
+		roleSettingsRepo: roleSettingsRepo,
+		issuer:           issuer,
+		ctxTimeout:       ctxTimeout,
+	}
+}
+
+func (u mfaUsecase) IsRequired(ctx context.Context, role string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "MFA.IsRequired")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	settings, err := u.roleSettingsRepo.Get(ctx, role)
+	if err != nil {
+		if errors.Is(err, errorspkg.ErrorNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return settings.MFARequired, nil
+}
+
+// Enroll generates a pending secret and returns it with otpauth URI, it's activated by Confirm
+func (u mfaUsecase) Enroll(ctx context.Context, userID string) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "MFA.Enroll")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{userID}})
+	if err != nil {
+		return "", "", err
+	}
+
+	if user.TOTPEnabled {
+		return "", "", ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	user.TOTPSecret = secret
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.URI(u.issuer, user.Username, secret), nil
+}
+
+// Confirm enables pending secret if code is valid and returns plain recovery codes, only their hashes are stored
+func (u mfaUsecase) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "MFA.Confirm")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{userID}})
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	step, ok := totp.Validate(code, user.TOTPSecret, time.Now(), totpSkew, user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if err := u.useStep(ctx, user.GUID, step); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	user.RecoveryCodes = hashes
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify accepts either a TOTP code of a step after the last accepted one or an unused recovery code, which is consumed
+func (u mfaUsecase) Verify(ctx context.Context, userID, code string) error {
+	ctx, span := tracing.Start(ctx, "MFA.Verify")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{userID}})
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return ErrMFANotEnrolled
+	}
+
+	if step, ok := totp.Validate(code, user.TOTPSecret, time.Now(), totpSkew, user.TOTPLastStep); ok {
+		return u.useStep(ctx, user.GUID, step)
+	}
+
+	// the code is looked up and removed at once, concurrent requests can't both use it
+	if err := u.usersRepo.UseRecoveryCode(ctx, user.GUID, hashRecoveryCode(code)); err != nil {
+		if errors.Is(err, errorspkg.ErrorConflict) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (u mfaUsecase) Disable(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "MFA.Disable")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{userID}})
+	if err != nil {
+		return err
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.RecoveryCodes = nil
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("two-factor authentication disabled", zap.String("target_user_id", user.GUID))
+
+	return nil
+}
+
+func (u mfaUsecase) ListRoleSettings(ctx context.Context) ([]*entity.RoleSettings, error) {
+	ctx, span := tracing.Start(ctx, "MFA.ListRoleSettings")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	return u.roleSettingsRepo.List(ctx)
+}
+
+func (u mfaUsecase) SetRoleRequired(ctx context.Context, role string, required bool) error {
+	ctx, span := tracing.Start(ctx, "MFA.SetRoleRequired")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	settings := &entity.RoleSettings{
+		Role:        role,
+		MFARequired: required,
+	}
+
+	u.BaseUsecase.beforeCreate(nil, nil, &settings.UpdatedAt)
+
+	return u.roleSettingsRepo.Upsert(ctx, settings)
+}
+
+// useStep stores the step of accepted code, a concurrent request which accepted the same code first makes it invalid
+func (u mfaUsecase) useStep(ctx context.Context, userID string, step int64) error {
+	if err := u.usersRepo.UseTOTPStep(ctx, userID, step); err != nil {
+		if errors.Is(err, errorspkg.ErrorConflict) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodesCount; i++ {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(buf))
+		code = code[:4] + "-" + code[4:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode uses plain sha256 since codes are random and single use
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
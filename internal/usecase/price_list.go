@@ -2,19 +2,61 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/money"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+)
+
+var (
+	ErrPriceInPast      = errors.New("price can't take effect in the past")
+	ErrPriceInEffect    = errors.New("price has taken effect, history can't be changed")
+	ErrInvalidPrice     = errors.New("price needs a currency code and non-negative amounts in distinct known tiers")
+	ErrInvalidPriceTier = errors.New("price tier needs a name and a code of lowercase letters, digits and underscores")
+	ErrPriceTierInUse   = errors.New("price tier has prices, it can't be deleted")
+	ErrInvalidCurrency  = errors.New("currency must be an ISO 4217 alphabetic code")
+	// ErrNoExchangeRate is returned when prices are asked in a currency there's no rate to
+	ErrNoExchangeRate      = errors.New("no exchange rate to convert prices")
+	ErrInvalidExchangeRate = errors.New("exchange rate needs two distinct currency codes and a positive rate")
+)
+
+const (
+	priceTierCodeMaxLen = 32
 )
 
 type PriceList interface {
+	// CreateService sets the default currency on a price without one
 	CreateService(ctx context.Context, req *entity.Services) (string, error)
-	ListServices(ctx context.Context, filter map[string]string) ([]*entity.Services, error)
+	// ListServices converts prices to Currency of the options, amounts are rounded to minor units of the currency
+	ListServices(ctx context.Context, filter entity.ServicesFilter, options entity.ServicesListOptions) ([]*entity.Services, error)
+	// UpdateService renames the service, changed prices take effect immediately, nil Prices are left as they are
 	UpdateService(ctx context.Context, req *entity.Services) error
 	DeleteService(ctx context.Context, id string) error
+	// SchedulePrice changes price of the service from ValidFrom, zero is now, history before now is never changed
+	SchedulePrice(ctx context.Context, req *entity.ServicePrices) (string, error)
+	ListPrices(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error)
+	// CancelPrice deletes a price of the service that hasn't taken effect yet
+	CancelPrice(ctx context.Context, serviceID, id string) error
+	CreatePriceTier(ctx context.Context, req *entity.PriceTiers) (string, error)
+	ListPriceTiers(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error)
+	// UpdatePriceTier changes name and position, code is fixed
+	UpdatePriceTier(ctx context.Context, req *entity.PriceTiers) error
+	// DeletePriceTier refuses a tier with prices in history
+	DeletePriceTier(ctx context.Context, id string) error
+	// CreateExchangeRate refuses a pair stored in either direction
+	CreateExchangeRate(ctx context.Context, req *entity.ExchangeRates) (string, error)
+	ListExchangeRates(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error)
+	// UpdateExchangeRate changes the rate, currencies of the pair are fixed
+	UpdateExchangeRate(ctx context.Context, req *entity.ExchangeRates) error
+	DeleteExchangeRate(ctx context.Context, id string) error
 	CreateServiceGroup(ctx context.Context, req *entity.ServiceGroups) (string, error)
-	ListServiceGroups(ctx context.Context, filter map[string]string) ([]*entity.ServiceGroups, error)
+	ListServiceGroups(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error)
 	UpdateServiceGroup(ctx context.Context, req *entity.ServiceGroups) error
 	DeleteServiceGroup(ctx context.Context, id string) error
 }
@@ -24,45 +66,308 @@ type priceListUsecase struct {
 	ctxTimeout    time.Duration
 	serviceRepo   repository.Services
 	serviceGroups repository.ServiceGroups
+	servicePrices repository.ServicePrices
+	priceTiers    repository.PriceTiers
+	exchangeRates repository.ExchangeRates
+	// currency is the default one of prices
+	currency string
 }
 
-func NewPriceListUsecase(ctxTimeout time.Duration, serviceRepo repository.Services, serviceGroupdRepo repository.ServiceGroups) PriceList {
+func NewPriceListUsecase(ctxTimeout time.Duration, serviceRepo repository.Services, serviceGroupdRepo repository.ServiceGroups, servicePricesRepo repository.ServicePrices, priceTiersRepo repository.PriceTiers, exchangeRatesRepo repository.ExchangeRates, currency string) PriceList {
 	return &priceListUsecase{
 		ctxTimeout:    ctxTimeout,
 		serviceRepo:   serviceRepo,
 		serviceGroups: serviceGroupdRepo,
+		servicePrices: servicePricesRepo,
+		priceTiers:    priceTiersRepo,
+		exchangeRates: exchangeRatesRepo,
+		currency:      currency,
 	}
 }
 
 func (u priceListUsecase) CreateService(ctx context.Context, req *entity.Services) (string, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.CreateService")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if err := u.checkPrices(ctx, req.Prices, &req.Currency); err != nil {
+		return "", err
+	}
+
 	u.beforeCreate(&req.GUID, &req.CreatedAt, &req.UpdateAt)
 
 	return req.GUID, u.serviceRepo.Create(ctx, req)
 }
-func (u priceListUsecase) ListServices(ctx context.Context, filter map[string]string) ([]*entity.Services, error) {
+func (u priceListUsecase) ListServices(ctx context.Context, filter entity.ServicesFilter, options entity.ServicesListOptions) ([]*entity.Services, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.ListServices")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	return u.serviceRepo.List(ctx, filter)
+	if options.Currency != "" && !money.Valid(options.Currency) {
+		return nil, ErrInvalidCurrency
+	}
+
+	services, err := u.serviceRepo.List(ctx, filter, options.At)
+	if err != nil || options.Currency == "" {
+		return services, err
+	}
+
+	rates, err := u.exchangeRates.List(ctx, entity.ExchangeRatesFilter{Currencies: []string{options.Currency}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range services {
+		if v.Currency == options.Currency {
+			continue
+		}
+
+		convert, ok := exchange(rates, v.Currency, options.Currency)
+		if !ok {
+			return nil, fmt.Errorf("%w from %s to %s", ErrNoExchangeRate, v.Currency, options.Currency)
+		}
+
+		for i := range v.Prices {
+			v.Prices[i].Amount = convert(v.Prices[i].Amount)
+		}
+		v.Currency = options.Currency
+	}
+
+	return services, nil
 }
 func (u priceListUsecase) UpdateService(ctx context.Context, req *entity.Services) error {
+	ctx, span := tracing.Start(ctx, "PriceList.UpdateService")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	if req.Prices != nil {
+		if err := u.checkPrices(ctx, req.Prices, &req.Currency); err != nil {
+			return err
+		}
+	}
+
 	u.beforeCreate(nil, nil, &req.UpdateAt)
 
-	return u.serviceRepo.Update(ctx, req)
+	if err := u.serviceRepo.Update(ctx, req); err != nil {
+		return err
+	}
+
+	if req.Prices == nil {
+		return nil
+	}
+
+	current, err := u.servicePrices.List(ctx, entity.ServicePricesFilter{ServiceIDs: []string{req.GUID}, At: req.UpdateAt})
+	if err != nil {
+		return err
+	}
+	// unchanged price doesn't make history
+	if len(current) > 0 && current[0].Currency == req.Currency && equalPrices(current[0].Prices, req.Prices) {
+		return nil
+	}
+
+	price := &entity.ServicePrices{
+		ServiceID: req.GUID,
+		Prices:    req.Prices,
+		Currency:  req.Currency,
+		ValidFrom: req.UpdateAt,
+	}
+	u.beforeCreate(&price.GUID, &price.CreatedAt, nil)
+
+	return u.servicePrices.Create(ctx, price)
 }
 func (u priceListUsecase) DeleteService(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PriceList.DeleteService")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	return u.serviceRepo.Delete(ctx, entity.ServicesFilter{GUIDs: []string{id}})
+}
+func (u priceListUsecase) SchedulePrice(ctx context.Context, req *entity.ServicePrices) (string, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.SchedulePrice")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := u.checkPrices(ctx, req.Prices, &req.Currency); err != nil {
+		return "", err
+	}
+
+	u.beforeCreate(&req.GUID, &req.CreatedAt, nil)
+
+	if req.ValidFrom.IsZero() {
+		req.ValidFrom = req.CreatedAt
+	}
+	if req.ValidFrom.Before(req.CreatedAt) {
+		return "", ErrPriceInPast
+	}
+
+	return req.GUID, u.servicePrices.Create(ctx, req)
+}
+func (u priceListUsecase) ListPrices(ctx context.Context, filter entity.ServicePricesFilter) ([]*entity.ServicePrices, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.ListPrices")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	return u.servicePrices.List(ctx, filter)
+}
+func (u priceListUsecase) CancelPrice(ctx context.Context, serviceID, id string) error {
+	ctx, span := tracing.Start(ctx, "PriceList.CancelPrice")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prices, err := u.servicePrices.List(ctx, entity.ServicePricesFilter{GUIDs: []string{id}, ServiceIDs: []string{serviceID}})
+	if err != nil {
+		return err
+	}
+	if len(prices) == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	if !prices[0].ValidFrom.After(time.Now()) {
+		return ErrPriceInEffect
+	}
+
+	return u.servicePrices.Delete(ctx, id)
+}
+func (u priceListUsecase) CreatePriceTier(ctx context.Context, req *entity.PriceTiers) (string, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.CreatePriceTier")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !validPriceTier(req) {
+		return "", ErrInvalidPriceTier
+	}
+
+	u.beforeCreate(&req.GUID, &req.CreatedAt, nil)
+
+	return req.GUID, u.priceTiers.Create(ctx, req)
+}
+func (u priceListUsecase) ListPriceTiers(ctx context.Context, filter entity.PriceTiersFilter) ([]*entity.PriceTiers, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.ListPriceTiers")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	return u.priceTiers.List(ctx, filter)
+}
+func (u priceListUsecase) UpdatePriceTier(ctx context.Context, req *entity.PriceTiers) error {
+	ctx, span := tracing.Start(ctx, "PriceList.UpdatePriceTier")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if strings.TrimSpace(req.Name) == "" {
+		return ErrInvalidPriceTier
+	}
+
+	if err := u.priceTiers.Update(ctx, req); err != nil {
+		return err
+	}
+
+	return nil
+}
+func (u priceListUsecase) DeletePriceTier(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PriceList.DeletePriceTier")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	return u.serviceRepo.Delete(ctx, map[string]string{"guid": id})
+	tiers, err := u.priceTiers.List(ctx, entity.PriceTiersFilter{GUIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+	if len(tiers) == 0 {
+		return errorspkg.ErrorNotFound
+	}
+
+	prices, err := u.servicePrices.List(ctx, entity.ServicePricesFilter{Tiers: []string{tiers[0].Code}})
+	if err != nil {
+		return err
+	}
+	if len(prices) > 0 {
+		return ErrPriceTierInUse
+	}
+
+	return u.priceTiers.Delete(ctx, id)
+}
+func (u priceListUsecase) CreateExchangeRate(ctx context.Context, req *entity.ExchangeRates) (string, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.CreateExchangeRate")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if !money.Valid(req.Base) || !money.Valid(req.Quote) || req.Base == req.Quote || req.Rate <= 0 {
+		return "", ErrInvalidExchangeRate
+	}
+
+	u.beforeCreate(&req.GUID, &req.CreatedAt, &req.UpdatedAt)
+
+	return req.GUID, u.exchangeRates.Create(ctx, req)
+}
+func (u priceListUsecase) ListExchangeRates(ctx context.Context, filter entity.ExchangeRatesFilter) ([]*entity.ExchangeRates, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.ListExchangeRates")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	return u.exchangeRates.List(ctx, filter)
+}
+func (u priceListUsecase) UpdateExchangeRate(ctx context.Context, req *entity.ExchangeRates) error {
+	ctx, span := tracing.Start(ctx, "PriceList.UpdateExchangeRate")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if req.Rate <= 0 {
+		return ErrInvalidExchangeRate
+	}
+
+	u.beforeCreate(nil, nil, &req.UpdatedAt)
+
+	if err := u.exchangeRates.Update(ctx, req); err != nil {
+		return err
+	}
+
+	return nil
+}
+func (u priceListUsecase) DeleteExchangeRate(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PriceList.DeleteExchangeRate")
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := u.exchangeRates.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return nil
 }
 func (u priceListUsecase) CreateServiceGroup(ctx context.Context, req *entity.ServiceGroups) (string, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.CreateServiceGroup")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -70,25 +375,34 @@ func (u priceListUsecase) CreateServiceGroup(ctx context.Context, req *entity.Se
 
 	return req.GUID, u.serviceGroups.Create(ctx, req)
 }
-func (u priceListUsecase) ListServiceGroups(ctx context.Context, filter map[string]string) ([]*entity.ServiceGroups, error) {
+func (u priceListUsecase) ListServiceGroups(ctx context.Context, filter entity.ServiceGroupsFilter) ([]*entity.ServiceGroups, error) {
+	ctx, span := tracing.Start(ctx, "PriceList.ListServiceGroups")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.serviceGroups.List(ctx, filter)
 }
 func (u priceListUsecase) UpdateServiceGroup(ctx context.Context, req *entity.ServiceGroups) error {
+	ctx, span := tracing.Start(ctx, "PriceList.UpdateServiceGroup")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	return u.serviceGroups.Update(ctx, req)
 }
 func (u priceListUsecase) DeleteServiceGroup(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "PriceList.DeleteServiceGroup")
+	defer span.End()
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	err := u.serviceRepo.Delete(ctx, map[string]string{"group_id": id})
+	err := u.serviceRepo.Delete(ctx, entity.ServicesFilter{GroupIDs: []string{id}})
 	if err != nil {
-		if err.Error() != "no sql rows" {
+		if !errors.Is(err, errorspkg.ErrorNotFound) {
 			return err
 		}
 	}
@@ -100,3 +414,78 @@ func (u priceListUsecase) DeleteServiceGroup(ctx context.Context, id string) err
 
 	return nil
 }
+
+// checkPrices sets the default currency if there's none and checks prices against tiers of the clinic
+func (u priceListUsecase) checkPrices(ctx context.Context, prices []entity.TierPrice, currency *string) error {
+	if *currency == "" {
+		*currency = u.currency
+	}
+	if !money.Valid(*currency) || len(prices) == 0 {
+		return ErrInvalidPrice
+	}
+
+	codes := make([]string, 0, len(prices))
+	seen := make(map[string]bool, len(prices))
+	for _, v := range prices {
+		if v.Amount < 0 || seen[v.Tier] {
+			return ErrInvalidPrice
+		}
+		seen[v.Tier] = true
+		codes = append(codes, v.Tier)
+	}
+
+	tiers, err := u.priceTiers.List(ctx, entity.PriceTiersFilter{Codes: codes})
+	if err != nil {
+		return err
+	}
+	if len(tiers) != len(codes) {
+		return ErrInvalidPrice
+	}
+
+	return nil
+}
+
+// equalPrices compares amounts by tier, order of tiers doesn't matter
+func equalPrices(a, b []entity.TierPrice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	amounts := make(map[string]entity.TierPrice, len(a))
+	for _, v := range a {
+		amounts[v.Tier] = v
+	}
+	for _, v := range b {
+		if amount, ok := amounts[v.Tier]; !ok || amount != v {
+			return false
+		}
+	}
+	return true
+}
+
+// exchange finds the rate of the pair in either direction and returns conversion of amounts from one currency to the other
+func exchange(rates []*entity.ExchangeRates, from, to string) (func(amount int64) int64, bool) {
+	for _, v := range rates {
+		rate := v.Rate
+		switch {
+		case v.Base == from && v.Quote == to:
+			return func(amount int64) int64 { return money.Convert(amount, from, to, rate) }, true
+		case v.Base == to && v.Quote == from:
+			return func(amount int64) int64 { return money.ConvertInverse(amount, from, to, rate) }, true
+		}
+	}
+	return nil, false
+}
+
+func validPriceTier(req *entity.PriceTiers) bool {
+	if strings.TrimSpace(req.Name) == "" || req.Code == "" || len(req.Code) > priceTierCodeMaxLen {
+		return false
+	}
+
+	for _, c := range req.Code {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -2,44 +2,75 @@ package usecase
 
 import (
 	"context"
-	// "errors"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"time"
 
 	"github.com/AsaHero/abclinic/internal/entity"
 	errorspkg "github.com/AsaHero/abclinic/internal/errors"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 	"github.com/AsaHero/abclinic/internal/pkg/validation"
+	"go.uber.org/zap"
 )
 
+var (
+	ErrWrongPassword   = errors.New("current password is wrong")
+	ErrPasswordExpired = errors.New("one-time password is expired")
+)
+
+// resetTokenBytes is the entropy of one-time password issued by admin reset
+const resetTokenBytes = 24
+
 type Rbac interface {
 	UserExists(ctx context.Context, id string) (bool, error)
 	UsernameExists(ctx context.Context, username string) (bool, *entity.Users, error)
-	GetUser(ctx context.Context, filter map[string]string) (*entity.Users, error)
+	GetUser(ctx context.Context, filter entity.UsersFilter) (*entity.Users, error)
 	CreateUser(ctx context.Context, req *entity.Users) (string, error)
-	ListUsers(ctx context.Context, filter map[string]string) ([]*entity.Users, error)
+	ListUsers(ctx context.Context, filter entity.UsersFilter) ([]*entity.Users, error)
 	UpdateUser(ctx context.Context, req *entity.Users) error
 	DeleteUser(ctx context.Context, id string) error
+	ChangePassword(ctx context.Context, id, currentPassword, newPassword string) error
+	SetPassword(ctx context.Context, id, newPassword string) error
+	ResetPassword(ctx context.Context, id string) (string, time.Time, error)
+	RevokeTokens(ctx context.Context, id string) error
 }
 
 type rbacUsecase struct {
 	BaseUsecase
-	usersRepo  repository.Users
-	ctxTimeout time.Duration
+	usersRepo        repository.Users
+	refreshTokenRepo repository.RefreshTokenRepo
+	denylist         *denylist.Denylist
+	passwordPolicy   validation.PasswordPolicy
+	accessTTL        time.Duration
+	resetTTL         time.Duration
+	ctxTimeout       time.Duration
 }
 
-func NewRbacUsecase(ctxTimeout time.Duration, usersRepo repository.Users) Rbac {
+func NewRbacUsecase(ctxTimeout time.Duration, usersRepo repository.Users, refreshTokenRepo repository.RefreshTokenRepo, denylist *denylist.Denylist, passwordPolicy validation.PasswordPolicy, accessTTL, resetTTL time.Duration) Rbac {
 	return &rbacUsecase{
-		usersRepo:  usersRepo,
-		ctxTimeout: ctxTimeout,
+		usersRepo:        usersRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		denylist:         denylist,
+		passwordPolicy:   passwordPolicy,
+		accessTTL:        accessTTL,
+		resetTTL:         resetTTL,
+		ctxTimeout:       ctxTimeout,
 	}
 }
 
 func (u rbacUsecase) UserExists(ctx context.Context, id string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.UserExists")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	var isExists bool = true
-	_, err := u.usersRepo.Get(ctx, map[string]string{"guid": id})
+	_, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{id}})
 	if err != nil {
 		if err == errorspkg.ErrorNotFound {
 			isExists = false
@@ -52,11 +83,14 @@ func (u rbacUsecase) UserExists(ctx context.Context, id string) (bool, error) {
 
 }
 func (u rbacUsecase) UsernameExists(ctx context.Context, username string) (bool, *entity.Users, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.UsernameExists")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	var isExists bool = true
-	user, err := u.usersRepo.Get(ctx, map[string]string{"username": username})
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{Username: username})
 	if err != nil {
 		if err == errorspkg.ErrorNotFound {
 			isExists = false
@@ -68,16 +102,26 @@ func (u rbacUsecase) UsernameExists(ctx context.Context, username string) (bool,
 	return isExists, user, nil
 
 }
-func (u rbacUsecase) GetUser(ctx context.Context, filter map[string]string) (*entity.Users, error) {
+func (u rbacUsecase) GetUser(ctx context.Context, filter entity.UsersFilter) (*entity.Users, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.GetUser")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	return u.usersRepo.Get(ctx, filter)
 }
 func (u rbacUsecase) CreateUser(ctx context.Context, req *entity.Users) (string, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.CreateUser")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
+	if err := u.passwordPolicy.Validate(req.Password, req.Username); err != nil {
+		return "", err
+	}
+
 	passwordHash, err := validation.HashPassword(req.Password)
 	if err != nil {
 		return "", err
@@ -89,30 +133,206 @@ func (u rbacUsecase) CreateUser(ctx context.Context, req *entity.Users) (string,
 
 	return req.GUID, u.usersRepo.Create(ctx, req)
 }
-func (u rbacUsecase) ListUsers(ctx context.Context, filter map[string]string) ([]*entity.Users, error) {
+func (u rbacUsecase) ListUsers(ctx context.Context, filter entity.UsersFilter) ([]*entity.Users, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.ListUsers")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
 	return u.usersRepo.List(ctx, filter)
 }
 func (u rbacUsecase) UpdateUser(ctx context.Context, req *entity.Users) error {
+	ctx, span := tracing.Start(ctx, "Rbac.UpdateUser")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
-	passwordHash, err := validation.HashPassword(req.Password)
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{req.GUID}})
 	if err != nil {
 		return err
 	}
 
-	req.Password = passwordHash
+	credentialsChanged := user.Role != req.Role || req.Password != ""
+
+	// second factor and identity provider link are managed by MFA and SSO usecases only
+	req.TOTPSecret = user.TOTPSecret
+	req.TOTPEnabled = user.TOTPEnabled
+	req.RecoveryCodes = user.RecoveryCodes
+	req.SSOSubject = user.SSOSubject
+
+	// empty password keeps the current one
+	if req.Password == "" {
+		req.Password = user.Password
+		req.MustChangePassword = user.MustChangePassword
+		req.PasswordExpiresAt = user.PasswordExpiresAt
+	} else {
+		if err := u.passwordPolicy.Validate(req.Password, req.Username); err != nil {
+			return err
+		}
+
+		passwordHash, err := validation.HashPassword(req.Password)
+		if err != nil {
+			return err
+		}
+
+		req.Password = passwordHash
+	}
 
 	u.BaseUsecase.beforeCreate(nil, nil, &req.UpdatedAt)
 
-	return u.usersRepo.Update(ctx, req)
+	if err := u.usersRepo.Update(ctx, req); err != nil {
+		return err
+	}
+
+	if credentialsChanged {
+		return u.revokeTokens(ctx, req.GUID)
+	}
+
+	return nil
 }
 func (u rbacUsecase) DeleteUser(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Rbac.DeleteUser")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	if err := u.usersRepo.Delete(ctx, entity.UsersFilter{GUIDs: []string{id}}); err != nil {
+		return err
+	}
+
+	return u.revokeTokens(ctx, id)
+}
+
+func (u rbacUsecase) ChangePassword(ctx context.Context, id, currentPassword, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "Rbac.ChangePassword")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+
+	if !validation.CheckPasswordHash(currentPassword, user.Password) {
+		return ErrWrongPassword
+	}
+
+	if user.PasswordExpiresAt != nil && time.Now().After(*user.PasswordExpiresAt) {
+		return ErrPasswordExpired
+	}
+
+	return u.setPassword(ctx, user, newPassword)
+}
+
+// SetPassword replaces password of the user without checking the current one
+func (u rbacUsecase) SetPassword(ctx context.Context, id, newPassword string) error {
+	ctx, span := tracing.Start(ctx, "Rbac.SetPassword")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
 	defer cancel()
 
-	return u.usersRepo.Delete(ctx, map[string]string{"guid": id})
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{id}})
+	if err != nil {
+		return err
+	}
+
+	return u.setPassword(ctx, user, newPassword)
+}
+
+// ResetPassword replaces password with a one-time token which must be changed on next login
+func (u rbacUsecase) ResetPassword(ctx context.Context, id string) (string, time.Time, error) {
+	ctx, span := tracing.Start(ctx, "Rbac.ResetPassword")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{GUIDs: []string{id}})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	buf := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	resetToken := base64.RawURLEncoding.EncodeToString(buf)
+
+	passwordHash, err := validation.HashPassword(resetToken)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(u.resetTTL)
+
+	user.Password = passwordHash
+	user.MustChangePassword = true
+	user.PasswordExpiresAt = &expiresAt
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return "", time.Time{}, err
+	}
+
+	if err := u.revokeTokens(ctx, user.GUID); err != nil {
+		return "", time.Time{}, err
+	}
+
+	logger.FromContext(ctx).Info("password reset issued", zap.String("target_user_id", user.GUID), zap.Time("expires_at", expiresAt))
+
+	return resetToken, expiresAt, nil
+}
+
+// RevokeTokens signs the user out of every session
+func (u rbacUsecase) RevokeTokens(ctx context.Context, id string) error {
+	ctx, span := tracing.Start(ctx, "Rbac.RevokeTokens")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	if err := u.revokeTokens(ctx, id); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Info("user tokens revoked", zap.String("target_user_id", id))
+
+	return nil
+}
+
+func (u rbacUsecase) setPassword(ctx context.Context, user *entity.Users, newPassword string) error {
+	if err := u.passwordPolicy.Validate(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	passwordHash, err := validation.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = passwordHash
+	user.MustChangePassword = false
+	user.PasswordExpiresAt = nil
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return u.revokeTokens(ctx, user.GUID)
+}
+
+// revokeTokens invalidates all outstanding access and refresh tokens of the user
+func (u rbacUsecase) revokeTokens(ctx context.Context, userID string) error {
+	u.denylist.RevokeUser(userID, u.accessTTL)
+
+	return u.refreshTokenRepo.DeleteByUserID(ctx, userID)
 }
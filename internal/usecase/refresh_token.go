@@ -7,6 +7,7 @@ import (
 	"github.com/AsaHero/abclinic/internal/entity"
 	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
 	"github.com/AsaHero/abclinic/internal/pkg/token"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
 	"github.com/google/uuid"
 )
 
@@ -14,7 +15,7 @@ type RefreshToken interface {
 	Get(ctx context.Context, refreshToken string) (*entity.RefreshToken, error)
 	Create(ctx context.Context, m *entity.RefreshToken) error
 	Delete(ctx context.Context, refreshToken string) error
-	GenerateToken(ctx context.Context, sub, tokenType, jwtSecret string, accessTTL, refreshTTL time.Duration, optionalFields ...map[string]interface{}) (string, string, error)
+	GenerateToken(ctx context.Context, sub, userID string, keys *token.KeySet, accessTTL, refreshTTL time.Duration) (string, string, error)
 }
 
 type refreshTokenService struct {
@@ -36,6 +37,9 @@ func (r *refreshTokenService) beforeCreate(m *entity.RefreshToken) error {
 }
 
 func (r *refreshTokenService) Get(ctx context.Context, refreshToken string) (*entity.RefreshToken, error) {
+	ctx, span := tracing.Start(ctx, "RefreshToken.Get")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
 	defer cancel()
 
@@ -43,6 +47,9 @@ func (r *refreshTokenService) Get(ctx context.Context, refreshToken string) (*en
 }
 
 func (r *refreshTokenService) Create(ctx context.Context, m *entity.RefreshToken) error {
+	ctx, span := tracing.Start(ctx, "RefreshToken.Create")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
 	defer cancel()
 
@@ -51,22 +58,29 @@ func (r *refreshTokenService) Create(ctx context.Context, m *entity.RefreshToken
 }
 
 func (r *refreshTokenService) Delete(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "RefreshToken.Delete")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
 	defer cancel()
 
 	return r.repo.Delete(ctx, refreshToken)
 }
 
-func (r *refreshTokenService) GenerateToken(ctx context.Context, sub, userID, jwtSecret string, accessTTL, refreshTTL time.Duration, optionalFields ...map[string]interface{}) (string, string, error) {
+func (r *refreshTokenService) GenerateToken(ctx context.Context, sub, userID string, keys *token.KeySet, accessTTL, refreshTTL time.Duration) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "RefreshToken.GenerateToken")
+	defer span.End()
+
 	ctx, cancel := context.WithTimeout(ctx, r.ctxTimeout)
 	defer cancel()
 
-	accessToken, refreshToken, err := token.GenerateToken(sub, userID, jwtSecret, accessTTL, refreshTTL, optionalFields...)
+	accessToken, refreshToken, err := token.GenerateToken(sub, userID, keys, accessTTL, refreshTTL)
 	if err != nil {
 		return "", "", err
 	}
 
 	m := entity.RefreshToken{
+		UserID:       userID,
 		RefreshToken: refreshToken,
 		ExpiryDate:   time.Now().Add(refreshTTL),
 	}
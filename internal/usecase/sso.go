@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AsaHero/abclinic/internal/entity"
+	errorspkg "github.com/AsaHero/abclinic/internal/errors"
+	"github.com/AsaHero/abclinic/internal/infrastructure/repository"
+	"github.com/AsaHero/abclinic/internal/pkg/denylist"
+	"github.com/AsaHero/abclinic/internal/pkg/logger"
+	"github.com/AsaHero/abclinic/internal/pkg/oidc"
+	"github.com/AsaHero/abclinic/internal/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSSODisabled      = errors.New("single sign-on is not configured")
+	ErrInvalidSSOState  = errors.New("invalid or expired sso state")
+	ErrSSONoRole        = errors.New("identity provider account has no role in the clinic")
+	ErrSSOUsernameTaken = errors.New("username is already used by a local account")
+	ErrSSOExchange      = errors.New("identity provider rejected login")
+)
+
+// SSORoleMapping grants Role to members of identity provider Group
+type SSORoleMapping struct {
+	Group string
+	Role  string
+}
+
+type SSOOptions struct {
+	// GroupsClaim is the id token claim holding groups of the user
+	GroupsClaim string
+	// RoleMapping is ordered by precedence, the first matching group wins
+	RoleMapping []SSORoleMapping
+	StateTTL    time.Duration
+}
+
+type SSO interface {
+	Enabled() bool
+	AuthorizationURL(ctx context.Context) (string, string, error)
+	Callback(ctx context.Context, code, state string) (*entity.Users, error)
+}
+
+type ssoUsecase struct {
+	BaseUsecase
+	usersRepo        repository.Users
+	refreshTokenRepo repository.RefreshTokenRepo
+	denylist         *denylist.Denylist
+	provider         *oidc.Provider
+	sessions         oidc.SessionStore
+	options          SSOOptions
+	accessTTL        time.Duration
+	ctxTimeout       time.Duration
+}
+
+// NewSSOUsecase returns disabled usecase if provider is nil
+func NewSSOUsecase(ctxTimeout time.Duration, usersRepo repository.Users, refreshTokenRepo repository.RefreshTokenRepo, denylist *denylist.Denylist, provider *oidc.Provider, sessions oidc.SessionStore, options SSOOptions, accessTTL time.Duration) SSO {
+	return &ssoUsecase{
+		usersRepo:        usersRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		denylist:         denylist,
+		provider:         provider,
+		sessions:         sessions,
+		options:          options,
+		accessTTL:        accessTTL,
+		ctxTimeout:       ctxTimeout,
+	}
+}
+
+func (u ssoUsecase) Enabled() bool {
+	return u.provider != nil
+}
+
+// AuthorizationURL starts authorization code flow and returns provider URL with its state. Nonce and PKCE verifier
+// are kept by the state until callback, the caller binds the state to the browser.
+func (u ssoUsecase) AuthorizationURL(ctx context.Context) (string, string, error) {
+	ctx, span := tracing.Start(ctx, "SSO.AuthorizationURL")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	if !u.Enabled() {
+		return "", "", ErrSSODisabled
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := u.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	u.sessions.Save(state, oidc.Session{
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}, u.options.StateTTL)
+
+	return authURL, state, nil
+}
+
+// Callback redeems authorization code and returns the user, provisioning it on first login
+func (u ssoUsecase) Callback(ctx context.Context, code, state string) (*entity.Users, error) {
+	ctx, span := tracing.Start(ctx, "SSO.Callback")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, u.ctxTimeout)
+	defer cancel()
+
+	if !u.Enabled() {
+		return nil, ErrSSODisabled
+	}
+
+	session, ok := u.sessions.Take(state)
+	if !ok {
+		return nil, ErrInvalidSSOState
+	}
+
+	claims, err := u.provider.Exchange(ctx, code, session.CodeVerifier, session.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSSOExchange, err)
+	}
+
+	role := u.role(claims.Strings(u.options.GroupsClaim))
+	if role == "" {
+		return nil, ErrSSONoRole
+	}
+
+	subject := claims.Issuer + "|" + claims.Subject
+
+	user, err := u.usersRepo.Get(ctx, entity.UsersFilter{SSOSubject: subject})
+	if err != nil {
+		if !errors.Is(err, errorspkg.ErrorNotFound) {
+			return nil, err
+		}
+		return u.provision(ctx, subject, role, claims)
+	}
+
+	// identity provider is the source of truth for role and names
+	if user.Role == role && user.Firstname == claims.GivenName && user.Lastname == claims.FamilyName {
+		return user, nil
+	}
+
+	roleChanged := user.Role != role
+
+	user.Role = role
+	user.Firstname = claims.GivenName
+	user.Lastname = claims.FamilyName
+
+	u.BaseUsecase.beforeCreate(nil, nil, &user.UpdatedAt)
+
+	if err := u.usersRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if roleChanged {
+		logger.FromContext(ctx).Info("sso role changed, revoking tokens", zap.String("target_user_id", user.GUID), zap.String("assigned_role", role))
+		u.denylist.RevokeUser(user.GUID, u.accessTTL)
+		if err := u.refreshTokenRepo.DeleteByUserID(ctx, user.GUID); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// provision creates user for identity provider account, it has no password so it can't use password login
+func (u ssoUsecase) provision(ctx context.Context, subject, role string, claims *oidc.Claims) (*entity.Users, error) {
+	username := claims.PreferredUsername
+	if username == "" {
+		username = claims.Email
+	}
+	if username == "" {
+		username = claims.Subject
+	}
+
+	_, err := u.usersRepo.Get(ctx, entity.UsersFilter{Username: username})
+	if err == nil {
+		return nil, ErrSSOUsernameTaken
+	}
+	if !errors.Is(err, errorspkg.ErrorNotFound) {
+		return nil, err
+	}
+
+	user := &entity.Users{
+		Role:       role,
+		Firstname:  claims.GivenName,
+		Lastname:   claims.FamilyName,
+		Username:   username,
+		SSOSubject: subject,
+	}
+
+	u.BaseUsecase.beforeCreate(&user.GUID, &user.CreatedAt, &user.UpdatedAt)
+
+	if err := u.usersRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Info("sso user provisioned", zap.String("target_user_id", user.GUID), zap.String("assigned_role", role))
+
+	return user, nil
+}
+
+func (u ssoUsecase) role(groups []string) string {
+	for _, mapping := range u.options.RoleMapping {
+		for _, group := range groups {
+			if group == mapping.Group {
+				return mapping.Role
+			}
+		}
+	}
+
+	return ""
+}
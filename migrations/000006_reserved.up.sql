@@ -0,0 +1,2 @@
+-- version 000006 is not used, this no-op keeps the sequence contiguous
+SELECT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+
+ALTER TABLE IF EXISTS "refresh_tokens" DROP COLUMN IF EXISTS "user_id";
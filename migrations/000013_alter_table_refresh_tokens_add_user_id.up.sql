@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "refresh_tokens" ADD COLUMN IF NOT EXISTS "user_id" UUID;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_id_idx ON "refresh_tokens" ("user_id");
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "password_expires_at";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "must_change_password";
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "must_change_password" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "password_expires_at" TIMESTAMP(0) WITH TIME ZONE;
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "recovery_codes";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "totp_enabled";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "totp_secret";
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "totp_secret" TEXT NOT NULL DEFAULT '';
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "totp_enabled" BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "recovery_codes" TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "role_settings" (
+    "role" CHARACTER VARYING(64) NOT NULL,
+    "mfa_required" BOOLEAN NOT NULL DEFAULT FALSE,
+    "updated_at" TIMESTAMP(0) WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT role_settings_role_pkey PRIMARY KEY (role)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "users_sso_subject_idx";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "sso_subject";
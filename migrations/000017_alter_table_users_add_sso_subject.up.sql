@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS "users" ADD COLUMN IF NOT EXISTS "sso_subject" TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS "users_sso_subject_idx" ON "users" ("sso_subject") WHERE "sso_subject" <> '';
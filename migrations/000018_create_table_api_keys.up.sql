@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "guid" UUID NOT NULL,
+    "name" CHARACTER VARYING(128) NOT NULL,
+    "prefix" CHARACTER VARYING(32) NOT NULL,
+    "key_hash" TEXT NOT NULL,
+    "role" CHARACTER VARYING(64) NOT NULL,
+    "scopes" TEXT[] NOT NULL DEFAULT '{}',
+    "created_by" UUID,
+    "last_used_at" TIMESTAMP(0) WITH TIME ZONE,
+    "expires_at" TIMESTAMP(0) WITH TIME ZONE,
+    "revoked_at" TIMESTAMP(0) WITH TIME ZONE,
+    "created_at" TIMESTAMP(0) WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP(0) WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT api_keys_guid_pkey PRIMARY KEY (guid)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "api_keys_prefix_idx" ON "api_keys" ("prefix");
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "dentists" DROP CONSTRAINT IF EXISTS dentists_id_pkey;
\ No newline at end of file
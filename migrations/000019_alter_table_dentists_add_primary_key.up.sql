@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "dentists" ADD CONSTRAINT dentists_id_pkey PRIMARY KEY (id);
\ No newline at end of file
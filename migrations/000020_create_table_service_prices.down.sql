@@ -0,0 +1,11 @@
+ALTER TABLE IF EXISTS "services" ADD COLUMN IF NOT EXISTS "price" NUMERIC[];
+
+UPDATE "services" SET "price" = p."price" FROM "service_prices" p
+WHERE p."service_id" = "services"."guid" AND p."valid_from" <= now() AND (p."valid_to" IS NULL OR p."valid_to" > now());
+
+-- services with only scheduled prices had no price yet
+UPDATE "services" SET "price" = '{0,0}' WHERE "price" IS NULL;
+
+ALTER TABLE IF EXISTS "services" ALTER COLUMN "price" SET NOT NULL;
+
+DROP TABLE IF EXISTS "service_prices";
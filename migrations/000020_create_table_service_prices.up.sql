@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "service_prices" (
+    "guid" UUID NOT NULL,
+    "service_id" UUID NOT NULL,
+    "price" NUMERIC[] NOT NULL,
+    "valid_from" TIMESTAMP WITH TIME ZONE NOT NULL,
+    "valid_to" TIMESTAMP WITH TIME ZONE,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT service_prices_guid_pkey PRIMARY KEY (guid),
+    CONSTRAINT service_prices_period_check CHECK ("valid_to" IS NULL OR "valid_to" > "valid_from")
+);
+
+ALTER TABLE IF EXISTS "service_prices" ADD CONSTRAINT "service_prices_service_id_fkey" FOREIGN KEY("service_id") REFERENCES services("guid") ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS "service_prices_service_id_valid_from_idx" ON "service_prices" ("service_id", "valid_from");
+
+-- current prices start the history, they are in effect since their service was created
+INSERT INTO "service_prices" ("guid", "service_id", "price", "valid_from")
+SELECT md5("guid"::text || '/price')::uuid, "guid", "price", COALESCE("created_at", now()) FROM "services";
+
+ALTER TABLE IF EXISTS "services" DROP COLUMN IF EXISTS "price";
@@ -0,0 +1,13 @@
+ALTER TABLE IF EXISTS "service_prices" ADD COLUMN IF NOT EXISTS "price" NUMERIC[];
+
+-- other tiers and currencies don't fit the array, absent regular and urgent price are 0
+UPDATE "service_prices" p SET "price" = ARRAY[
+    COALESCE((SELECT "amount" FROM "service_price_tiers" WHERE "service_price_id" = p."guid" AND "tier" = 'regular'), 0),
+    COALESCE((SELECT "amount" FROM "service_price_tiers" WHERE "service_price_id" = p."guid" AND "tier" = 'urgent'), 0)
+];
+
+ALTER TABLE IF EXISTS "service_prices" ALTER COLUMN "price" SET NOT NULL;
+ALTER TABLE IF EXISTS "service_prices" DROP COLUMN IF EXISTS "currency";
+
+DROP TABLE IF EXISTS "service_price_tiers";
+DROP TABLE IF EXISTS "price_tiers";
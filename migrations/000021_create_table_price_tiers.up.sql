@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS "price_tiers" (
+    "guid" UUID NOT NULL,
+    "code" CHARACTER VARYING(32) NOT NULL,
+    "name" CHARACTER VARYING(128) NOT NULL,
+    "position" INTEGER NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT price_tiers_guid_pkey PRIMARY KEY (guid)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "price_tiers_code_idx" ON "price_tiers" ("code");
+
+-- positions of the price array were regular and urgent price
+INSERT INTO "price_tiers" ("guid", "code", "name", "position") VALUES
+    (md5('price_tiers/regular')::uuid, 'regular', 'Regular', 1),
+    (md5('price_tiers/urgent')::uuid, 'urgent', 'Urgent', 2);
+
+CREATE TABLE IF NOT EXISTS "service_price_tiers" (
+    "service_price_id" UUID NOT NULL,
+    "tier" CHARACTER VARYING(32) NOT NULL,
+    "amount" NUMERIC NOT NULL,
+    "is_from" BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT service_price_tiers_pkey PRIMARY KEY (service_price_id, tier),
+    CONSTRAINT service_price_tiers_amount_check CHECK ("amount" >= 0)
+);
+
+ALTER TABLE IF EXISTS "service_price_tiers" ADD CONSTRAINT "service_price_tiers_service_price_id_fkey" FOREIGN KEY("service_price_id") REFERENCES service_prices("guid") ON DELETE CASCADE;
+ALTER TABLE IF EXISTS "service_price_tiers" ADD CONSTRAINT "service_price_tiers_tier_fkey" FOREIGN KEY("tier") REFERENCES price_tiers("code");
+
+INSERT INTO "service_price_tiers" ("service_price_id", "tier", "amount")
+SELECT "guid", 'regular', "price"[1] FROM "service_prices" WHERE "price"[1] IS NOT NULL;
+
+INSERT INTO "service_price_tiers" ("service_price_id", "tier", "amount")
+SELECT "guid", 'urgent', "price"[2] FROM "service_prices" WHERE "price"[2] IS NOT NULL;
+
+-- prices were quoted in the local currency, UZS is also the default of price_list.currency,
+-- deployments that quoted in another currency update these rows after migrating
+ALTER TABLE IF EXISTS "service_prices" ADD COLUMN IF NOT EXISTS "currency" CHARACTER VARYING(3) NOT NULL DEFAULT 'UZS';
+ALTER TABLE IF EXISTS "service_prices" ALTER COLUMN "currency" DROP DEFAULT;
+
+ALTER TABLE IF EXISTS "service_prices" DROP COLUMN IF EXISTS "price";
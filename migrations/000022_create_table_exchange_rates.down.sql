@@ -0,0 +1,15 @@
+ALTER TABLE IF EXISTS "service_price_tiers" ADD COLUMN IF NOT EXISTS "amount_major" NUMERIC;
+
+UPDATE "service_price_tiers" t SET "amount_major" = t."amount" / POWER(10::NUMERIC, CASE
+    WHEN p."currency" IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+    WHEN p."currency" IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+    ELSE 2
+END)
+FROM "service_prices" p WHERE p."guid" = t."service_price_id";
+
+ALTER TABLE IF EXISTS "service_price_tiers" DROP COLUMN IF EXISTS "amount";
+ALTER TABLE IF EXISTS "service_price_tiers" RENAME COLUMN "amount_major" TO "amount";
+ALTER TABLE IF EXISTS "service_price_tiers" ALTER COLUMN "amount" SET NOT NULL;
+ALTER TABLE IF EXISTS "service_price_tiers" ADD CONSTRAINT "service_price_tiers_amount_check" CHECK ("amount" >= 0);
+
+DROP TABLE IF EXISTS "exchange_rates";
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS "exchange_rates" (
+    "guid" UUID NOT NULL,
+    "base" CHARACTER VARYING(3) NOT NULL,
+    "quote" CHARACTER VARYING(3) NOT NULL,
+    "rate" NUMERIC NOT NULL,
+    "created_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT exchange_rates_guid_pkey PRIMARY KEY (guid),
+    CONSTRAINT exchange_rates_rate_check CHECK ("rate" > 0),
+    CONSTRAINT exchange_rates_pair_check CHECK ("base" <> "quote")
+);
+
+-- a pair converts both ways, it's stored once for either direction
+CREATE UNIQUE INDEX IF NOT EXISTS "exchange_rates_pair_idx" ON "exchange_rates" (LEAST("base", "quote"), GREATEST("base", "quote"));
+
+-- amounts are kept in minor units of the currency of their price, ISO 4217 exponents other than 2 are listed
+ALTER TABLE IF EXISTS "service_price_tiers" ADD COLUMN IF NOT EXISTS "amount_minor" BIGINT;
+
+UPDATE "service_price_tiers" t SET "amount_minor" = ROUND(t."amount" * POWER(10::NUMERIC, CASE
+    WHEN p."currency" IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+    WHEN p."currency" IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+    ELSE 2
+END))
+FROM "service_prices" p WHERE p."guid" = t."service_price_id";
+
+ALTER TABLE IF EXISTS "service_price_tiers" DROP COLUMN IF EXISTS "amount";
+ALTER TABLE IF EXISTS "service_price_tiers" RENAME COLUMN "amount_minor" TO "amount";
+ALTER TABLE IF EXISTS "service_price_tiers" ALTER COLUMN "amount" SET NOT NULL;
+ALTER TABLE IF EXISTS "service_price_tiers" ADD CONSTRAINT "service_price_tiers_amount_check" CHECK ("amount" >= 0);
@@ -0,0 +1,9 @@
+// Package migrations embeds SQL schema migrations so the binary can apply them
+package migrations
+
+import "embed"
+
+// FS holds <version>_<name>.up.sql and <version>_<name>.down.sql files
+//
+//go:embed *.sql
+var FS embed.FS